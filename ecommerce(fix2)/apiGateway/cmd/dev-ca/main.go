@@ -0,0 +1,171 @@
+// dev-ca — локальный CA для mTLS между gateway и сервисами. Создает CA, если
+// его еще нет, и выпускает каждому сервису сертификат с его именем в CN:
+//
+//	go run ./cmd/dev-ca -out ../certs
+//
+// Повторный запуск выпускает новые сертификаты тем же CA: запущенные сервисы
+// подхватят их сами. С -new-ca заменяется и CA; сервисы, которые перечитают
+// файлы в разное время, до этого не смогут соединиться друг с другом.
+package main
+
+import (
+	grpcDelivery "apiGateway/internal/grpc"
+	"apiGateway/internal/logging"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"io/fs"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// services — имена, по которым сервисы узнают друг друга (CN сертификата)
+var services = []string{
+	"apiGateway",
+	grpcDelivery.UserServiceIdentity,
+	grpcDelivery.InventoryServiceIdentity,
+	grpcDelivery.OrderServiceIdentity,
+}
+
+func main() {
+	out := flag.String("out", "../certs", "directory for ca.crt and <service>.crt/.key")
+	hosts := flag.String("hosts", "localhost,127.0.0.1", "comma-separated extra DNS names and IPs for service certificates")
+	days := flag.Int("days", 90, "service certificate lifetime in days")
+	newCA := flag.Bool("new-ca", false, "replace the existing CA")
+	flag.Parse()
+
+	if err := os.MkdirAll(*out, 0o700); err != nil {
+		logging.Fatal("failed to create output directory", "error", err)
+	}
+	ca, caKey, err := loadCA(*out)
+	if errors.Is(err, fs.ErrNotExist) || *newCA {
+		if ca, caKey, err = createCA(*out); err == nil {
+			slog.Info("created CA", "file", filepath.Join(*out, "ca.crt"))
+		}
+	}
+	if err != nil {
+		logging.Fatal("failed to prepare CA", "error", err)
+	}
+
+	lifetime := time.Duration(*days) * 24 * time.Hour
+	for _, name := range services {
+		if err := issue(*out, name, strings.Split(*hosts, ","), lifetime, ca, caKey); err != nil {
+			logging.Fatal("failed to issue certificate", "service", name, "error", err)
+		}
+		slog.Info("issued certificate", "service", name, "file", filepath.Join(*out, name+".crt"), "days", *days)
+	}
+}
+
+func loadCA(dir string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	pair, err := tls.LoadX509KeyPair(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key"))
+	if err != nil {
+		return nil, nil, err
+	}
+	key, ok := pair.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, nil, errors.New("ca.key is not an ECDSA key")
+	}
+	return pair.Leaf, key, nil
+}
+
+func createCA(dir string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial(),
+		Subject:               pkix.Name{CommonName: "ecommerce dev CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := writePair(dir, "ca", der, key); err != nil {
+		return nil, nil, err
+	}
+	ca, err := x509.ParseCertificate(der)
+	return ca, key, err
+}
+
+// issue выпускает сертификат сервиса name. Он годится и для сервера, и для
+// клиента: gateway и сервисы предъявляют один и тот же сертификат.
+func issue(dir, name string, hosts []string, lifetime time.Duration, ca *x509.Certificate, caKey *ecdsa.PrivateKey) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial(),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(lifetime),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{name},
+	}
+	for _, h := range hosts {
+		if h = strings.TrimSpace(h); h == "" {
+			continue
+		}
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+	return writePair(dir, name, der, key)
+}
+
+// writePair пишет name.key и name.crt. Каждый файл заменяется целиком через
+// rename, чтобы сервис не прочитал его наполовину записанным; ключ пишется
+// первым, а несовпадающую пару сервис отбросит до следующей проверки.
+func writePair(dir, name string, der []byte, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	if err := writeFile(filepath.Join(dir, name+".key"), keyPEM, 0o600); err != nil {
+		return err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return writeFile(filepath.Join(dir, name+".crt"), certPEM, 0o644)
+}
+
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func serial() *big.Int {
+	n, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		logging.Fatal("failed to generate serial number", "error", err)
+	}
+	return n
+}
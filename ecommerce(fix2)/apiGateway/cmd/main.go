@@ -2,47 +2,258 @@
 package main
 
 import (
+	"apiGateway/internal/config"
 	"apiGateway/internal/delivery/handlers"
 	grpcDelivery "apiGateway/internal/grpc"
+	"apiGateway/internal/lifecycle"
+	"apiGateway/internal/logging"
+	"apiGateway/internal/message"
+	"apiGateway/internal/metrics"
 	"apiGateway/internal/middleware"
-	"log"
-	_ "net/http"
+	"apiGateway/internal/mtls"
+	"apiGateway/internal/oidc"
+	"apiGateway/internal/tracing"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
 	_ "net/http/httputil"
 	_ "net/url"
+	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func main() {
-	// Initialize gRPC clients
-	userClient, err := grpcDelivery.NewUserClient("localhost:50053")
+	// Load config: YAML file, environment, flags
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		logging.Fatal("invalid config", "error", err)
+	}
+	if cfg.PrintConfig {
+		fmt.Println(cfg.YAML())
+		return
+	}
+	if err := logging.Setup(cfg.Log.Level); err != nil {
+		logging.Fatal("invalid log level", "error", err)
+	}
+	slog.Info("API Gateway config", "config", cfg.String())
+
+	// Graceful shutdown on SIGTERM; steps run in reverse registration order
+	app := lifecycle.New(cfg.Shutdown.Timeout)
+
+	// Prometheus /metrics on a separate port; stopped last
+	if cfg.Metrics.Addr != "" {
+		app.OnShutdown("metrics server", metrics.Serve(cfg.Metrics.Addr).Shutdown)
+	}
+
+	// OpenTelemetry tracing; the provider is shut down after the HTTP server
+	// and flushes the remaining spans
+	shutdownTracing, err := tracing.Setup(context.Background(), "apiGateway", cfg.Tracing.Exporter, cfg.Tracing.Endpoint)
+	if err != nil {
+		logging.Fatal("failed to set up tracing", "error", err)
+	}
+	app.OnShutdown("tracing", shutdownTracing)
+
+	// mTLS certificates for calls to the services; reloaded when the files are replaced
+	certs, err := mtls.Load(cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.CAFile)
+	if err != nil {
+		logging.Fatal("failed to load TLS certificates", "error", err)
+	}
+	app.OnShutdown("TLS reload", certs.Watch(cfg.TLS.ReloadInterval))
+
+	// Initialize gRPC clients: mTLS, default deadline and retries of idempotent calls
+	clientOpts := grpcDelivery.ClientOptions{
+		Timeout:       cfg.Services.Timeout,
+		RetryAttempts: cfg.Services.RetryAttempts,
+		RetryBackoff:  cfg.Services.RetryBackoff,
+		TLS:           certs,
+	}
+	userClient, err := grpcDelivery.NewUserClient(cfg.Services.UserAddr, clientOpts)
+	if err != nil {
+		logging.Fatal("failed to connect to user service", "error", err)
+	}
+
+	// Initialize Redis client; if Redis is down the gateway keeps working on the local cache tier
+	redisClient := grpcDelivery.NewRedisClient(cfg.Redis.Addr)
+	pingCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	if err := redisClient.Ping(pingCtx); err != nil {
+		slog.Warn("Redis unavailable, using in-memory cache", "error", err)
+	}
+	cancel()
+	app.OnShutdown("Redis", func(context.Context) error { return redisClient.Close() })
+	cache := grpcDelivery.NewResilientCache(redisClient, cfg.Cache.LocalSize)
+
+	// Initialize inventory client with Redis-backed product cache
+	inventoryClient, err := grpcDelivery.NewInventoryClient(cfg.Services.InventoryAddr, clientOpts)
+	if err != nil {
+		logging.Fatal("failed to connect to inventory service", "error", err)
+	}
+	productCache := grpcDelivery.NewCachedInventoryClient(inventoryClient, cache)
+
+	// Sessions are validated through UserService and cached
+	sessions := grpcDelivery.NewSessionValidator(userClient, cache)
+	apiKeys := grpcDelivery.NewAPIKeyValidator(userClient, cache)
+	evictor := handlers.NewUserEvictor(cache, sessions, apiKeys)
+
+	// Readiness follows downstream grpc.health.v1; Redis and RabbitMQ are reported but optional
+	downstream, err := grpcDelivery.NewDownstreamHealth(map[string]grpcDelivery.Downstream{
+		"user":      {Addr: cfg.Services.UserAddr, Identity: grpcDelivery.UserServiceIdentity},
+		"inventory": {Addr: cfg.Services.InventoryAddr, Identity: grpcDelivery.InventoryServiceIdentity},
+		"order":     {Addr: cfg.Services.OrderAddr, Identity: grpcDelivery.OrderServiceIdentity},
+	}, certs)
+	if err != nil {
+		logging.Fatal("failed to set up health checks", "error", err)
+	}
+	probes := handlers.NewProbes(downstream, cfg.Shutdown.DrainDelay)
+	probes.Optional("redis", redisClient.Ping)
+
+	// Subscribe to stock changes to invalidate cached products and to account
+	// deletions to drop cached profiles and sessions
+	rabbitClient, err := message.NewRabbitMQClient(cfg.RabbitMQ.URL)
 	if err != nil {
-		log.Fatalf("Failed to connect to user service: %v", err)
+		slog.Warn("RabbitMQ unavailable, caches will rely on TTLs only", "error", err)
+	} else {
+		app.OnShutdown("RabbitMQ connection", func(context.Context) error {
+			rabbitClient.Close()
+			return nil
+		})
+		rabbitClient.RegisterMetrics()
+		probes.Optional("rabbitmq", rabbitClient.Ping)
+		if err := message.NewStockConsumer(productCache, rabbitClient).Start(); err != nil {
+			slog.Error("failed to start stock consumer", "error", err)
+		}
+		if err := message.NewUserDeletedConsumer(evictor, rabbitClient).Start(); err != nil {
+			slog.Error("failed to start user deleted consumer", "error", err)
+		}
+		app.OnShutdown("RabbitMQ consumers", rabbitClient.StopConsumers)
 	}
-	
-	// Initialize Redis client
-	redisClient := grpcDelivery.NewRedisClient()
 
 	// Initialize Gin router
-	r := gin.Default()
+	r, err := newRouter(cfg.HTTP.TrustedProxies)
+	if err != nil {
+		logging.Fatal("invalid trusted proxies", "error", err)
+	}
+	r.Use(otelgin.Middleware("apiGateway", otelgin.WithFilter(func(req *http.Request) bool {
+		return req.URL.Path != "/healthz" && req.URL.Path != "/readyz"
+	})))
+	r.Use(metrics.HTTP())
+	r.Use(middleware.Logger())
+
+	// Per-IP rate limits run before authentication so that password guessing
+	// through /login or Basic auth is throttled too
+	rateLimiter := grpcDelivery.NewRateLimiter(redisClient)
+	r.Use(middleware.RateLimit(rateLimiter, rateLimitPolicy(cfg.RateLimit.IP, cfg.RateLimit.IPGroups), middleware.ByIP))
 
 	// Add authentication middleware
-	r.Use(middleware.Auth(userClient))
+	r.Use(middleware.Auth(userClient, sessions, apiKeys, apiKeyScopes))
+
+	// Per-user rate limits
+	r.Use(middleware.RateLimit(rateLimiter, rateLimitPolicy(cfg.RateLimit.User, cfg.RateLimit.UserGroups), middleware.ByUser))
 
 	// Register routes
-	handlers.RegisterRoutes(r, userClient, redisClient)
+	handlers.RegisterRoutes(r, userClient, cache, evictor)
+	handlers.RegisterSessionRoutes(r, userClient, sessions)
+	handlers.RegisterAddressRoutes(r, userClient)
+	handlers.RegisterAPIKeyRoutes(r, userClient, apiKeys)
+	handlers.RegisterPrivacyRoutes(r, userClient, evictor)
+	handlers.RegisterAdminRoutes(r, userClient, evictor)
+	handlers.RegisterHealthRoutes(r, cache)
+	handlers.RegisterProbeRoutes(r, probes)
+	if provider := newOIDCProvider(cfg.OIDC); provider != nil {
+		handlers.RegisterOIDCRoutes(r, userClient, provider, cache)
+	}
+	setupServiceProxies(r, productCache, userClient, cfg.Services.OrderAddr, clientOpts)
+
+	// Start server; on shutdown it stops accepting connections and waits for in-flight requests
+	srv := &http.Server{Addr: cfg.HTTP.Addr, Handler: r}
+	app.OnShutdown("HTTP server", srv.Shutdown)
+	// Registered last so it runs first: /readyz turns 503 before the server stops accepting connections
+	app.OnShutdown("readiness", probes.Drain)
+
+	slog.Info("API Gateway listening", "addr", cfg.HTTP.Addr)
+	err = app.Run(func() error {
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		logging.Fatal("API Gateway stopped with error", "error", err)
+	}
+	slog.Info("API Gateway stopped")
+}
+
+// newOIDCProvider настраивает вход через SSO, если задан oidc.issuer; иначе возвращает nil
+func newOIDCProvider(cfg config.OIDCConfig) *oidc.Provider {
+	if cfg.Issuer == "" {
+		return nil
+	}
+	slog.Info("OIDC login enabled", "issuer", cfg.Issuer)
+	return oidc.NewProvider(oidc.Config{
+		Issuer:       cfg.Issuer,
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+	})
+}
 
-	// Start server
-	if err := r.Run(":8080"); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+// rateLimitPolicy переводит лимиты из rate_limit в политику middleware
+func rateLimitPolicy(def config.RateLimitRule, groups map[string]config.RateLimitRule) middleware.RateLimitPolicy {
+	policy := middleware.RateLimitPolicy{
+		Default: middleware.RateLimitRule{Limit: def.Limit, Period: def.Period},
+		Groups:  make(map[string]middleware.RateLimitRule, len(groups)),
 	}
+	for prefix, rule := range groups {
+		policy.Groups[prefix] = middleware.RateLimitRule{Limit: rule.Limit, Period: rule.Period}
+	}
+	return policy
+}
+
+// apiKeyScopes — маршруты, доступные по API-ключу, и нужные для них scopes
+var apiKeyScopes = middleware.APIKeyScopes{
+	"GET /products":        "products:read",
+	"GET /products/:id":    "products:read",
+	"POST /products":       "products:write",
+	"PUT /products/:id":    "products:write",
+	"DELETE /products/:id": "products:write",
+
+	"GET /orders":            "orders:read",
+	"GET /orders/:id":        "orders:read",
+	"POST /orders":           "orders:write",
+	"PUT /orders/:id/status": "orders:write",
+}
+
+// newRouter creates the gin engine with the middleware every route needs.
+// gin.Default would add a plain-text logger; requests are logged as JSON instead.
+func newRouter(trustedProxies []string) (*gin.Engine, error) {
+	r := gin.New()
+	// The client IP keys rate limits, login lockout and sessions, so
+	// X-Forwarded-For is only honoured from the configured proxies; by
+	// default none are trusted and the connection address is used
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, err
+	}
+	// The request ID goes first so every later middleware and log line sees it
+	r.Use(middleware.RequestID(), middleware.Recovery())
+	// Handlers pass *gin.Context to the gRPC clients as context.Context; with
+	// the fallback it exposes the request context and the span started by otelgin
+	r.ContextWithFallback = true
+	return r, nil
 }
 
 // setupServiceProxies настраивает проксирование запросов к микросервисам
-func setupServiceProxies(r *gin.Engine) {
+func setupServiceProxies(r *gin.Engine, inventoryClient handlers.InventoryClient, userClient *grpcDelivery.UserClient, orderAddr string, clientOpts grpcDelivery.ClientOptions) {
 	// Create HTTP endpoints for inventory service
-	inventoryHandler := createInventoryHandler()
-	orderHandler := createOrderHandler()
+	inventoryHandler := handlers.NewInventoryHandler(inventoryClient)
+	orderHandler := createOrderHandler(orderAddr, clientOpts, userClient)
 
 	// REST API routes
 	r.GET("/products", inventoryHandler.GetProducts)
@@ -58,20 +269,11 @@ func setupServiceProxies(r *gin.Engine) {
 }
 
 // Implement these handler creators to connect to gRPC services
-func createInventoryHandler() *handlers.InventoryHandler {
-	// Connect to inventory gRPC service
-	inventoryClient, err := grpcDelivery.NewInventoryClient("localhost:50051")
-	if err != nil {
-		log.Fatalf("failed to connect to inventory service: %v", err)
-	}
-	return handlers.NewInventoryHandler(inventoryClient)
-}
-
-func createOrderHandler() *handlers.OrderHandler {
+func createOrderHandler(addr string, clientOpts grpcDelivery.ClientOptions, userClient *grpcDelivery.UserClient) *handlers.OrderHandler {
 	// Connect to order gRPC service
-	orderClient, err := grpcDelivery.NewOrderClient("localhost:50052")
+	orderClient, err := grpcDelivery.NewOrderClient(addr, clientOpts)
 	if err != nil {
-		log.Fatalf("failed to connect to order service: %v", err)
+		logging.Fatal("failed to connect to order service", "error", err)
 	}
-	return handlers.NewOrderHandler(orderClient)
+	return handlers.NewOrderHandler(orderClient, userClient)
 }
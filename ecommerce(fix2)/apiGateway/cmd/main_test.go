@@ -0,0 +1,98 @@
+package main
+
+import (
+	grpcDelivery "apiGateway/internal/grpc"
+	"apiGateway/internal/middleware"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// ipKeyRouter отвечает ключом корзины, в которую попал запрос
+func ipKeyRouter(t *testing.T, trustedProxies []string) *gin.Engine {
+	t.Helper()
+	r, err := newRouter(trustedProxies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.GET("/key", func(c *gin.Context) { c.String(http.StatusOK, middleware.ByIP(c)) })
+	return r
+}
+
+func requestKey(r *gin.Engine, remoteAddr, forwardedFor string) string {
+	req := httptest.NewRequest(http.MethodGet, "/key", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		req.Header.Set("X-Real-IP", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Body.String()
+}
+
+func TestForgedForwardedForDoesNotChangeBucketKey(t *testing.T) {
+	r := ipKeyRouter(t, nil)
+	for _, forged := range []string{"", "1.2.3.4", "5.6.7.8, 203.0.113.7", "not-an-ip"} {
+		if got := requestKey(r, "203.0.113.7:40000", forged); got != "ip:203.0.113.7" {
+			t.Errorf("X-Forwarded-For %q: key = %q, want ip:203.0.113.7", forged, got)
+		}
+	}
+}
+
+func TestTrustedProxyForwardsClientIP(t *testing.T) {
+	r := ipKeyRouter(t, []string{"10.0.0.0/8"})
+
+	if got := requestKey(r, "10.1.2.3:40000", "198.51.100.9"); got != "ip:198.51.100.9" {
+		t.Errorf("via trusted proxy: key = %q, want ip:198.51.100.9", got)
+	}
+	// Заголовок от клиента, который не является прокси, по-прежнему игнорируется
+	if got := requestKey(r, "203.0.113.7:40000", "198.51.100.9"); got != "ip:203.0.113.7" {
+		t.Errorf("direct client: key = %q, want ip:203.0.113.7", got)
+	}
+}
+
+func TestInvalidTrustedProxy(t *testing.T) {
+	if _, err := newRouter([]string{"not-a-cidr"}); err == nil {
+		t.Fatal("newRouter accepted an invalid proxy")
+	}
+}
+
+// Перебор X-Forwarded-For не дает новых попыток входа сверх лимита по IP
+func TestLoginLimitIgnoresForgedForwardedFor(t *testing.T) {
+	r, err := newRouter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Redis нет, корзины ведутся в памяти
+	limiter := grpcDelivery.NewRateLimiter(grpcDelivery.NewRedisClient("127.0.0.1:1"))
+	r.Use(middleware.RateLimit(limiter, middleware.RateLimitPolicy{
+		Default: middleware.RateLimitRule{Limit: 300, Period: time.Minute},
+		Groups:  map[string]middleware.RateLimitRule{"/login": {Limit: 5, Period: time.Minute}},
+	}, middleware.ByIP))
+	r.POST("/login", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for i := 1; i <= 6; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/login", nil)
+		req.RemoteAddr = "203.0.113.7:40000"
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", i))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		want := http.StatusNoContent
+		if i == 6 {
+			want = http.StatusTooManyRequests
+		}
+		if w.Code != want {
+			t.Fatalf("request %d: status = %d, want %d", i, w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// mock-oidc — локальный OIDC-провайдер для разработки. Вход без пароля:
+// пользователь выбирается параметром login_hint, например
+// http://localhost:8080/login/oidc → ...&login_hint=alice@example.com.
+package main
+
+import (
+	"apiGateway/internal/logging"
+	"apiGateway/internal/oidc"
+	"flag"
+	"log/slog"
+	"net/http"
+)
+
+func main() {
+	addr := flag.String("addr", ":9000", "listen address")
+	issuer := flag.String("issuer", "http://localhost:9000", "issuer URL as seen by the gateway")
+	clientID := flag.String("client-id", "gateway", "OAuth2 client id")
+	clientSecret := flag.String("client-secret", "gateway-secret", "OAuth2 client secret")
+	flag.Parse()
+
+	provider, err := oidc.NewMockProvider(*clientID, *clientSecret, oidc.MockUser{
+		Subject:           "staff-0001",
+		Email:             "staff@example.com",
+		EmailVerified:     true,
+		Name:              "Staff Member",
+		PreferredUsername: "staff",
+	})
+	if err != nil {
+		logging.Fatal("failed to create mock provider", "error", err)
+	}
+	provider.Issuer = *issuer
+
+	slog.Info("mock OIDC provider listening", "issuer", *issuer, "addr", *addr)
+	if err := http.ListenAndServe(*addr, provider); err != nil {
+		logging.Fatal("failed to serve", "error", err)
+	}
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	grpcDelivery "apiGateway/internal/grpc"
+	"apiGateway/internal/proto"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// addressBody — адрес в запросах и ответах API
+type addressBody struct {
+	Id         int32  `json:"id"`
+	FullName   string `json:"full_name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	City       string `json:"city"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+	Phone      string `json:"phone"`
+	IsDefault  bool   `json:"is_default"`
+}
+
+func newAddressBody(a *proto.Address) addressBody {
+	return addressBody{
+		Id:         a.Id,
+		FullName:   a.FullName,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+		Phone:      a.Phone,
+		IsDefault:  a.IsDefault,
+	}
+}
+
+func (b addressBody) toProto(userID, id int32) *proto.Address {
+	return &proto.Address{
+		Id:         id,
+		UserId:     userID,
+		FullName:   b.FullName,
+		Line1:      b.Line1,
+		Line2:      b.Line2,
+		City:       b.City,
+		Region:     b.Region,
+		PostalCode: b.PostalCode,
+		Country:    b.Country,
+		Phone:      b.Phone,
+		IsDefault:  b.IsDefault,
+	}
+}
+
+// RegisterAddressRoutes регистрирует адресную книгу текущего пользователя
+func RegisterAddressRoutes(r *gin.Engine, userClient *grpcDelivery.UserClient) {
+	me := r.Group("/me/addresses")
+
+	me.GET("", func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		list, err := userClient.ListAddresses(c.Request.Context(), userID)
+		if err != nil {
+			respondError(c, err, "failed to list addresses")
+			return
+		}
+		result := make([]addressBody, 0, len(list))
+		for _, a := range list {
+			result = append(result, newAddressBody(a))
+		}
+		c.JSON(http.StatusOK, gin.H{"addresses": result})
+	})
+
+	me.POST("", func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		var body addressBody
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+			return
+		}
+		a, err := userClient.CreateAddress(c.Request.Context(), body.toProto(userID, 0))
+		if err != nil {
+			respondError(c, err, "failed to create address")
+			return
+		}
+		c.JSON(http.StatusCreated, newAddressBody(a))
+	})
+
+	me.GET("/:id", func(c *gin.Context) {
+		userID, addressID, ok := addressParams(c)
+		if !ok {
+			return
+		}
+		a, err := userClient.GetAddress(c.Request.Context(), userID, addressID)
+		if err != nil {
+			respondError(c, err, "failed to get address")
+			return
+		}
+		c.JSON(http.StatusOK, newAddressBody(a))
+	})
+
+	me.PUT("/:id", func(c *gin.Context) {
+		userID, addressID, ok := addressParams(c)
+		if !ok {
+			return
+		}
+		var body addressBody
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+			return
+		}
+		a, err := userClient.UpdateAddress(c.Request.Context(), body.toProto(userID, addressID))
+		if err != nil {
+			respondError(c, err, "failed to update address")
+			return
+		}
+		c.JSON(http.StatusOK, newAddressBody(a))
+	})
+
+	me.DELETE("/:id", func(c *gin.Context) {
+		userID, addressID, ok := addressParams(c)
+		if !ok {
+			return
+		}
+		if err := userClient.DeleteAddress(c.Request.Context(), userID, addressID); err != nil {
+			respondError(c, err, "failed to delete address")
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	me.POST("/:id/default", func(c *gin.Context) {
+		userID, addressID, ok := addressParams(c)
+		if !ok {
+			return
+		}
+		a, err := userClient.SetDefaultAddress(c.Request.Context(), userID, addressID)
+		if err != nil {
+			respondError(c, err, "failed to set default address")
+			return
+		}
+		c.JSON(http.StatusOK, newAddressBody(a))
+	})
+}
+
+// addressParams возвращает текущего пользователя и :id адреса
+func addressParams(c *gin.Context) (int32, int32, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return 0, 0, false
+	}
+	addressID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address id"})
+		return 0, 0, false
+	}
+	return userID, int32(addressID), true
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	grpcDelivery "apiGateway/internal/grpc"
+	"apiGateway/internal/middleware"
+	"apiGateway/internal/proto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminUserResponse — пользователь в ответах /admin/users
+type adminUserResponse struct {
+	Id               int32  `json:"id"`
+	Username         string `json:"username"`
+	DisplayName      string `json:"display_name"`
+	Email            string `json:"email"`
+	EmailVerified    bool   `json:"email_verified"`
+	Role             string `json:"role"`
+	TwoFactorEnabled bool   `json:"two_factor_enabled"`
+	Disabled         bool   `json:"disabled"`
+	CreatedAt        string `json:"created_at"`
+}
+
+func newAdminUserResponse(u *proto.UserResponse) adminUserResponse {
+	return adminUserResponse{
+		Id:               u.Id,
+		Username:         u.Username,
+		DisplayName:      u.DisplayName,
+		Email:            u.Email,
+		EmailVerified:    u.EmailVerified,
+		Role:             u.Role,
+		TwoFactorEnabled: u.TotpEnabled,
+		Disabled:         u.Disabled,
+		CreatedAt:        u.CreatedAt,
+	}
+}
+
+// RegisterAdminRoutes регистрирует управление пользователями для администраторов.
+// gin требует одно имя параметра на позиции в пути, поэтому :user — это ID
+// пользователя везде, кроме unlock, где по историческим причинам передается имя.
+func RegisterAdminRoutes(r *gin.Engine, userClient *grpcDelivery.UserClient, evictor *UserEvictor) {
+	admin := r.Group("/admin/users", middleware.RequireRole("admin"))
+
+	// Снятие блокировки входа после серии неудачных попыток
+	admin.POST("/:user/unlock", func(c *gin.Context) {
+		if err := userClient.UnlockUser(c.Request.Context(), c.Param("user")); err != nil {
+			respondError(c, err, "unlock failed")
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	// Фильтры: role, status (active | disabled), created_after и created_before
+	// в RFC 3339, q — подстрока имени или email
+	admin.GET("", func(c *gin.Context) {
+		pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "0"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page_size"})
+			return
+		}
+		resp, err := userClient.ListUsers(c.Request.Context(), &proto.ListUsersRequest{
+			PageSize:      int32(pageSize),
+			PageToken:     c.Query("page_token"),
+			Role:          c.Query("role"),
+			Status:        c.Query("status"),
+			CreatedAfter:  c.Query("created_after"),
+			CreatedBefore: c.Query("created_before"),
+			Query:         c.Query("q"),
+		})
+		if err != nil {
+			respondError(c, err, "failed to list users")
+			return
+		}
+		users := make([]adminUserResponse, 0, len(resp.Users))
+		for _, u := range resp.Users {
+			users = append(users, newAdminUserResponse(u))
+		}
+		c.JSON(http.StatusOK, gin.H{"users": users, "next_page_token": resp.NextPageToken})
+	})
+
+	admin.GET("/:user", func(c *gin.Context) {
+		id, ok := adminTargetID(c, false)
+		if !ok {
+			return
+		}
+		u, err := userClient.GetProfile(c.Request.Context(), id)
+		if err != nil {
+			respondError(c, err, "failed to get user")
+			return
+		}
+		c.JSON(http.StatusOK, newAdminUserResponse(u))
+	})
+
+	// Отключение сразу завершает все сессии пользователя
+	admin.POST("/:user/disable", func(c *gin.Context) {
+		id, ok := adminTargetID(c, true)
+		if !ok {
+			return
+		}
+		u, err := userClient.DisableUser(c.Request.Context(), id)
+		if err != nil {
+			respondError(c, err, "failed to disable user")
+			return
+		}
+		revoked, err := userClient.RevokeAllSessions(c.Request.Context(), id, 0)
+		if err != nil {
+			// Сессии отключенного пользователя все равно не пройдут проверку в UserService
+			slog.WarnContext(c.Request.Context(), "failed to revoke sessions of disabled user", "user_id", id, "error", err)
+		}
+		if err := evictor.EvictUser(c.Request.Context(), id, revoked); err != nil {
+			respondCacheError(c)
+			return
+		}
+		c.JSON(http.StatusOK, newAdminUserResponse(u))
+	})
+
+	admin.POST("/:user/enable", func(c *gin.Context) {
+		id, ok := adminTargetID(c, false)
+		if !ok {
+			return
+		}
+		u, err := userClient.EnableUser(c.Request.Context(), id)
+		if err != nil {
+			respondError(c, err, "failed to enable user")
+			return
+		}
+		if err := evictor.EvictUser(c.Request.Context(), id, nil); err != nil {
+			respondCacheError(c)
+			return
+		}
+		c.JSON(http.StatusOK, newAdminUserResponse(u))
+	})
+
+	admin.PUT("/:user/role", func(c *gin.Context) {
+		id, ok := adminTargetID(c, true)
+		if !ok {
+			return
+		}
+		var body struct {
+			Role string `json:"role"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+			return
+		}
+		u, err := userClient.SetUserRole(c.Request.Context(), id, body.Role)
+		if err != nil {
+			respondError(c, err, "failed to set role")
+			return
+		}
+		if err := evictor.EvictUser(c.Request.Context(), id, nil); err != nil {
+			respondCacheError(c)
+			return
+		}
+		c.JSON(http.StatusOK, newAdminUserResponse(u))
+	})
+}
+
+// adminTargetID возвращает ID пользователя из :user. Если notSelf, администратор не может
+// выполнить действие над собой — так нельзя случайно остаться без доступа.
+func adminTargetID(c *gin.Context, notSelf bool) (int32, bool) {
+	id, err := strconv.Atoi(c.Param("user"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, false
+	}
+	if currentID, _ := currentUserID(c); notSelf && int32(id) == currentID {
+		c.JSON(http.StatusConflict, gin.H{"error": "cannot apply this action to your own account"})
+		return 0, false
+	}
+	return int32(id), true
+}
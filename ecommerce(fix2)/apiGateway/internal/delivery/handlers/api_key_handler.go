@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	grpcDelivery "apiGateway/internal/grpc"
+	"apiGateway/internal/proto"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type createAPIKeyBody struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int32    `json:"expires_in_days"` // 0 — бессрочный
+}
+
+type rotateAPIKeyBody struct {
+	GracePeriodHours int32 `json:"grace_period_hours"` // 0 — 24 часа
+}
+
+// RegisterAPIKeyRoutes регистрирует маршруты для управления API-ключами текущего пользователя.
+// Сами эти маршруты по API-ключу недоступны: ключ не может выпустить себе замену.
+func RegisterAPIKeyRoutes(r *gin.Engine, userClient *grpcDelivery.UserClient, apiKeys *grpcDelivery.APIKeyValidator) {
+	me := r.Group("/me/api-keys")
+
+	me.GET("", func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		keys, err := userClient.ListApiKeys(c.Request.Context(), userID)
+		if err != nil {
+			respondError(c, err, "failed to list api keys")
+			return
+		}
+		result := make([]gin.H, 0, len(keys))
+		for _, k := range keys {
+			result = append(result, apiKeyJSON(k))
+		}
+		c.JSON(http.StatusOK, gin.H{"api_keys": result})
+	})
+
+	me.POST("", func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		var body createAPIKeyBody
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		resp, err := userClient.CreateApiKey(c.Request.Context(), &proto.CreateApiKeyRequest{
+			UserId:        userID,
+			Name:          body.Name,
+			Scopes:        body.Scopes,
+			ExpiresInDays: body.ExpiresInDays,
+		})
+		if err != nil {
+			respondError(c, err, "failed to create api key")
+			return
+		}
+		c.JSON(http.StatusCreated, createdAPIKeyJSON(resp))
+	})
+
+	me.DELETE("/:id", func(c *gin.Context) {
+		userID, keyID, ok := apiKeyParams(c)
+		if !ok {
+			return
+		}
+		if err := userClient.RevokeApiKey(c.Request.Context(), userID, keyID); err != nil {
+			respondError(c, err, "failed to revoke api key")
+			return
+		}
+		if err := apiKeys.Revoke(c.Request.Context(), keyID); err != nil {
+			respondCacheError(c)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	// Ротация: новый ключ с теми же именем и scopes, старый действует еще grace_period_hours
+	me.POST("/:id/rotate", func(c *gin.Context) {
+		userID, keyID, ok := apiKeyParams(c)
+		if !ok {
+			return
+		}
+		var body rotateAPIKeyBody
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&body); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+				return
+			}
+		}
+
+		resp, err := userClient.RotateApiKey(c.Request.Context(), userID, keyID, body.GracePeriodHours)
+		if err != nil {
+			respondError(c, err, "failed to rotate api key")
+			return
+		}
+		c.JSON(http.StatusCreated, createdAPIKeyJSON(resp))
+	})
+}
+
+// apiKeyParams достает ID пользователя и ключа; при ошибке ответ уже отправлен
+func apiKeyParams(c *gin.Context) (userID, keyID int32, ok bool) {
+	userID, ok = currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return 0, 0, false
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid api key id"})
+		return 0, 0, false
+	}
+	return userID, int32(id), true
+}
+
+func apiKeyJSON(k *proto.ApiKey) gin.H {
+	return gin.H{
+		"id":           k.Id,
+		"name":         k.Name,
+		"prefix":       k.Prefix,
+		"scopes":       k.Scopes,
+		"created_at":   k.CreatedAt,
+		"expires_at":   k.ExpiresAt,
+		"last_used_at": k.LastUsedAt,
+	}
+}
+
+// createdAPIKeyJSON — ответ с самим ключом; больше его нигде не показать
+func createdAPIKeyJSON(resp *proto.CreateApiKeyResponse) gin.H {
+	body := apiKeyJSON(resp.ApiKey)
+	body["key"] = resp.Key
+	return body
+}
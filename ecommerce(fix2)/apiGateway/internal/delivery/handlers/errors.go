@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// respondError переводит ошибку gRPC-сервиса в HTTP-ответ. Сообщения сервисов
+// передаются клиенту только для кодов, которые описывают ошибку клиента;
+// для остальных отвечаем fallback, чтобы не раскрывать внутренние детали.
+//
+//	InvalidArgument    → 400, или 422 с полем "fields", если сервис прислал нарушения по полям
+//	AlreadyExists      → 409
+//	FailedPrecondition → 409
+//	Unauthenticated    → 401
+//	PermissionDenied   → 403
+//	NotFound           → 404
+//	ResourceExhausted  → 429
+//	Unavailable        → 503
+//	DeadlineExceeded   → 504
+//	остальные          → 500
+func respondError(c *gin.Context, err error, fallback string) {
+	st := status.Convert(err)
+	switch st.Code() {
+	case codes.InvalidArgument:
+		if fields := fieldViolations(st); len(fields) > 0 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": st.Message(), "fields": fields})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": st.Message()})
+	case codes.AlreadyExists, codes.FailedPrecondition:
+		c.JSON(http.StatusConflict, gin.H{"error": st.Message()})
+	case codes.Unauthenticated:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": st.Message()})
+	case codes.PermissionDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": st.Message()})
+	case codes.NotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": st.Message()})
+	case codes.ResourceExhausted:
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": st.Message()})
+	case codes.Unavailable:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "service unavailable"})
+	case codes.DeadlineExceeded:
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "service timed out"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// fieldViolations достает нарушения по полям из деталей BadRequest
+func fieldViolations(st *status.Status) map[string]string {
+	fields := make(map[string]string)
+	for _, d := range st.Details() {
+		br, ok := d.(*errdetails.BadRequest)
+		if !ok {
+			continue
+		}
+		for _, v := range br.GetFieldViolations() {
+			fields[v.GetField()] = v.GetDescription()
+		}
+	}
+	return fields
+}
+
+// respondCacheError — изменение уже сохранено в сервисе, но gateway не смог
+// записать в Redis отзыв или сброс кеша, и другие экземпляры еще до минуты
+// будут видеть старое состояние. Клиент получает 503 и может повторить запрос.
+func respondCacheError(c *gin.Context) {
+	c.JSON(http.StatusServiceUnavailable, gin.H{"error": "change saved, but not yet applied on all gateways; retry the request"})
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	grpcDelivery "apiGateway/internal/grpc"
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheStatusProvider отдает состояние кеша для health endpoint
+type CacheStatusProvider interface {
+	Status() grpcDelivery.CacheStatus
+}
+
+// RegisterHealthRoutes регистрирует /health. Недоступный Redis не делает
+// gateway нерабочим, поэтому в этом случае кеш просто помечается как degraded.
+func RegisterHealthRoutes(r *gin.Engine, cache CacheStatusProvider) {
+	r.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status": "ok",
+			"cache":  cache.Status(),
+		})
+	})
+}
+
+// readinessTimeout ограничивает опрос зависимостей в /readyz
+const readinessTimeout = 2 * time.Second
+
+// DownstreamChecker опрашивает сервисы за gateway
+type DownstreamChecker interface {
+	Check(ctx context.Context) map[string]string
+}
+
+// Probes — liveness (/healthz) и readiness (/readyz) для оркестратора.
+// Gateway готов, когда отвечают SERVING все сервисы за ним и он не
+// останавливается. Redis и RabbitMQ показываются отдельно и на готовность не
+// влияют: без них gateway работает, только с деградацией.
+type Probes struct {
+	downstream DownstreamChecker
+	optional   map[string]func(context.Context) error
+	drainDelay time.Duration
+	draining   atomic.Bool
+}
+
+func NewProbes(downstream DownstreamChecker, drainDelay time.Duration) *Probes {
+	return &Probes{
+		downstream: downstream,
+		optional:   make(map[string]func(context.Context) error),
+		drainDelay: drainDelay,
+	}
+}
+
+// Optional добавляет зависимость, которая видна в /readyz, но не влияет на готовность
+func (p *Probes) Optional(name string, check func(context.Context) error) {
+	p.optional[name] = check
+}
+
+// Drain — шаг остановки: /readyz начинает отвечать 503, и gateway ждет
+// drainDelay, чтобы балансировщик успел убрать его из ротации до того, как
+// HTTP-сервер перестанет принимать соединения
+func (p *Probes) Drain(ctx context.Context) error {
+	p.draining.Store(true)
+	if p.drainDelay <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(p.drainDelay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RegisterProbeRoutes регистрирует /healthz и /readyz
+func RegisterProbeRoutes(r *gin.Engine, p *Probes) {
+	// Liveness: процесс жив и обрабатывает запросы; от зависимостей не зависит,
+	// иначе сбой одного сервиса приводил бы к перезапуску gateway
+	r.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	r.GET("/readyz", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+		defer cancel()
+
+		services := p.downstream.Check(ctx)
+		ready := !p.draining.Load()
+		for _, state := range services {
+			if state != "SERVING" {
+				ready = false
+			}
+		}
+		dependencies := make(map[string]string, len(p.optional))
+		for name, check := range p.optional {
+			dependencies[name] = "up"
+			if err := check(ctx); err != nil {
+				dependencies[name] = "down"
+			}
+		}
+
+		body := gin.H{"services": services, "dependencies": dependencies}
+		switch {
+		case p.draining.Load():
+			body["status"] = "shutting down"
+		case ready:
+			body["status"] = "ready"
+		default:
+			body["status"] = "not ready"
+		}
+		code := http.StatusOK
+		if !ready {
+			code = http.StatusServiceUnavailable
+		}
+		c.JSON(code, body)
+	})
+}
@@ -35,7 +35,7 @@ func NewInventoryHandler(client InventoryClient) *InventoryHandler {
 func (h *InventoryHandler) GetProducts(c *gin.Context) {
 	products, err := h.client.ListProducts(c, &inventory.Empty{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err, "internal error")
 		return
 	}
 
@@ -53,7 +53,7 @@ func (h *InventoryHandler) GetProduct(c *gin.Context) {
 
 	product, err := h.client.GetProduct(c, &inventory.ProductID{Id: int32(id)})
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		respondError(c, err, "failed to get product")
 		return
 	}
 
@@ -70,7 +70,7 @@ func (h *InventoryHandler) CreateProduct(c *gin.Context) {
 
 	createdProduct, err := h.client.CreateProduct(c, &product)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err, "internal error")
 		return
 	}
 
@@ -95,7 +95,7 @@ func (h *InventoryHandler) UpdateProduct(c *gin.Context) {
 
 	updatedProduct, err := h.client.UpdateProduct(c, &product)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err, "internal error")
 		return
 	}
 
@@ -113,7 +113,7 @@ func (h *InventoryHandler) DeleteProduct(c *gin.Context) {
 
 	_, err = h.client.DeleteProduct(c, &inventory.ProductID{Id: int32(id)})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err, "internal error")
 		return
 	}
 
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	grpcDelivery "apiGateway/internal/grpc"
+	"apiGateway/internal/oidc"
+	"apiGateway/internal/proto"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// oidcStateTTL — сколько у пользователя есть времени на вход у провайдера
+	oidcStateTTL = 10 * time.Minute
+	// oidcStateCookie привязывает вход к браузеру, который его начал
+	oidcStateCookie = "oidc_state"
+)
+
+// oidcLogin — незавершенный вход через провайдера. Хранится в кеше по state:
+// gateway может работать в нескольких экземплярах, и callback может прийти не туда,
+// где начинался вход.
+type oidcLogin struct {
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+	Device   string `json:"device"`
+}
+
+// RegisterOIDCRoutes регистрирует вход через внешнего OIDC-провайдера
+// (authorization code flow с PKCE). /login/oidc перенаправляет на страницу
+// входа провайдера, /login/oidc/callback открывает сессию так же, как /login.
+func RegisterOIDCRoutes(r *gin.Engine, userClient *grpcDelivery.UserClient, provider *oidc.Provider, cache grpcDelivery.Cache) {
+	r.GET("/login/oidc", func(c *gin.Context) {
+		state, nonce := randomState(), randomState()
+		login := oidcLogin{Verifier: oauth2.GenerateVerifier(), Nonce: nonce, Device: c.Query("device")}
+		// Пока Redis недоступен, state есть только у этого экземпляра: вход
+		// пройдет, если callback попадет на него же
+		err := cache.Set(c.Request.Context(), oidcStateKey(state), login, oidcStateTTL)
+		if err != nil && !errors.Is(err, grpcDelivery.ErrCacheDeferred) {
+			slog.ErrorContext(c.Request.Context(), "failed to save OIDC login state", "error", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "login is temporarily unavailable"})
+			return
+		}
+
+		target, err := provider.AuthCodeURL(c.Request.Context(), state, nonce, login.Verifier)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "identity provider is unavailable", "error", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "identity provider is unavailable"})
+			return
+		}
+		// Без cookie злоумышленник мог бы подсунуть жертве ссылку на callback
+		// со своим state и кодом, и жертва вошла бы в его учетную запись.
+		// SameSite=Lax: браузер возвращается с провайдера обычным переходом.
+		http.SetCookie(c.Writer, &http.Cookie{
+			Name:     oidcStateCookie,
+			Value:    state,
+			Path:     "/login/oidc",
+			MaxAge:   int(oidcStateTTL / time.Second),
+			Secure:   strings.HasPrefix(provider.RedirectURL(), "https://"),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+		c.Redirect(http.StatusFound, target)
+	})
+
+	r.GET("/login/oidc/callback", func(c *gin.Context) {
+		// state одноразовый: GetDel отдает его одному callback, повторный
+		// или параллельный с тем же кодом не пройдет
+		state := c.Query("state")
+		cookie, _ := c.Cookie(oidcStateCookie)
+		http.SetCookie(c.Writer, &http.Cookie{Name: oidcStateCookie, Path: "/login/oidc", MaxAge: -1})
+		if state == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) != 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "login session is invalid or has expired"})
+			return
+		}
+		var login oidcLogin
+		if cache.GetDel(c.Request.Context(), oidcStateKey(state), &login) != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "login session is invalid or has expired"})
+			return
+		}
+
+		if errCode := c.Query("error"); errCode != "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "identity provider denied login: " + errCode})
+			return
+		}
+
+		claims, err := provider.Exchange(c.Request.Context(), c.Query("code"), login.Verifier, login.Nonce)
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "OIDC login failed", "error", err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "identity provider login failed"})
+			return
+		}
+
+		user, err := userClient.LoginWithOIDC(c.Request.Context(), &proto.OIDCLoginRequest{
+			Issuer:            provider.Issuer(),
+			Subject:           claims.Subject,
+			Email:             claims.Email,
+			EmailVerified:     claims.EmailVerified,
+			Name:              claims.Name,
+			PreferredUsername: claims.PreferredUsername,
+		})
+		if err != nil {
+			if status.Code(err) == codes.PermissionDenied {
+				respondError(c, err, "login failed")
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "UserService rejected OIDC login", "subject", claims.Subject, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "login failed"})
+			return
+		}
+		startSession(c, userClient, user, login.Device)
+	})
+}
+
+func oidcStateKey(state string) string {
+	return "oidc:state:" + state
+}
+
+func randomState() string {
+	raw := make([]byte, 32)
+	rand.Read(raw)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
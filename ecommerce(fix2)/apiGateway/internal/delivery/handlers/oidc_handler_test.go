@@ -0,0 +1,488 @@
+package handlers
+
+import (
+	grpcDelivery "apiGateway/internal/grpc"
+	"apiGateway/internal/mtls"
+	"apiGateway/internal/oidc"
+	"apiGateway/internal/proto"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// memCache — Cache в памяти вместо Redis
+type memCache struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func newMemCache() *memCache {
+	return &memCache{data: make(map[string][]byte)}
+}
+
+var errCacheMiss = errors.New("cache miss")
+
+func (m *memCache) Get(_ context.Context, key string, dest interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	raw, ok := m.data[key]
+	if !ok {
+		return errCacheMiss
+	}
+	return json.Unmarshal(raw, dest)
+}
+
+func (m *memCache) GetDel(_ context.Context, key string, dest interface{}) error {
+	m.mu.Lock()
+	raw, ok := m.data[key]
+	delete(m.data, key)
+	m.mu.Unlock()
+	if !ok {
+		return errCacheMiss
+	}
+	return json.Unmarshal(raw, dest)
+}
+
+func (m *memCache) Set(_ context.Context, key string, value interface{}, _ time.Duration) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = raw
+	return nil
+}
+
+func (m *memCache) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, key)
+	return nil
+}
+
+func (m *memCache) Incr(_ context.Context, key string) (int64, error) {
+	var n int64
+	_ = m.Get(context.Background(), key, &n)
+	n++
+	return n, m.Set(context.Background(), key, n, 0)
+}
+
+// fakeUserService отвечает на вызовы gateway вместо UserService
+type fakeUserService struct {
+	proto.UnimplementedUserServiceServer
+
+	mu     sync.Mutex
+	logins []*proto.OIDCLoginRequest
+	role   string // роль, с которой ValidateSession возвращает пользователя
+}
+
+func (f *fakeUserService) LoginWithOIDC(_ context.Context, req *proto.OIDCLoginRequest) (*proto.UserResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.logins = append(f.logins, req)
+	return &proto.UserResponse{Id: 7, Username: "alice", Role: "user"}, nil
+}
+
+// requests возвращает запросы LoginWithOIDC, пришедшие к этому моменту
+func (f *fakeUserService) requests() []*proto.OIDCLoginRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*proto.OIDCLoginRequest(nil), f.logins...)
+}
+
+func (f *fakeUserService) CreateSession(_ context.Context, req *proto.CreateSessionRequest) (*proto.CreateSessionResponse, error) {
+	return &proto.CreateSessionResponse{Session: &proto.Session{Id: 11, Device: req.Device}, Token: "session-token"}, nil
+}
+
+// startUserService запускает fakeUserService за mTLS, как настоящий сервис,
+// и возвращает клиент gateway к нему
+func startUserService(t *testing.T, fake *fakeUserService) *grpcDelivery.UserClient {
+	t.Helper()
+	certFile, keyFile, caFile := writeTestCerts(t, grpcDelivery.UserServiceIdentity)
+	store, err := mtls.Load(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS13})))
+	proto.RegisterUserServiceServer(srv, fake)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	client, err := grpcDelivery.NewUserClient(lis.Addr().String(), grpcDelivery.ClientOptions{
+		Timeout:       5 * time.Second,
+		RetryAttempts: 1,
+		TLS:           store,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// writeTestCerts выпускает CA и сертификат с CN = name, как cmd/dev-ca
+func writeTestCerts(t *testing.T, name string) (certFile, keyFile, caFile string) {
+	t.Helper()
+	dir := t.TempDir()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{name},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile, caFile = filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key"), filepath.Join(dir, "ca.crt")
+	for file, block := range map[string]*pem.Block{
+		certFile: {Type: "CERTIFICATE", Bytes: der},
+		keyFile:  {Type: "PRIVATE KEY", Bytes: keyDER},
+		caFile:   {Type: "CERTIFICATE", Bytes: caDER},
+	} {
+		if err := os.WriteFile(file, pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return certFile, keyFile, caFile
+}
+
+// oidcTest — gateway с маршрутами входа через мок-провайдер
+type oidcTest struct {
+	router *gin.Engine
+	cache  *memCache
+	users  *fakeUserService
+	issuer string
+	cookie *http.Cookie // cookie со state, которую /login/oidc выдал браузеру
+}
+
+func newOIDCTest(t *testing.T, users ...oidc.MockUser) *oidcTest {
+	t.Helper()
+	mock, err := oidc.NewMockProvider("gateway", "secret", users...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := httptest.NewServer(mock)
+	t.Cleanup(idp.Close)
+	mock.Issuer = idp.URL
+
+	provider := oidc.NewProvider(oidc.Config{
+		Issuer:       idp.URL,
+		ClientID:     "gateway",
+		ClientSecret: "secret",
+		RedirectURL:  "http://gateway.test/login/oidc/callback",
+	})
+	fake := &fakeUserService{}
+	tt := &oidcTest{router: gin.New(), cache: newMemCache(), users: fake, issuer: idp.URL}
+	RegisterOIDCRoutes(tt.router, startUserService(t, fake), provider, tt.cache)
+	return tt
+}
+
+// start открывает /login/oidc и проходит вход у провайдера; возвращает
+// callback, с которым провайдер вернул бы браузер на gateway
+func (tt *oidcTest) start(t *testing.T, loginHint string) *url.URL {
+	t.Helper()
+	w := httptest.NewRecorder()
+	tt.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/login/oidc?device=laptop", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("/login/oidc: status = %d, body %s", w.Code, w.Body)
+	}
+	for _, c := range w.Result().Cookies() {
+		if c.Name == oidcStateCookie {
+			tt.cookie = c
+		}
+	}
+	if tt.cookie == nil || !tt.cookie.HttpOnly {
+		t.Fatalf("/login/oidc: no HttpOnly %s cookie", oidcStateCookie)
+	}
+	target, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loginHint != "" {
+		q := target.Query()
+		q.Set("login_hint", loginHint)
+		target.RawQuery = q.Encode()
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(target.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return callback
+}
+
+func (tt *oidcTest) callback(callback *url.URL) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil)
+	if tt.cookie != nil {
+		req.AddCookie(tt.cookie)
+	}
+	tt.router.ServeHTTP(w, req)
+	return w
+}
+
+// tamper меняет сохраненный вход, который ждет callback с этим state
+func (tt *oidcTest) tamper(t *testing.T, callback *url.URL, change func(*oidcLogin)) {
+	t.Helper()
+	key := oidcStateKey(callback.Query().Get("state"))
+	var login oidcLogin
+	if err := tt.cache.Get(context.Background(), key, &login); err != nil {
+		t.Fatal(err)
+	}
+	change(&login)
+	tt.cache.Set(context.Background(), key, login, oidcStateTTL)
+}
+
+var alice = oidc.MockUser{Subject: "u1", Email: "alice@example.com", EmailVerified: true, Name: "Alice", PreferredUsername: "alice"}
+
+func TestOIDCCallback(t *testing.T) {
+	tt := newOIDCTest(t, alice)
+	callback := tt.start(t, "")
+
+	w := tt.callback(callback)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	var body struct {
+		UserID    int32  `json:"user_id"`
+		SessionID int32  `json:"session_id"`
+		Token     string `json:"token"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.UserID != 7 || body.SessionID != 11 || body.Token != "Bearer session-token" {
+		t.Errorf("response = %+v", body)
+	}
+
+	logins := tt.users.requests()
+	if len(logins) != 1 {
+		t.Fatalf("LoginWithOIDC called %d times, want 1", len(logins))
+	}
+	got := logins[0]
+	if got.Issuer != tt.issuer || got.Subject != alice.Subject || got.Email != alice.Email ||
+		!got.EmailVerified || got.Name != alice.Name || got.PreferredUsername != alice.PreferredUsername {
+		t.Errorf("LoginWithOIDC request = %v, want claims of %+v from %s", got, alice, tt.issuer)
+	}
+
+	// state одноразовый: повтор того же callback не проходит
+	if w := tt.callback(callback); w.Code != http.StatusBadRequest {
+		t.Errorf("replayed callback: status = %d, want 400", w.Code)
+	}
+}
+
+// Одновременные callback с одним state: вход завершает только один
+func TestOIDCCallbackConcurrentReplay(t *testing.T) {
+	tt := newOIDCTest(t, alice)
+	callback := tt.start(t, "")
+
+	codes := make(chan int, 5)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(codes); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- tt.callback(callback).Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	ok := 0
+	for code := range codes {
+		if code == http.StatusOK {
+			ok++
+		}
+	}
+	if ok != 1 || len(tt.users.requests()) != 1 {
+		t.Errorf("%d callbacks succeeded and LoginWithOIDC was called %d times, want 1 and 1", ok, len(tt.users.requests()))
+	}
+}
+
+// Провайдер передает, подтвержден ли email, а UserService по этому флагу
+// решает, привязать ли существующую учетную запись
+func TestOIDCCallbackForwardsUnverifiedEmail(t *testing.T) {
+	mallory := oidc.MockUser{Subject: "u2", Email: "alice@example.com", EmailVerified: false}
+	tt := newOIDCTest(t, mallory)
+
+	if w := tt.callback(tt.start(t, "")); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	if got := tt.users.requests()[0]; got.Subject != "u2" || got.EmailVerified {
+		t.Errorf("LoginWithOIDC request = %v, want unverified email of u2", got)
+	}
+}
+
+func TestOIDCCallbackRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(callback *url.URL, tt *oidcTest, t *testing.T)
+		wantCode int
+	}{
+		{
+			name: "unknown state",
+			change: func(callback *url.URL, _ *oidcTest, _ *testing.T) {
+				q := callback.Query()
+				q.Set("state", "forged")
+				callback.RawQuery = q.Encode()
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "unknown state with matching cookie",
+			change: func(callback *url.URL, tt *oidcTest, _ *testing.T) {
+				q := callback.Query()
+				q.Set("state", "forged")
+				callback.RawQuery = q.Encode()
+				tt.cookie.Value = "forged"
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			// Злоумышленник подсовывает жертве callback своего входа
+			name: "missing cookie",
+			change: func(_ *url.URL, tt *oidcTest, _ *testing.T) {
+				tt.cookie = nil
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "cookie of another login",
+			change: func(_ *url.URL, tt *oidcTest, _ *testing.T) {
+				tt.cookie.Value = randomState()
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "missing state",
+			change: func(callback *url.URL, _ *oidcTest, _ *testing.T) {
+				q := callback.Query()
+				q.Del("state")
+				callback.RawQuery = q.Encode()
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "nonce mismatch",
+			change: func(callback *url.URL, tt *oidcTest, t *testing.T) {
+				tt.tamper(t, callback, func(l *oidcLogin) { l.Nonce = randomState() })
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "wrong PKCE verifier",
+			change: func(callback *url.URL, tt *oidcTest, t *testing.T) {
+				tt.tamper(t, callback, func(l *oidcLogin) { l.Verifier = randomState() })
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "forged code",
+			change: func(callback *url.URL, _ *oidcTest, _ *testing.T) {
+				q := callback.Query()
+				q.Set("code", "forged")
+				callback.RawQuery = q.Encode()
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "provider error",
+			change: func(callback *url.URL, _ *oidcTest, _ *testing.T) {
+				q := callback.Query()
+				q.Del("code")
+				q.Set("error", "access_denied")
+				callback.RawQuery = q.Encode()
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := newOIDCTest(t, alice)
+			callback := tt.start(t, "")
+			tc.change(callback, tt, t)
+
+			if w := tt.callback(callback); w.Code != tc.wantCode {
+				t.Errorf("status = %d, want %d, body %s", w.Code, tc.wantCode, w.Body)
+			}
+			if len(tt.users.requests()) != 0 {
+				t.Errorf("LoginWithOIDC was called for a rejected callback")
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"google.golang.org/grpc"
 
 	// You'll need to create these proto imports
+	"apiGateway/internal/proto"
 	"apiGateway/internal/proto/order"
 )
 
@@ -20,14 +21,20 @@ type OrderClient interface {
 	ListOrdersByUser(ctx context.Context, req *order.ListOrdersRequest, opts ...grpc.CallOption) (*order.OrderList, error)
 }
 
+// AddressLookup returns an address from the user's address book
+type AddressLookup interface {
+	GetAddress(ctx context.Context, userID, addressID int32) (*proto.Address, error)
+}
+
 // OrderHandler handles HTTP requests for order service
 type OrderHandler struct {
-	client OrderClient
+	client    OrderClient
+	addresses AddressLookup
 }
 
 // NewOrderHandler creates a new order handler
-func NewOrderHandler(client OrderClient) *OrderHandler {
-	return &OrderHandler{client: client}
+func NewOrderHandler(client OrderClient, addresses AddressLookup) *OrderHandler {
+	return &OrderHandler{client: client, addresses: addresses}
 }
 
 // GetOrders returns all orders for the current user
@@ -47,7 +54,7 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 
 	orders, err := h.client.ListOrdersByUser(c, &order.ListOrdersRequest{UserId: int32(userID)})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err, "internal error")
 		return
 	}
 
@@ -72,7 +79,7 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 
 	order, err := h.client.GetOrder(c, &order.OrderID{Id: int32(id)})
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		respondError(c, err, "failed to get order")
 		return
 	}
 
@@ -93,6 +100,7 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 			ProductID int `json:"product_id"`
 			Quantity  int `json:"quantity"`
 		} `json:"items"`
+		AddressID int `json:"address_id"`
 	}
 
 	if err := c.ShouldBindJSON(&orderReq); err != nil {
@@ -115,6 +123,26 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		Items:  make([]*order.OrderItem, 0, len(orderReq.Items)),
 	}
 
+	// Snapshot the shipping address so later edits don't change the order
+	if orderReq.AddressID > 0 {
+		addr, err := h.addresses.GetAddress(c, int32(userID), int32(orderReq.AddressID))
+		if err != nil {
+			respondError(c, err, "failed to get address")
+			return
+		}
+		newOrder.AddressId = addr.Id
+		newOrder.ShippingAddress = &order.ShippingAddress{
+			FullName:   addr.FullName,
+			Line1:      addr.Line1,
+			Line2:      addr.Line2,
+			City:       addr.City,
+			Region:     addr.Region,
+			PostalCode: addr.PostalCode,
+			Country:    addr.Country,
+			Phone:      addr.Phone,
+		}
+	}
+
 	// Add items to the order
 	for _, item := range orderReq.Items {
 		newOrder.Items = append(newOrder.Items, &order.OrderItem{
@@ -126,7 +154,7 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	// Create the order
 	createdOrder, err := h.client.CreateOrder(c, newOrder)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err, "internal error")
 		return
 	}
 
@@ -155,7 +183,7 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 		Status: statusUpdate.Status,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err, "internal error")
 		return
 	}
 
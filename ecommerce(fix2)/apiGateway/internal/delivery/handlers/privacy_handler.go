@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	grpcDelivery "apiGateway/internal/grpc"
+	"apiGateway/internal/proto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// privacyRequestResponse — запрос на выгрузку или удаление данных в ответах API
+type privacyRequestResponse struct {
+	RequestId   int32  `json:"request_id"`
+	Kind        string `json:"kind"`
+	Status      string `json:"status"`
+	CreatedAt   string `json:"created_at"`
+	CompletedAt string `json:"completed_at,omitempty"`
+}
+
+func newPrivacyRequestResponse(r *proto.PrivacyRequest) privacyRequestResponse {
+	return privacyRequestResponse{
+		RequestId:   r.Id,
+		Kind:        r.Kind,
+		Status:      r.Status,
+		CreatedAt:   r.CreatedAt,
+		CompletedAt: r.CompletedAt,
+	}
+}
+
+// RegisterPrivacyRoutes регистрирует выгрузку данных и удаление учетной записи
+func RegisterPrivacyRoutes(r *gin.Engine, userClient *grpcDelivery.UserClient, evictor *UserEvictor) {
+	// Сервисы собирают данные асинхронно, статус проверяется через GET /me/export/:id
+	r.POST("/me/export", func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		req, err := userClient.ExportMyData(c.Request.Context(), userID)
+		if err != nil {
+			respondError(c, err, "export failed")
+			return
+		}
+		c.JSON(http.StatusAccepted, newPrivacyRequestResponse(req))
+	})
+
+	// Пока выгрузка собирается — 202 со статусом, потом — сам архив
+	r.GET("/me/export/:id", func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		requestID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid export id"})
+			return
+		}
+		export, err := userClient.GetDataExport(c.Request.Context(), userID, int32(requestID))
+		if err != nil {
+			respondError(c, err, "failed to get export")
+			return
+		}
+		if len(export.Archive) == 0 {
+			c.JSON(http.StatusAccepted, newPrivacyRequestResponse(export.Request))
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="export-%d.json"`, requestID))
+		c.Data(http.StatusOK, "application/json", export.Archive)
+	})
+
+	// Удаление учетной записи; требует пароль, даже если запрос пришел с токеном сессии
+	r.DELETE("/me", func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		var body struct {
+			Password string `json:"password"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil || body.Password == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "password is required"})
+			return
+		}
+		req, err := userClient.RequestAccountDeletion(c.Request.Context(), userID, body.Password)
+		if err != nil {
+			respondError(c, err, "account deletion failed")
+			return
+		}
+		// Остальные сессии попадут в denylist по событию; текущую отзываем сразу
+		if err := evictor.EvictUser(c.Request.Context(), userID, []int32{currentSessionID(c)}); err != nil {
+			respondCacheError(c)
+			return
+		}
+		c.JSON(http.StatusAccepted, newPrivacyRequestResponse(req))
+	})
+}
+
+// UserEvictor сбрасывает кеш профиля, сессий и API-ключей пользователя и
+// добавляет отозванные сессии в denylist
+type UserEvictor struct {
+	cache    grpcDelivery.Cache
+	sessions *grpcDelivery.SessionValidator
+	apiKeys  *grpcDelivery.APIKeyValidator
+}
+
+func NewUserEvictor(cache grpcDelivery.Cache, sessions *grpcDelivery.SessionValidator, apiKeys *grpcDelivery.APIKeyValidator) *UserEvictor {
+	return &UserEvictor{cache: cache, sessions: sessions, apiKeys: apiKeys}
+}
+
+// EvictUser возвращает ошибку, если сброс не дойдет до Redis: тогда другие
+// экземпляры gateway будут видеть старый профиль, отозванные сессии и
+// закешированные сессии и ключи с прежней ролью
+func (e *UserEvictor) EvictUser(ctx context.Context, userID int32, sessionIDs []int32) error {
+	err := e.cache.Delete(ctx, profileCacheKey(userID))
+	if err != nil {
+		slog.WarnContext(ctx, "failed to evict cached profile", "user_id", userID, "error", err)
+		if errors.Is(err, grpcDelivery.ErrCacheDeferred) {
+			err = nil
+		}
+	}
+	revoke := make([]int32, 0, len(sessionIDs))
+	for _, id := range sessionIDs {
+		if id != 0 {
+			revoke = append(revoke, id)
+		}
+	}
+	return errors.Join(err, e.sessions.Revoke(ctx, revoke...), e.sessions.EvictUser(ctx, userID), e.apiKeys.EvictUser(ctx, userID))
+}
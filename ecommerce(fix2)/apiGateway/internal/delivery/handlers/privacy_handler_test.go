@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	grpcDelivery "apiGateway/internal/grpc"
+	"apiGateway/internal/proto"
+	"context"
+	"testing"
+)
+
+func (f *fakeUserService) ValidateSession(context.Context, *proto.ValidateSessionRequest) (*proto.ValidateSessionResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &proto.ValidateSessionResponse{
+		Session: &proto.Session{Id: 11},
+		User:    &proto.UserResponse{Id: 7, Username: "alice", Role: f.role},
+	}, nil
+}
+
+func (f *fakeUserService) setRole(role string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.role = role
+}
+
+// После смены роли закешированная сессия не должна сохранять прежнюю
+func TestEvictUserDropsCachedSessionRole(t *testing.T) {
+	fake := &fakeUserService{role: "admin"}
+	users := startUserService(t, fake)
+	cache := newMemCache()
+	sessions := grpcDelivery.NewSessionValidator(users, cache)
+	evictor := NewUserEvictor(cache, sessions, grpcDelivery.NewAPIKeyValidator(users, cache))
+	ctx := context.Background()
+
+	role := func() string {
+		t.Helper()
+		info, err := sessions.Validate(ctx, "session-token")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Role
+	}
+
+	if got := role(); got != "admin" {
+		t.Fatalf("role = %q, want admin", got)
+	}
+	fake.setRole("user")
+	if got := role(); got != "admin" {
+		t.Fatalf("role = %q, want the cached admin before eviction", got)
+	}
+	if err := evictor.EvictUser(ctx, 7, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := role(); got != "user" {
+		t.Errorf("role after eviction = %q, want user", got)
+	}
+}
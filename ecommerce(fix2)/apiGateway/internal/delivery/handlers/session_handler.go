@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	grpcDelivery "apiGateway/internal/grpc"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterSessionRoutes регистрирует маршруты для просмотра и отзыва сессий текущего пользователя
+func RegisterSessionRoutes(r *gin.Engine, userClient *grpcDelivery.UserClient, sessions *grpcDelivery.SessionValidator) {
+	me := r.Group("/me/sessions")
+
+	me.GET("", func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		list, err := userClient.ListSessions(c.Request.Context(), userID)
+		if err != nil {
+			respondError(c, err, "failed to list sessions")
+			return
+		}
+
+		current := currentSessionID(c)
+		result := make([]gin.H, 0, len(list))
+		for _, s := range list {
+			result = append(result, gin.H{
+				"id":           s.Id,
+				"device":       s.Device,
+				"ip":           s.Ip,
+				"user_agent":   s.UserAgent,
+				"created_at":   s.CreatedAt,
+				"last_seen_at": s.LastSeenAt,
+				"current":      s.Id == current,
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"sessions": result})
+	})
+
+	me.DELETE("/:id", func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		sessionID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+			return
+		}
+
+		if err := userClient.RevokeSession(c.Request.Context(), userID, int32(sessionID)); err != nil {
+			respondError(c, err, "failed to revoke session")
+			return
+		}
+		if err := sessions.Revoke(c.Request.Context(), int32(sessionID)); err != nil {
+			respondCacheError(c)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	// Выход на всех остальных устройствах; текущая сессия остается
+	me.DELETE("", func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		revoked, err := userClient.RevokeAllSessions(c.Request.Context(), userID, currentSessionID(c))
+		if err != nil {
+			respondError(c, err, "failed to revoke sessions")
+			return
+		}
+		if err := sessions.Revoke(c.Request.Context(), revoked...); err != nil {
+			respondCacheError(c)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"revoked": len(revoked)})
+	})
+}
+
+// currentSessionID возвращает ID сессии запроса; 0 — запрос пришел с Basic auth
+func currentSessionID(c *gin.Context) int32 {
+	id, _ := c.Get("session_id")
+	sessionID, _ := id.(int32)
+	return sessionID
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	grpcDelivery "apiGateway/internal/grpc"
+	"apiGateway/internal/proto"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+)
+
+func (f *fakeUserService) RevokeSession(context.Context, *proto.RevokeSessionRequest) (*proto.Empty, error) {
+	return &proto.Empty{}, nil
+}
+
+// Сессия уже отозвана в UserService; ответ зависит от того, дошел ли отзыв
+// до Redis или хотя бы встал в очередь повтора
+func TestRevokeSessionCacheWrite(t *testing.T) {
+	tests := []struct {
+		name      string
+		redisDown bool
+		fillQueue bool // занять всю очередь повтора до запроса
+		wantCode  int
+	}{
+		{name: "written to Redis", wantCode: http.StatusNoContent},
+		{name: "deferred while Redis is down", redisDown: true, wantCode: http.StatusNoContent},
+		{name: "replay queue is full", redisDown: true, fillQueue: true, wantCode: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := miniredis.RunT(t)
+			cache := grpcDelivery.NewResilientCache(grpcDelivery.NewRedisClient(m.Addr()), 100)
+			if tt.redisDown {
+				m.Close()
+			}
+			if tt.fillQueue {
+				fillReplayQueue(t, cache)
+			}
+
+			users := startUserService(t, &fakeUserService{})
+			r := gin.New()
+			r.Use(func(c *gin.Context) { c.Set("user_id", "7") })
+			RegisterSessionRoutes(r, users, grpcDelivery.NewSessionValidator(users, cache))
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/me/sessions/11", nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantCode, w.Body)
+			}
+		})
+	}
+}
+
+// fillReplayQueue пишет в кеш при недоступном Redis, пока очередь повтора
+// не перестанет принимать записи
+func fillReplayQueue(t *testing.T, cache *grpcDelivery.ResilientCache) {
+	t.Helper()
+	ctx := context.Background()
+	for i := 0; i < 1_000_000; i++ {
+		err := cache.Set(ctx, fmt.Sprintf("fill:%d", i), true, time.Minute)
+		if !errors.Is(err, grpcDelivery.ErrCacheDeferred) {
+			if err == nil {
+				t.Fatal("cache write succeeded with Redis down")
+			}
+			return
+		}
+	}
+	t.Fatal("replay queue never filled up")
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	grpcDelivery "apiGateway/internal/grpc"
+	"apiGateway/internal/middleware"
 	"apiGateway/internal/proto"
 	"fmt"
 	"net/http"
@@ -9,30 +10,37 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
-func RegisterRoutes(r *gin.Engine, userClient *grpcDelivery.UserClient, redisClient *grpcDelivery.RedisClient) {
+func RegisterRoutes(r *gin.Engine, userClient *grpcDelivery.UserClient, cache grpcDelivery.Cache, evictor *UserEvictor) {
 	r.POST("/register", func(c *gin.Context) {
 		var body struct {
 			Username string `json:"username"`
 			Password string `json:"password"`
+			Email    string `json:"email"`
 		}
 		if err := c.ShouldBindJSON(&body); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
 			return
 		}
-		user, err := userClient.Register(body.Username, body.Password)
+		user, err := userClient.Register(c.Request.Context(), body.Username, body.Password, body.Email)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "registration failed: " + err.Error()})
+			respondError(c, err, "registration failed")
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"id": user.Id, "username": user.Username})
+		c.JSON(http.StatusOK, gin.H{"id": user.Id, "username": user.Username, "email": user.Email})
 	})
 
 	r.POST("/login", func(c *gin.Context) {
 		var body struct {
 			Username string `json:"username"`
 			Password string `json:"password"`
+			Device   string `json:"device"`
 		}
 		if err := c.ShouldBindJSON(&body); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
@@ -45,87 +53,319 @@ func RegisterRoutes(r *gin.Engine, userClient *grpcDelivery.UserClient, redisCli
 			Password: body.Password,
 		}
 
-		user, err := userClient.Authenticate(c, req)
+		var trailer metadata.MD
+		user, err := userClient.Authenticate(grpcDelivery.WithClientIP(c, c.ClientIP()), req, grpc.Trailer(&trailer))
 		if err != nil {
+			if middleware.AbortIfLocked(c, err, trailer) {
+				return
+			}
+			// Пароль верный, но включена 2FA — клиент должен прислать код на /login/2fa
+			if challenge := trailer.Get(grpcDelivery.ChallengeMetadataKey); status.Code(err) == codes.FailedPrecondition && len(challenge) > 0 {
+				c.JSON(http.StatusOK, gin.H{"otp_required": true, "challenge_token": challenge[0]})
+				return
+			}
+			if status.Code(err) == codes.PermissionDenied {
+				respondError(c, err, "login failed")
+				return
+			}
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"user_id": user.Id, "token": "Bearer " + strconv.Itoa(int(user.Id))})
+		startSession(c, userClient, user, body.Device)
 	})
 
-	r.GET("/profile/:id", func(c *gin.Context) {
-		idStr := c.Param("id")
-		id, err := strconv.Atoi(idStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+	// Второй шаг входа: токен из ответа /login и код из приложения-аутентификатора
+	// (или резервный код)
+	r.POST("/login/2fa", func(c *gin.Context) {
+		var body struct {
+			ChallengeToken string `json:"challenge_token"`
+			Code           string `json:"code"`
+			Device         string `json:"device"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
 			return
 		}
 
-		// Try to get from cache first
-		cacheKey := fmt.Sprintf("user:%d", id)
-		var cachedUser struct {
-			Id       int32  `json:"id"`
-			Username string `json:"username"`
+		var trailer metadata.MD
+		user, err := userClient.VerifyTOTP(grpcDelivery.WithClientIP(c, c.ClientIP()), body.ChallengeToken, body.Code, grpc.Trailer(&trailer))
+		if err != nil {
+			if middleware.AbortIfLocked(c, err, trailer) {
+				return
+			}
+			if code := status.Code(err); code == codes.InvalidArgument || code == codes.PermissionDenied {
+				respondError(c, err, "login failed")
+				return
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+			return
 		}
+		startSession(c, userClient, user, body.Device)
+	})
 
-		err = redisClient.Get(c.Request.Context(), cacheKey, &cachedUser)
-		if err == nil {
-			c.JSON(http.StatusOK, cachedUser)
+	// Подключение 2FA: enroll выдает секрет и otpauth:// URI для QR-кода,
+	// confirm включает 2FA по первому коду и возвращает резервные коды
+	r.POST("/me/2fa/enroll", func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 			return
 		}
+		resp, err := userClient.EnrollTOTP(c.Request.Context(), userID)
+		if err != nil {
+			respondError(c, err, "two-factor setup failed")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"secret": resp.Secret, "otpauth_uri": resp.OtpauthUri})
+	})
 
-		// If not in cache, get from service
-		user, err := userClient.GetProfile(int32(id))
+	r.POST("/me/2fa/confirm", func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		var body struct {
+			Code string `json:"code"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+			return
+		}
+		recoveryCodes, err := userClient.ConfirmTOTP(c.Request.Context(), userID, body.Code)
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			respondError(c, err, "two-factor setup failed")
 			return
 		}
+		c.JSON(http.StatusOK, gin.H{"recovery_codes": recoveryCodes})
+	})
 
-		// Cache the result
-		userData := struct {
-			Id       int32  `json:"id"`
-			Username string `json:"username"`
-		}{
-			Id:       user.Id,
-			Username: user.Username,
+	// Профиль текущего пользователя
+	r.GET("/me", func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		getProfile(c, userClient, cache, userID)
+	})
+
+	// Частичное обновление: меняются только переданные поля
+	r.PATCH("/me", func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
 		}
-		redisClient.Set(c.Request.Context(), cacheKey, userData, 30*time.Minute)
+		updateProfile(c, userClient, evictor, userID)
+	})
 
-		c.JSON(http.StatusOK, userData)
+	// Чужие профили доступны только администраторам
+	r.GET("/profile/:id", func(c *gin.Context) {
+		id, ok := profileID(c)
+		if !ok {
+			return
+		}
+		getProfile(c, userClient, cache, id)
 	})
 
-	// Add cache invalidation for user updates
 	r.PUT("/profile/:id", func(c *gin.Context) {
-		idStr := c.Param("id")
-		id, err := strconv.Atoi(idStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		id, ok := profileID(c)
+		if !ok {
 			return
 		}
+		updateProfile(c, userClient, evictor, id)
+	})
 
+	// Запрос на сброс пароля. Ответ всегда одинаковый, чтобы по нему нельзя
+	// было узнать, зарегистрирован ли адрес.
+	r.POST("/password/reset-request", func(c *gin.Context) {
 		var body struct {
-			Username string `json:"username"`
+			Email string `json:"email"`
 		}
-		if err := c.ShouldBindJSON(&body); err != nil {
+		if err := c.ShouldBindJSON(&body); err != nil || body.Email == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
 			return
 		}
+		if err := userClient.RequestPasswordReset(c.Request.Context(), body.Email); err != nil {
+			respondError(c, err, "password reset failed")
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": "if the email is registered, a reset link has been sent"})
+	})
 
-		// Update user in service
-		req := &proto.UpdateProfileRequest{
-			Id:       int32(id),
-			Username: body.Username,
+	r.POST("/password/reset", func(c *gin.Context) {
+		var body struct {
+			Token       string `json:"token"`
+			NewPassword string `json:"new_password"`
 		}
-		user, err := userClient.UpdateProfile(c.Request.Context(), req)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "update failed"})
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+			return
+		}
+		if err := userClient.ResetPassword(c.Request.Context(), body.Token, body.NewPassword); err != nil {
+			respondError(c, err, "request failed")
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	r.GET("/email/verify", func(c *gin.Context) {
+		if err := userClient.VerifyEmail(c.Request.Context(), c.Query("token")); err != nil {
+			respondError(c, err, "request failed")
 			return
 		}
+		c.JSON(http.StatusOK, gin.H{"message": "email verified"})
+	})
+}
+
+// profileResponse — профиль в ответах API и в кеше gateway
+type profileResponse struct {
+	Id            int32  `json:"id"`
+	Username      string `json:"username"`
+	DisplayName   string `json:"display_name"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
 
-		// Invalidate cache
-		cacheKey := fmt.Sprintf("user:%d", id)
-		redisClient.Delete(c.Request.Context(), cacheKey)
+func newProfileResponse(user *proto.UserResponse) profileResponse {
+	return profileResponse{
+		Id:            user.Id,
+		Username:      user.Username,
+		DisplayName:   user.DisplayName,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+	}
+}
+
+func profileCacheKey(id int32) string {
+	return fmt.Sprintf("user:v2:%d", id)
+}
+
+func getProfile(c *gin.Context, userClient *grpcDelivery.UserClient, cache grpcDelivery.Cache, id int32) {
+	// Try to get from cache first
+	var cached profileResponse
+	if err := cache.Get(c.Request.Context(), profileCacheKey(id), &cached); err == nil {
+		c.JSON(http.StatusOK, cached)
+		return
+	}
+
+	// If not in cache, get from service
+	user, err := userClient.GetProfile(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	profile := newProfileResponse(user)
+	cache.Set(c.Request.Context(), profileCacheKey(id), profile, 30*time.Minute)
+	c.JSON(http.StatusOK, profile)
+}
+
+// updateProfile обновляет только поля, которые есть в теле запроса
+func updateProfile(c *gin.Context, userClient *grpcDelivery.UserClient, evictor *UserEvictor, id int32) {
+	var body struct {
+		Username        *string `json:"username"`
+		DisplayName     *string `json:"display_name"`
+		Email           *string `json:"email"`
+		CurrentPassword string  `json:"current_password"`
+		NewPassword     *string `json:"new_password"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	req := &proto.UpdateProfileRequest{
+		Id:              id,
+		CurrentPassword: body.CurrentPassword,
+		UpdateMask:      &fieldmaskpb.FieldMask{},
+	}
+	// Смена пароля завершает все сессии, кроме текущей; если пароль меняет
+	// администратор, у пользователя завершаются все
+	if userID, _ := currentUserID(c); userID == id {
+		req.CurrentSessionId = currentSessionID(c)
+	}
+	if body.Username != nil {
+		req.Username = *body.Username
+		req.UpdateMask.Paths = append(req.UpdateMask.Paths, "username")
+	}
+	if body.DisplayName != nil {
+		req.DisplayName = *body.DisplayName
+		req.UpdateMask.Paths = append(req.UpdateMask.Paths, "display_name")
+	}
+	if body.Email != nil {
+		req.Email = *body.Email
+		req.UpdateMask.Paths = append(req.UpdateMask.Paths, "email")
+	}
+	if body.NewPassword != nil {
+		req.NewPassword = *body.NewPassword
+		req.UpdateMask.Paths = append(req.UpdateMask.Paths, "password")
+	}
+	if len(req.UpdateMask.Paths) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nothing to update"})
+		return
+	}
 
-		c.JSON(http.StatusOK, gin.H{"id": user.Id, "username": user.Username})
+	user, err := userClient.UpdateProfile(c.Request.Context(), req)
+	if err != nil {
+		respondError(c, err, "update failed")
+		return
+	}
+
+	// Сбрасываем профиль в кеше и запрещаем сессии, завершенные сменой пароля
+	if err := evictor.EvictUser(c.Request.Context(), id, user.GetRevokedSessionIds()); err != nil {
+		respondCacheError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, newProfileResponse(user))
+}
+
+// profileID разбирает :id и проверяет, что это профиль текущего пользователя
+// или что запрос делает администратор
+func profileID(c *gin.Context) (int32, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, false
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return 0, false
+	}
+	if int32(id) != userID && c.GetString("role") != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return 0, false
+	}
+	return int32(id), true
+}
+
+// startSession открывает сессию после успешного входа и отвечает ее токеном
+func startSession(c *gin.Context, userClient *grpcDelivery.UserClient, user *proto.UserResponse, device string) {
+	resp, err := userClient.CreateSession(c.Request.Context(), &proto.CreateSessionRequest{
+		UserId:    user.Id,
+		Device:    device,
+		Ip:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "login failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":    user.Id,
+		"session_id": resp.Session.GetId(),
+		"token":      "Bearer " + resp.Token,
 	})
 }
+
+// currentUserID возвращает ID пользователя, которого аутентифицировал middleware.Auth
+func currentUserID(c *gin.Context) (int32, bool) {
+	id, err := strconv.Atoi(c.GetString("user_id"))
+	if err != nil {
+		return 0, false
+	}
+	return int32(id), true
+}
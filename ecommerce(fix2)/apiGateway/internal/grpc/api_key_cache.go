@@ -0,0 +1,127 @@
+package grpcDelivery
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ErrAPIKeyRevoked возвращается для ключа из denylist
+var ErrAPIKeyRevoked = errors.New("api key revoked")
+
+// APIKeyInfo — проверенный API-ключ, от имени владельца которого выполняется запрос
+type APIKeyInfo struct {
+	KeyID  int32    `json:"key_id"`
+	UserID int32    `json:"user_id"`
+	Role   string   `json:"role"`
+	Scopes []string `json:"scopes"`
+	// Gen — поколение ключей владельца, с которым ключ попал в кеш (см. EvictUser)
+	Gen int64 `json:"gen"`
+}
+
+func (k *APIKeyInfo) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyValidator проверяет API-ключи через UserService так же, как
+// SessionValidator проверяет сессии: результат кешируется на
+// sessionCacheTTL, отозванные ключи попадают в denylist. Кеш ключей
+// пользователя целиком сбрасывает EvictUser.
+type APIKeyValidator struct {
+	users *UserClient
+	cache Cache
+}
+
+func NewAPIKeyValidator(users *UserClient, cache Cache) *APIKeyValidator {
+	return &APIKeyValidator{users: users, cache: cache}
+}
+
+// Validate возвращает ключ. Ошибки UserService возвращаются как есть.
+func (v *APIKeyValidator) Validate(ctx context.Context, key string) (*APIKeyInfo, error) {
+	cacheKey := apiKeyCacheKey(key)
+
+	var info APIKeyInfo
+	if err := v.cache.Get(ctx, cacheKey, &info); err == nil {
+		if v.isRevoked(ctx, info.KeyID) {
+			return nil, ErrAPIKeyRevoked
+		}
+		// Поколение сменилось — владельца отключили или сменили ему роль,
+		// ключ проверяется в UserService заново
+		if info.Gen == v.userGen(ctx, info.UserID) {
+			return &info, nil
+		}
+	}
+
+	resp, err := v.users.ValidateApiKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	info = APIKeyInfo{
+		KeyID:  resp.ApiKey.GetId(),
+		UserID: resp.User.GetId(),
+		Role:   resp.User.GetRole(),
+		Scopes: resp.ApiKey.GetScopes(),
+	}
+	info.Gen = v.userGen(ctx, info.UserID)
+	if err := v.cache.Set(ctx, cacheKey, info, sessionCacheTTL); err != nil {
+		slog.WarnContext(ctx, "failed to cache API key", "api_key_id", info.KeyID, "error", err)
+	}
+	return &info, nil
+}
+
+// Revoke добавляет ключи в denylist. Ошибка означает, что запись не дойдет
+// до Redis и другие экземпляры gateway будут принимать отозванное до
+// истечения своего кеша.
+func (v *APIKeyValidator) Revoke(ctx context.Context, keyIDs ...int32) error {
+	var errs []error
+	for _, id := range keyIDs {
+		if err := v.cache.Set(ctx, revokedAPIKeyKey(id), true, sessionCacheTTL); err != nil {
+			slog.ErrorContext(ctx, "failed to add API key to denylist", "api_key_id", id, "error", err)
+			errs = append(errs, lost(err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// EvictUser сбрасывает кеш всех ключей пользователя: закешированные роль и
+// сам факт, что владелец активен, перестают действовать. Ключи по ID для
+// этого знать не нужно, в кеше меняется только поколение пользователя.
+func (v *APIKeyValidator) EvictUser(ctx context.Context, userID int32) error {
+	if _, err := v.cache.Incr(ctx, apiKeyUserGenKey(userID)); err != nil {
+		slog.ErrorContext(ctx, "failed to evict cached API keys", "user_id", userID, "error", err)
+		return lost(err)
+	}
+	return nil
+}
+
+func (v *APIKeyValidator) userGen(ctx context.Context, userID int32) int64 {
+	var gen int64
+	_ = v.cache.Get(ctx, apiKeyUserGenKey(userID), &gen)
+	return gen
+}
+
+func (v *APIKeyValidator) isRevoked(ctx context.Context, keyID int32) bool {
+	var revoked bool
+	return v.cache.Get(ctx, revokedAPIKeyKey(keyID), &revoked) == nil && revoked
+}
+
+func apiKeyCacheKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "apikey:v1:" + hex.EncodeToString(sum[:])
+}
+
+func apiKeyUserGenKey(userID int32) string {
+	return fmt.Sprintf("apikey:user:%d:gen", userID)
+}
+
+func revokedAPIKeyKey(id int32) string {
+	return fmt.Sprintf("apikey:revoked:%d", id)
+}
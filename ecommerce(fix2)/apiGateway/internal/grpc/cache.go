@@ -0,0 +1,272 @@
+package grpcDelivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCacheMiss возвращается, если ключа нет в кеше
+var ErrCacheMiss = errors.New("cache miss")
+
+// errCacheUnavailable возвращается, пока breaker не пропускает вызовы к Redis
+var errCacheUnavailable = errors.New("cache unavailable")
+
+// ErrCacheDeferred возвращают Set, Delete и Incr, если Redis недоступен:
+// запись уже видна в локальном кеше и будет повторена в Redis, когда он
+// вернется. Остальные ошибки записи означают, что запись потеряна.
+var ErrCacheDeferred = errors.New("cache write deferred until Redis is available")
+
+// lost оставляет только ошибки потерянных записей: отложенная запись еще
+// дойдет до Redis
+func lost(err error) error {
+	if errors.Is(err, ErrCacheDeferred) {
+		return nil
+	}
+	return err
+}
+
+// Cache — кеш, которым пользуются обработчики gateway
+type Cache interface {
+	Get(ctx context.Context, key string, dest interface{}) error
+	// GetDel читает ключ и удаляет его: из одновременных GetDel одного
+	// ключа значение получит только один
+	GetDel(ctx context.Context, key string, dest interface{}) error
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	Delete(ctx context.Context, key string) error
+	Incr(ctx context.Context, key string) (int64, error)
+}
+
+const (
+	cacheOpTimeout        = 100 * time.Millisecond
+	cacheBreakerThreshold = 5
+	cacheBreakerTimeout   = 10 * time.Second
+)
+
+// CacheStats — счетчики обращений к кешу
+type CacheStats struct {
+	Hits         uint64 `json:"hits"`
+	Misses       uint64 `json:"misses"`
+	Errors       uint64 `json:"errors"`
+	FallbackHits uint64 `json:"fallback_hits"`
+}
+
+// CacheStatus описывает состояние кеша для health endpoint
+type CacheStatus struct {
+	Status     string     `json:"status"`
+	Breaker    string     `json:"breaker"`
+	LocalItems int        `json:"local_items"`
+	Deferred   int        `json:"deferred_writes"`
+	Stats      CacheStats `json:"stats"`
+}
+
+// ResilientCache работает с Redis через circuit breaker и с короткими
+// таймаутами. Все записи дублируются в ограниченный in-process LRU,
+// из которого читаются данные, пока Redis недоступен. Записи, не дошедшие
+// до Redis, повторяются, когда он возвращается (см. replayQueue).
+type ResilientCache struct {
+	redis   *RedisClient
+	local   *lruCache
+	breaker *circuitBreaker
+	replays *replayQueue
+
+	hits, misses, errors, fallbackHits atomic.Uint64
+}
+
+// NewResilientCache создает кеш поверх Redis с локальным LRU на localSize записей
+func NewResilientCache(redis *RedisClient, localSize int) *ResilientCache {
+	return &ResilientCache{
+		redis:   redis,
+		local:   newLRUCache(localSize),
+		breaker: newCircuitBreaker(cacheBreakerThreshold, cacheBreakerTimeout),
+		replays: newReplayQueue(),
+	}
+}
+
+func (c *ResilientCache) Get(ctx context.Context, key string, dest interface{}) error {
+	val, err := c.getRaw(ctx, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(val, dest)
+}
+
+func (c *ResilientCache) GetDel(ctx context.Context, key string, dest interface{}) error {
+	val, err := c.getDelRaw(ctx, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(val, dest)
+}
+
+func (c *ResilientCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	val, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	c.local.Set(key, val, expiration)
+	w := replayWrite{kind: replaySet, val: val}
+	if expiration > 0 {
+		w.expiresAt = time.Now().Add(expiration)
+	}
+	return c.write(ctx, key, w, func(ctx context.Context) error {
+		return c.redis.setRaw(ctx, key, val, expiration)
+	})
+}
+
+func (c *ResilientCache) Delete(ctx context.Context, key string) error {
+	c.local.Delete(key)
+	return c.write(ctx, key, replayWrite{kind: replayDelete}, func(ctx context.Context) error {
+		return c.redis.Delete(ctx, key)
+	})
+}
+
+func (c *ResilientCache) Incr(ctx context.Context, key string) (int64, error) {
+	var n int64
+	err := c.write(ctx, key, replayWrite{kind: replayIncr}, func(ctx context.Context) error {
+		var err error
+		n, err = c.redis.Incr(ctx, key)
+		return err
+	})
+	if err != nil {
+		// Redis недоступен — считаем локально, чтобы инвалидация продолжала работать
+		return c.local.Incr(key), err
+	}
+	c.local.Set(key, []byte(strconv.FormatInt(n, 10)), 0)
+	return n, nil
+}
+
+// write выполняет запись в Redis, а если это не удалось, ставит ее в
+// очередь повтора. Ключ, который уже ждет повтора, сразу встает в очередь:
+// иначе повтор перезаписал бы новое значение старым.
+func (c *ResilientCache) write(ctx context.Context, key string, w replayWrite, op func(ctx context.Context) error) error {
+	if !c.replays.has(key) {
+		if err := c.do(ctx, op); err == nil {
+			return nil
+		}
+	}
+	if !c.replays.add(key, w) {
+		slog.ErrorContext(ctx, "cache replay queue is full, write to Redis is lost", "key", key)
+		return errCacheUnavailable
+	}
+	return ErrCacheDeferred
+}
+
+// Status возвращает состояние кеша: up — Redis доступен, degraded — работает
+// только локальный уровень
+func (c *ResilientCache) Status() CacheStatus {
+	state := c.breaker.State()
+	status := "up"
+	if state != breakerClosed {
+		status = "degraded"
+	}
+	return CacheStatus{
+		Status:     status,
+		Breaker:    state.String(),
+		LocalItems: c.local.Len(),
+		Deferred:   c.replays.len(),
+		Stats:      c.Stats(),
+	}
+}
+
+func (c *ResilientCache) Stats() CacheStats {
+	return CacheStats{
+		Hits:         c.hits.Load(),
+		Misses:       c.misses.Load(),
+		Errors:       c.errors.Load(),
+		FallbackHits: c.fallbackHits.Load(),
+	}
+}
+
+func (c *ResilientCache) getRaw(ctx context.Context, key string) ([]byte, error) {
+	var val []byte
+	err := c.do(ctx, func(ctx context.Context) error {
+		var err error
+		val, err = c.redis.getRaw(ctx, key)
+		return err
+	})
+	switch {
+	case err == nil:
+		c.hits.Add(1)
+		return val, nil
+	case errors.Is(err, ErrCacheMiss):
+		// Redis — основной источник: если там ключа нет, локальной копии не верим
+		c.misses.Add(1)
+		return nil, ErrCacheMiss
+	}
+
+	if val, ok := c.local.Get(key); ok {
+		c.hits.Add(1)
+		c.fallbackHits.Add(1)
+		return val, nil
+	}
+	c.misses.Add(1)
+	return nil, ErrCacheMiss
+}
+
+// getDelRaw забирает ключ из Redis, а пока Redis недоступен — из локального
+// уровня; удаление забранного ключа в Redis тогда повторяется, когда он
+// вернется. Ключ, который еще ждет повтора записи, в Redis пока устаревший,
+// и верна локальная копия.
+func (c *ResilientCache) getDelRaw(ctx context.Context, key string) ([]byte, error) {
+	local, localOK := c.local.GetDel(key)
+	if !c.replays.has(key) {
+		var val []byte
+		err := c.do(ctx, func(ctx context.Context) error {
+			var err error
+			val, err = c.redis.getDelRaw(ctx, key)
+			return err
+		})
+		switch {
+		case err == nil:
+			c.hits.Add(1)
+			return val, nil
+		case errors.Is(err, ErrCacheMiss):
+			c.misses.Add(1)
+			return nil, ErrCacheMiss
+		}
+	}
+
+	if !localOK {
+		c.misses.Add(1)
+		return nil, ErrCacheMiss
+	}
+	if !c.replays.add(key, replayWrite{kind: replayDelete}) {
+		slog.ErrorContext(ctx, "cache replay queue is full, write to Redis is lost", "key", key)
+	}
+	c.hits.Add(1)
+	c.fallbackHits.Add(1)
+	return local, nil
+}
+
+// do выполняет операцию с Redis с таймаутом, если breaker это разрешает
+func (c *ResilientCache) do(ctx context.Context, op func(ctx context.Context) error) error {
+	if !c.breaker.Allow() {
+		return errCacheUnavailable
+	}
+
+	opCtx, cancel := context.WithTimeout(ctx, cacheOpTimeout)
+	defer cancel()
+
+	err := op(opCtx)
+	if err != nil && !errors.Is(err, ErrCacheMiss) {
+		if ctx.Err() != nil {
+			// Запрос отменил клиент, Redis тут ни при чем
+			return err
+		}
+		c.errors.Add(1)
+		c.breaker.Failure()
+		if c.breaker.State() == breakerOpen {
+			slog.WarnContext(ctx, "Redis unavailable, falling back to local cache", "error", err)
+		}
+		return err
+	}
+	c.breaker.Success()
+	c.replay()
+	return err
+}
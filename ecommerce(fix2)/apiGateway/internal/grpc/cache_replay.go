@@ -0,0 +1,139 @@
+package grpcDelivery
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// cacheReplayLimit ограничивает число ключей, ждущих записи в Redis
+const cacheReplayLimit = 10000
+
+type replayKind int
+
+const (
+	replaySet replayKind = iota
+	replayDelete
+	replayIncr
+)
+
+// replayWrite — последняя запись ключа, которая не дошла до Redis
+type replayWrite struct {
+	kind      replayKind
+	val       []byte
+	expiresAt time.Time // нулевое — без TTL
+	seq       uint64
+}
+
+// replayQueue хранит записи, которые не удалось выполнить в Redis, и
+// повторяет их, когда Redis возвращается. Без этого отзыв сессии, сброс
+// профиля или поколения списков товаров, сделанный во время сбоя, остался бы
+// только в локальном кеше этого экземпляра, а остальные продолжали бы читать
+// из Redis устаревшие данные.
+//
+// Для ключа хранится только последняя запись: она и определяет итоговое
+// значение. Несколько Incr одного ключа схлопываются в один — счетчики в
+// кеше служат поколениями, и важно лишь, что значение сменилось.
+type replayQueue struct {
+	mu      sync.Mutex
+	pending map[string]replayWrite
+	seq     uint64
+	running atomic.Bool
+}
+
+func newReplayQueue() *replayQueue {
+	return &replayQueue{pending: make(map[string]replayWrite)}
+}
+
+// add ставит запись в очередь; false — очередь переполнена и запись потеряна
+func (q *replayQueue) add(key string, w replayWrite) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.pending[key]; !ok && len(q.pending) >= cacheReplayLimit {
+		return false
+	}
+	q.seq++
+	w.seq = q.seq
+	q.pending[key] = w
+	return true
+}
+
+// has сообщает, ждет ли ключ повтора: новая запись такого ключа тоже должна
+// встать в очередь, иначе повтор перезапишет ее старым значением
+func (q *replayQueue) has(key string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	_, ok := q.pending[key]
+	return ok
+}
+
+func (q *replayQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.pending)
+}
+
+func (q *replayQueue) snapshot() map[string]replayWrite {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	writes := make(map[string]replayWrite, len(q.pending))
+	for key, w := range q.pending {
+		writes[key] = w
+	}
+	return writes
+}
+
+// done убирает запись, если пока шел повтор ее не заменили более новой
+func (q *replayQueue) done(key string, seq uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if w, ok := q.pending[key]; ok && w.seq == seq {
+		delete(q.pending, key)
+	}
+}
+
+// replay повторяет отложенные записи в фоне. Вызывается после успешного
+// обращения к Redis; одновременно идет не больше одного повтора, и первая
+// же ошибка прерывает его до следующего успешного обращения.
+func (c *ResilientCache) replay() {
+	if c.replays.len() == 0 || !c.replays.running.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer c.replays.running.Store(false)
+
+		ctx := context.Background()
+		writes := c.replays.snapshot()
+		for key, w := range writes {
+			err := c.do(ctx, func(ctx context.Context) error {
+				switch w.kind {
+				case replayDelete:
+					return c.redis.Delete(ctx, key)
+				case replayIncr:
+					_, err := c.redis.Incr(ctx, key)
+					return err
+				}
+				var ttl time.Duration
+				if !w.expiresAt.IsZero() {
+					if ttl = time.Until(w.expiresAt); ttl <= 0 {
+						return nil // запись истекла бы и в Redis
+					}
+				}
+				return c.redis.setRaw(ctx, key, w.val, ttl)
+			})
+			if err != nil {
+				slog.Warn("failed to replay cache writes, will retry", "pending", c.replays.len(), "error", err)
+				return
+			}
+			c.replays.done(key, w.seq)
+		}
+		slog.Info("replayed cache writes made while Redis was unavailable", "count", len(writes))
+	}()
+}
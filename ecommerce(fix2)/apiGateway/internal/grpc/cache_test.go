@@ -0,0 +1,51 @@
+package grpcDelivery
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestResilientCacheGetDel(t *testing.T) {
+	tests := []struct {
+		name string
+		// down — Redis недоступен при записи; back — вернулся к чтению
+		down, back bool
+	}{
+		{name: "Redis available"},
+		{name: "Redis down", down: true},
+		{name: "Redis back before the write is replayed", down: true, back: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := miniredis.RunT(t)
+			c := NewResilientCache(NewRedisClient(m.Addr()), 10)
+			ctx := context.Background()
+			if tt.down {
+				m.Close()
+			}
+			if err := c.Set(ctx, "state", "login", time.Minute); err != nil && !errors.Is(err, ErrCacheDeferred) {
+				t.Fatal(err)
+			}
+			if tt.back {
+				if err := m.Restart(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var got string
+			if err := c.GetDel(ctx, "state", &got); err != nil || got != "login" {
+				t.Fatalf("first GetDel = %q, %v; want login", got, err)
+			}
+			if err := c.GetDel(ctx, "state", &got); !errors.Is(err, ErrCacheMiss) {
+				t.Fatalf("second GetDel = %v, want cache miss", err)
+			}
+			if !tt.down && m.Exists("state") {
+				t.Error("key is still in Redis")
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package grpcDelivery
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	const openTimeout = 20 * time.Millisecond
+
+	// Шаги: f — ошибка, s — успех, w — ждать openTimeout, a — вызов Allow
+	tests := []struct {
+		name      string
+		steps     string
+		wantState breakerState
+		wantAllow bool
+	}{
+		{name: "closed below threshold", steps: "ff", wantState: breakerClosed, wantAllow: true},
+		{name: "opens at threshold", steps: "fff", wantState: breakerOpen, wantAllow: false},
+		{name: "success resets failures", steps: "ffsff", wantState: breakerClosed, wantAllow: true},
+		{name: "half-open after timeout", steps: "fffwa", wantState: breakerHalfOpen, wantAllow: false},
+		{name: "probe success closes", steps: "fffwas", wantState: breakerClosed, wantAllow: true},
+		{name: "probe failure reopens", steps: "fffwaf", wantState: breakerOpen, wantAllow: false},
+		{name: "stuck probe lets the next one through", steps: "fffwaw", wantState: breakerHalfOpen, wantAllow: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newCircuitBreaker(3, openTimeout)
+			for _, step := range tt.steps {
+				switch step {
+				case 'f':
+					b.Failure()
+				case 's':
+					b.Success()
+				case 'w':
+					time.Sleep(openTimeout)
+				case 'a':
+					if !b.Allow() {
+						t.Fatalf("after %q: probe call not allowed", tt.steps)
+					}
+				}
+			}
+			if got := b.State(); got != tt.wantState {
+				t.Errorf("state = %s, want %s", got, tt.wantState)
+			}
+			if got := b.Allow(); got != tt.wantAllow {
+				t.Errorf("Allow() = %v, want %v", got, tt.wantAllow)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package grpcDelivery
+
+import (
+	"apiGateway/internal/mtls"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// DownstreamHealth опрашивает сервисы за gateway по протоколу grpc.health.v1.
+// Соединения для проверок свои: клиенты сервисов их наружу не отдают, а
+// grpc.Dial ленивый и ничего не стоит, пока проверок нет.
+type DownstreamHealth struct {
+	names   []string
+	clients map[string]healthpb.HealthClient
+}
+
+// Downstream — адрес сервиса и его имя в сертификате
+type Downstream struct {
+	Addr     string
+	Identity string
+}
+
+// NewDownstreamHealth принимает сервисы по именам в ответе, например "user"
+func NewDownstreamHealth(services map[string]Downstream, store *mtls.Store) (*DownstreamHealth, error) {
+	h := &DownstreamHealth{clients: make(map[string]healthpb.HealthClient, len(services))}
+	for name, svc := range services {
+		creds := credentials.NewTLS(store.ClientConfig(svc.Identity))
+		conn, err := grpc.Dial(svc.Addr, grpc.WithTransportCredentials(creds))
+		if err != nil {
+			return nil, fmt.Errorf("dial %s health: %w", name, err)
+		}
+		h.names = append(h.names, name)
+		h.clients[name] = healthpb.NewHealthClient(conn)
+	}
+	sort.Strings(h.names)
+	return h, nil
+}
+
+// Check опрашивает сервисы параллельно и возвращает статус каждого: SERVING,
+// NOT_SERVING или код ошибки gRPC, если сервис не ответил
+func (h *DownstreamHealth) Check(ctx context.Context) map[string]string {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		result = make(map[string]string, len(h.names))
+	)
+	for _, name := range h.names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			resp, err := h.clients[name].Check(ctx, &healthpb.HealthCheckRequest{})
+			state := resp.GetStatus().String()
+			if err != nil {
+				state = status.Code(err).String()
+			}
+			mu.Lock()
+			result[name] = state
+			mu.Unlock()
+		}(name)
+	}
+	wg.Wait()
+	return result
+}
@@ -0,0 +1,127 @@
+package grpcDelivery
+
+import (
+	"apiGateway/internal/logging"
+	"apiGateway/internal/metrics"
+	"apiGateway/internal/mtls"
+	"apiGateway/internal/proto"
+	"apiGateway/internal/proto/inventory"
+	"apiGateway/internal/proto/order"
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// ClientOptions — политика вызовов сервисов, общая для всех клиентов
+type ClientOptions struct {
+	Timeout       time.Duration // дедлайн вызова, если у контекста его нет
+	RetryAttempts int           // попыток идемпотентного вызова, 1 — без повторов
+	RetryBackoff  time.Duration // пауза перед первым повтором, дальше удваивается
+	TLS           *mtls.Store   // сертификат gateway и CA для mTLS
+}
+
+// Имена сервисов в их сертификатах (CN). Gateway соединяется только с тем
+// сервисом, которого ждет по адресу, а сервисы по этому же имени узнают gateway.
+const (
+	UserServiceIdentity      = "userService"
+	InventoryServiceIdentity = "inventoryService"
+	OrderServiceIdentity     = "orderService"
+)
+
+// maxRetryBackoff ограничивает рост паузы между повторами
+const maxRetryBackoff = 2 * time.Second
+
+// idempotent — вызовы, которые безопасно повторить: чтение и запись, которая
+// устанавливает значение, а не меняет текущее. Создание, удаление, вход и
+// отправка писем не повторяются: первая попытка могла дойти до сервиса.
+var idempotent = map[string]bool{
+	inventory.InventoryService_GetProduct_FullMethodName:    true,
+	inventory.InventoryService_ListProducts_FullMethodName:  true,
+	inventory.InventoryService_UpdateProduct_FullMethodName: true,
+	order.OrderService_GetOrder_FullMethodName:              true,
+	order.OrderService_ListOrdersByUser_FullMethodName:      true,
+	order.OrderService_UpdateOrderStatus_FullMethodName:     true,
+	proto.UserService_GetProfile_FullMethodName:             true,
+	proto.UserService_ValidateSession_FullMethodName:        true,
+	proto.UserService_ListSessions_FullMethodName:           true,
+	proto.UserService_GetAddress_FullMethodName:             true,
+	proto.UserService_ListAddresses_FullMethodName:          true,
+	proto.UserService_GetDataExport_FullMethodName:          true,
+	proto.UserService_ListUsers_FullMethodName:              true,
+	proto.UserService_DisableUser_FullMethodName:            true,
+	proto.UserService_EnableUser_FullMethodName:             true,
+	proto.UserService_SetUserRole_FullMethodName:            true,
+	proto.UserService_ListApiKeys_FullMethodName:            true,
+	proto.UserService_ValidateApiKey_FullMethodName:         true,
+}
+
+// dialOptions — mTLS с сервисом identity, трассировка и перехватчики клиентов
+// сервисов. Дедлайн стоит перед повторами и ограничивает вызов целиком, а
+// метрики — после них и считают каждую попытку.
+func dialOptions(opts ClientOptions, identity string) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithTransportCredentials(credentials.NewTLS(opts.TLS.ClientConfig(identity))),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithChainUnaryInterceptor(
+			logging.UnaryClientInterceptor(),
+			UnaryClientTimeout(opts.Timeout),
+			UnaryClientRetry(opts.RetryAttempts, opts.RetryBackoff),
+			metrics.UnaryClientInterceptor(),
+		),
+	}
+}
+
+// UnaryClientTimeout задает дедлайн вызову, у контекста которого его нет.
+// Обработчики gin передают контекст HTTP-запроса без дедлайна, и без этого
+// вызов к зависшему сервису ждал бы, пока клиент не закроет соединение.
+func UnaryClientTimeout(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// UnaryClientRetry повторяет идемпотентные вызовы, получившие Unavailable:
+// сервис перезапускается или соединение еще не установлено. Паузы растут
+// вдвое со случайным разбросом, чтобы повторы от разных запросов не приходили
+// одновременно; повтор, который не успевает до дедлайна, не делается.
+func UnaryClientRetry(attempts int, backoff time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if attempts <= 1 || !idempotent[method] {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		wait := backoff
+		for attempt := 1; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if status.Code(err) != codes.Unavailable || attempt == attempts {
+				return err
+			}
+
+			pause := wait/2 + rand.N(wait/2+1)
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < pause {
+				return err
+			}
+			slog.WarnContext(ctx, "retrying service call",
+				"method", method, "attempt", attempt, "backoff", pause, "error", status.Convert(err).Message())
+			timer := time.NewTimer(pause)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+			wait = min(wait*2, maxRetryBackoff)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package grpcDelivery
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLRUEviction(t *testing.T) {
+	// Шаги: "set k", "get k", "del k"
+	tests := []struct {
+		name  string
+		steps []string
+		want  []string // оставшиеся ключи
+	}{
+		{
+			name:  "oldest is evicted",
+			steps: []string{"set a", "set b", "set c", "set d"},
+			want:  []string{"b", "c", "d"},
+		},
+		{
+			name:  "get keeps a key",
+			steps: []string{"set a", "set b", "set c", "get a", "set d"},
+			want:  []string{"a", "c", "d"},
+		},
+		{
+			name:  "overwrite keeps a key",
+			steps: []string{"set a", "set b", "set c", "set a", "set d"},
+			want:  []string{"a", "c", "d"},
+		},
+		{
+			name:  "delete frees a slot",
+			steps: []string{"set a", "set b", "set c", "del b", "set d"},
+			want:  []string{"a", "c", "d"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newLRUCache(3)
+			for _, step := range tt.steps {
+				op, key := step[:3], step[4:]
+				switch op {
+				case "set":
+					c.Set(key, []byte(key), 0)
+				case "get":
+					c.Get(key)
+				case "del":
+					c.Delete(key)
+				}
+			}
+
+			var got []string
+			for _, key := range []string{"a", "b", "c", "d"} {
+				if _, ok := c.Get(key); ok {
+					got = append(got, key)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("keys = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLRUExpiry(t *testing.T) {
+	c := newLRUCache(3)
+	c.Set("a", []byte("a"), time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	if _, ok := c.Get("a"); ok {
+		t.Error("expired key returned")
+	}
+	if n := c.Len(); n != 0 {
+		t.Errorf("Len() = %d, want 0", n)
+	}
+}
@@ -0,0 +1,232 @@
+package grpcDelivery
+
+import (
+	"apiGateway/internal/proto/inventory"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// productCacheVersion входит во все ключи; при изменении формата записей
+	// достаточно поднять версию, и старые ключи просто истекут
+	productCacheVersion = "v1"
+	productListGenKey   = "products:" + productCacheVersion + ":list:gen"
+
+	productSoftTTL        = 1 * time.Minute  // после него запись обновляется в фоне
+	productHardTTL        = 10 * time.Minute // TTL ключа в Redis
+	productNegativeTTL    = 30 * time.Second // TTL для закешированных 404
+	productRefreshTimeout = 5 * time.Second
+
+	// productVersionStripes — число счетчиков версий ключей. Ключи делят
+	// счетчики по хешу: совпадение лишь пропускает запись в кеш
+	productVersionStripes = 256
+)
+
+// productCacheEntry хранится в Redis. До SoftExpiry запись считается свежей,
+// после — отдается как есть, но параллельно перечитывается из InventoryService.
+type productCacheEntry struct {
+	Product    *inventory.Product   `json:"product,omitempty"`
+	Products   []*inventory.Product `json:"products,omitempty"`
+	NotFound   bool                 `json:"not_found,omitempty"`
+	SoftExpiry time.Time            `json:"soft_expiry"`
+}
+
+type productFetchFunc func(ctx context.Context) (*productCacheEntry, error)
+
+// CachedInventoryClient кеширует ответы GetProduct и ListProducts
+// и сбрасывает кеш при изменении товаров
+type CachedInventoryClient struct {
+	inventory.InventoryServiceClient
+	cache Cache
+	group singleflight.Group
+
+	// versions растут при каждой инвалидации ключа. Чтение, начатое до
+	// инвалидации, видит новую версию и не записывает в кеш старые данные.
+	versions [productVersionStripes]atomic.Uint64
+}
+
+// NewCachedInventoryClient оборачивает клиент InventoryService кешем
+func NewCachedInventoryClient(client inventory.InventoryServiceClient, cache Cache) *CachedInventoryClient {
+	return &CachedInventoryClient{
+		InventoryServiceClient: client,
+		cache:                  cache,
+	}
+}
+
+// GetProduct возвращает товар из кеша, при промахе — из InventoryService
+func (c *CachedInventoryClient) GetProduct(ctx context.Context, in *inventory.ProductID, opts ...grpc.CallOption) (*inventory.Product, error) {
+	entry, err := c.load(ctx, productKey(in.Id), func(ctx context.Context) (*productCacheEntry, error) {
+		p, err := c.InventoryServiceClient.GetProduct(ctx, &inventory.ProductID{Id: in.Id}, opts...)
+		if status.Code(err) == codes.NotFound {
+			return &productCacheEntry{NotFound: true}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &productCacheEntry{Product: p}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if entry.NotFound {
+		return nil, status.Errorf(codes.NotFound, "product %d not found", in.Id)
+	}
+	return entry.Product, nil
+}
+
+// ListProducts возвращает список товаров из кеша, при промахе — из InventoryService
+func (c *CachedInventoryClient) ListProducts(ctx context.Context, in *inventory.Empty, opts ...grpc.CallOption) (*inventory.ProductList, error) {
+	entry, err := c.load(ctx, c.listKey(ctx), func(ctx context.Context) (*productCacheEntry, error) {
+		list, err := c.InventoryServiceClient.ListProducts(ctx, &inventory.Empty{}, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &productCacheEntry{Products: list.Products}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &inventory.ProductList{Products: entry.Products}, nil
+}
+
+// CreateProduct создает товар и сбрасывает закешированные списки, а также 404,
+// закешированный для нового id до создания
+func (c *CachedInventoryClient) CreateProduct(ctx context.Context, in *inventory.Product, opts ...grpc.CallOption) (*inventory.Product, error) {
+	p, err := c.InventoryServiceClient.CreateProduct(ctx, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.InvalidateProduct(ctx, p.Id)
+	return p, nil
+}
+
+// UpdateProduct обновляет товар и сбрасывает его кеш
+func (c *CachedInventoryClient) UpdateProduct(ctx context.Context, in *inventory.Product, opts ...grpc.CallOption) (*inventory.Product, error) {
+	p, err := c.InventoryServiceClient.UpdateProduct(ctx, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.InvalidateProduct(ctx, in.Id)
+	return p, nil
+}
+
+// DeleteProduct удаляет товар и сбрасывает его кеш
+func (c *CachedInventoryClient) DeleteProduct(ctx context.Context, in *inventory.ProductID, opts ...grpc.CallOption) (*inventory.Empty, error) {
+	res, err := c.InventoryServiceClient.DeleteProduct(ctx, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.InvalidateProduct(ctx, in.Id)
+	return res, nil
+}
+
+// InvalidateProduct удаляет товар из кеша вместе со всеми списками товаров.
+// Ошибка означает, что сброс не дойдет до Redis и другие экземпляры gateway
+// будут отдавать старые данные, пока записи не истекут.
+func (c *CachedInventoryClient) InvalidateProduct(ctx context.Context, id int32) error {
+	key := productKey(id)
+	// Новые запросы не должны присоединяться к уже идущему (устаревшему)
+	// чтению, а само это чтение не должно записать результат в кеш
+	c.version(key).Add(1)
+	c.group.Forget(key)
+	err := c.cache.Delete(ctx, key)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to invalidate cached product", "key", key, "error", err)
+	}
+	return errors.Join(lost(err), c.invalidateLists(ctx))
+}
+
+// invalidateLists поднимает поколение списков, после чего старые ключи списков
+// больше не читаются и истекают по TTL
+func (c *CachedInventoryClient) invalidateLists(ctx context.Context) error {
+	_, err := c.cache.Incr(ctx, productListGenKey)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to invalidate cached product lists", "error", err)
+	}
+	return lost(err)
+}
+
+// load читает запись из кеша. Промахи для одного ключа схлопываются в один
+// запрос к InventoryService, а устаревшие записи обновляются в фоне.
+func (c *CachedInventoryClient) load(ctx context.Context, key string, fetch productFetchFunc) (*productCacheEntry, error) {
+	var entry productCacheEntry
+	if err := c.cache.Get(ctx, key, &entry); err == nil {
+		if time.Now().After(entry.SoftExpiry) {
+			// Обновление переживает запрос, но сохраняет его request id и трассу
+			c.group.DoChan(key, func() (interface{}, error) {
+				return c.fetchAndStore(context.WithoutCancel(ctx), key, fetch)
+			})
+		}
+		return &entry, nil
+	}
+
+	// Запрос выполняется от имени всех ожидающих, поэтому отмена первого
+	// клиента не должна обрывать его для остальных
+	v, err, _ := c.group.Do(key, func() (interface{}, error) {
+		return c.fetchAndStore(context.WithoutCancel(ctx), key, fetch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*productCacheEntry), nil
+}
+
+func (c *CachedInventoryClient) fetchAndStore(ctx context.Context, key string, fetch productFetchFunc) (*productCacheEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, productRefreshTimeout)
+	defer cancel()
+
+	version := c.version(key).Load()
+	entry, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	soft, hard := productSoftTTL, productHardTTL
+	if entry.NotFound {
+		soft, hard = productNegativeTTL, productNegativeTTL
+	}
+	entry.SoftExpiry = time.Now().Add(soft)
+
+	if c.version(key).Load() != version {
+		// Ключ сбросили, пока шло чтение: ответ отдаем, но не кешируем
+		return entry, nil
+	}
+	if err := c.cache.Set(ctx, key, entry, hard); err != nil {
+		slog.WarnContext(ctx, "failed to cache product", "key", key, "error", err)
+	}
+	// Инвалидация могла пройти между проверкой и записью; тогда ее Delete
+	// выполнился раньше нашего Set, и запись надо убрать еще раз
+	if c.version(key).Load() != version {
+		if err := c.cache.Delete(ctx, key); err != nil {
+			slog.WarnContext(ctx, "failed to drop stale cached product", "key", key, "error", err)
+		}
+	}
+	return entry, nil
+}
+
+func (c *CachedInventoryClient) version(key string) *atomic.Uint64 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &c.versions[h.Sum32()%productVersionStripes]
+}
+
+func (c *CachedInventoryClient) listKey(ctx context.Context) string {
+	var gen int64
+	// Если счетчика еще нет, используется нулевое поколение
+	_ = c.cache.Get(ctx, productListGenKey, &gen)
+	return fmt.Sprintf("products:%s:list:%d", productCacheVersion, gen)
+}
+
+func productKey(id int32) string {
+	return fmt.Sprintf("product:%s:%d", productCacheVersion, id)
+}
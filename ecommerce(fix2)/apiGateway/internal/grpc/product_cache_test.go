@@ -0,0 +1,180 @@
+package grpcDelivery
+
+import (
+	"apiGateway/internal/proto/inventory"
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// memCache — Cache в памяти вместо Redis
+type memCache struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func newMemCache() *memCache {
+	return &memCache{data: make(map[string][]byte)}
+}
+
+func (m *memCache) Get(_ context.Context, key string, dest interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	raw, ok := m.data[key]
+	if !ok {
+		return ErrCacheMiss
+	}
+	return json.Unmarshal(raw, dest)
+}
+
+func (m *memCache) GetDel(_ context.Context, key string, dest interface{}) error {
+	m.mu.Lock()
+	raw, ok := m.data[key]
+	delete(m.data, key)
+	m.mu.Unlock()
+	if !ok {
+		return ErrCacheMiss
+	}
+	return json.Unmarshal(raw, dest)
+}
+
+func (m *memCache) Set(_ context.Context, key string, value interface{}, _ time.Duration) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = raw
+	return nil
+}
+
+func (m *memCache) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, key)
+	return nil
+}
+
+func (m *memCache) Incr(_ context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, _ := strconv.ParseInt(string(m.data[key]), 10, 64)
+	n++
+	m.data[key] = []byte(strconv.FormatInt(n, 10))
+	return n, nil
+}
+
+func (m *memCache) has(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.data[key]
+	return ok
+}
+
+// fakeInventory — InventoryService с одним изменяемым товаром. Если задан
+// block, GetProduct сообщает о начале чтения в started и ждет, пока block
+// закроют.
+type fakeInventory struct {
+	inventory.InventoryServiceClient
+
+	mu      sync.Mutex
+	product *inventory.Product
+	started chan struct{}
+	block   chan struct{}
+}
+
+func (f *fakeInventory) GetProduct(ctx context.Context, in *inventory.ProductID, _ ...grpc.CallOption) (*inventory.Product, error) {
+	f.mu.Lock()
+	p := f.product
+	f.mu.Unlock()
+	if f.block != nil {
+		select {
+		case f.started <- struct{}{}:
+		default:
+		}
+		<-f.block
+	}
+	if p == nil || p.Id != in.Id {
+		return nil, status.Errorf(codes.NotFound, "product %d not found", in.Id)
+	}
+	return p, nil
+}
+
+func (f *fakeInventory) CreateProduct(ctx context.Context, in *inventory.Product, _ ...grpc.CallOption) (*inventory.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.product = &inventory.Product{Id: 7, Name: in.Name}
+	return f.product, nil
+}
+
+func TestCreateProductDropsCachedNotFound(t *testing.T) {
+	cache := newMemCache()
+	client := NewCachedInventoryClient(&fakeInventory{}, cache)
+	ctx := context.Background()
+
+	if _, err := client.GetProduct(ctx, &inventory.ProductID{Id: 7}); status.Code(err) != codes.NotFound {
+		t.Fatalf("before create: err = %v, want NotFound", err)
+	}
+	if _, err := client.CreateProduct(ctx, &inventory.Product{Name: "lamp"}); err != nil {
+		t.Fatal(err)
+	}
+	p, err := client.GetProduct(ctx, &inventory.ProductID{Id: 7})
+	if err != nil {
+		t.Fatalf("after create: err = %v, want the new product", err)
+	}
+	if p.Name != "lamp" {
+		t.Errorf("name = %q, want lamp", p.Name)
+	}
+}
+
+func TestInvalidateDuringFetchIsNotOverwritten(t *testing.T) {
+	cache := newMemCache()
+	inv := &fakeInventory{
+		product: &inventory.Product{Id: 7, Name: "old"},
+		started: make(chan struct{}, 1),
+		block:   make(chan struct{}),
+	}
+	client := NewCachedInventoryClient(inv, cache)
+	ctx := context.Background()
+
+	done := make(chan *inventory.Product)
+	go func() {
+		p, err := client.GetProduct(ctx, &inventory.ProductID{Id: 7})
+		if err != nil {
+			t.Error(err)
+		}
+		done <- p
+	}()
+	<-inv.started
+
+	// Товар изменился, пока чтение старой версии еще шло
+	inv.mu.Lock()
+	inv.product = &inventory.Product{Id: 7, Name: "new"}
+	inv.mu.Unlock()
+	if err := client.InvalidateProduct(ctx, 7); err != nil {
+		t.Fatal(err)
+	}
+	close(inv.block)
+
+	if p := <-done; p.Name != "old" {
+		t.Fatalf("in-flight read = %q, want old", p.Name)
+	}
+	if cache.has(productKey(7)) {
+		t.Fatal("read started before the invalidation was cached")
+	}
+	p, err := client.GetProduct(ctx, &inventory.ProductID{Id: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "new" {
+		t.Errorf("after invalidation = %q, want new", p.Name)
+	}
+}
@@ -0,0 +1,168 @@
+package grpcDelivery
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimitResult — результат списания токена из корзины
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // через сколько корзина снова будет полной
+	RetryAfter time.Duration // через сколько появится следующий токен (если отказано)
+}
+
+// tokenBucketScript атомарно пополняет корзину по времени Redis и списывает
+// из нее один токен. Возвращает {allowed, tokens}.
+var tokenBucketScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local period_ms = tonumber(ARGV[2])
+local rate = limit / period_ms
+
+local t = redis.call('TIME')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1]) or limit
+local ts = tonumber(data[2]) or now
+
+tokens = math.min(limit, tokens + (now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], period_ms)
+return {allowed, tostring(tokens)}
+`)
+
+const localBucketsLimit = 100000
+
+// RateLimiter реализует token bucket в Redis. Пока Redis недоступен,
+// корзины ведутся в памяти процесса.
+type RateLimiter struct {
+	redis   *RedisClient
+	breaker *circuitBreaker
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	ts     time.Time
+	limit  float64
+	rate   float64 // токенов в наносекунду
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(b.limit, b.tokens+float64(now.Sub(b.ts))*b.rate)
+	b.ts = now
+}
+
+func NewRateLimiter(redis *RedisClient) *RateLimiter {
+	return &RateLimiter{
+		redis:   redis,
+		breaker: newCircuitBreaker(cacheBreakerThreshold, cacheBreakerTimeout),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Take списывает токен из корзины key, в которую помещается limit токенов
+// и которая полностью пополняется за period
+func (l *RateLimiter) Take(ctx context.Context, key string, limit int, period time.Duration) RateLimitResult {
+	if l.breaker.Allow() {
+		tokens, allowed, err := l.takeRedis(ctx, key, limit, period)
+		if err == nil {
+			l.breaker.Success()
+			return newRateLimitResult(allowed, tokens, limit, period)
+		}
+		if ctx.Err() == nil {
+			l.breaker.Failure()
+			if l.breaker.State() == breakerOpen {
+				slog.WarnContext(ctx, "Redis unavailable, using in-memory rate limit buckets", "error", err)
+			}
+		}
+	}
+
+	tokens, allowed := l.takeLocal(key, limit, period)
+	return newRateLimitResult(allowed, tokens, limit, period)
+}
+
+func (l *RateLimiter) takeRedis(ctx context.Context, key string, limit int, period time.Duration) (float64, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, cacheOpTimeout)
+	defer cancel()
+
+	res, err := tokenBucketScript.Run(ctx, l.redis.client, []string{key}, limit, period.Milliseconds()).Slice()
+	if err != nil {
+		return 0, false, err
+	}
+	allowed, _ := res[0].(int64)
+	tokensStr, _ := res[1].(string)
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return tokens, allowed == 1, nil
+}
+
+func (l *RateLimiter) takeLocal(key string, limit int, period time.Duration) (float64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= localBucketsLimit {
+			l.evictFullLocked(now)
+		}
+		b = &tokenBucket{
+			tokens: float64(limit),
+			ts:     now,
+			limit:  float64(limit),
+			rate:   float64(limit) / float64(period),
+		}
+		l.buckets[key] = b
+	}
+
+	b.refill(now)
+	if b.tokens < 1 {
+		return b.tokens, false
+	}
+	b.tokens--
+	return b.tokens, true
+}
+
+// evictFullLocked удаляет корзины, которые уже пополнились до конца:
+// их состояние не отличается от новой корзины
+func (l *RateLimiter) evictFullLocked(now time.Time) {
+	for key, b := range l.buckets {
+		if b.refill(now); b.tokens >= b.limit {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func newRateLimitResult(allowed bool, tokens float64, limit int, period time.Duration) RateLimitResult {
+	perToken := period / time.Duration(limit)
+	res := RateLimitResult{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(limit) - tokens) * float64(perToken)),
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1 - tokens) * float64(perToken))
+	}
+	return res
+}
@@ -0,0 +1,77 @@
+package grpcDelivery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestRateLimiterTokenBucket(t *testing.T) {
+	const (
+		limit  = 3
+		period = 3 * time.Second // токен в секунду
+	)
+	type take struct {
+		after     time.Duration // на сколько сдвинуть часы Redis перед вызовом
+		allowed   bool
+		remaining int
+	}
+	tests := []struct {
+		name  string
+		takes []take
+	}{
+		{
+			name:  "full bucket allows limit requests",
+			takes: []take{{0, true, 2}, {0, true, 1}, {0, true, 0}},
+		},
+		{
+			name:  "empty bucket denies",
+			takes: []take{{0, true, 2}, {0, true, 1}, {0, true, 0}, {0, false, 0}, {500 * time.Millisecond, false, 0}},
+		},
+		{
+			name:  "one token refills per period/limit",
+			takes: []take{{0, true, 2}, {0, true, 1}, {0, true, 0}, {time.Second, true, 0}, {0, false, 0}},
+		},
+		{
+			name:  "refill stops at limit",
+			takes: []take{{0, true, 2}, {0, true, 1}, {time.Minute, true, 2}, {0, true, 1}, {0, true, 0}, {0, false, 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := miniredis.RunT(t)
+			now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			m.SetTime(now)
+			l := NewRateLimiter(NewRedisClient(m.Addr()))
+
+			for i, tk := range tt.takes {
+				now = now.Add(tk.after)
+				m.SetTime(now)
+				res := l.Take(context.Background(), "rl:test", limit, period)
+				if res.Allowed != tk.allowed || res.Remaining != tk.remaining {
+					t.Fatalf("take %d: allowed = %v, remaining = %d; want %v, %d", i+1, res.Allowed, res.Remaining, tk.allowed, tk.remaining)
+				}
+				if !res.Allowed && (res.RetryAfter <= 0 || res.RetryAfter > period/limit) {
+					t.Errorf("take %d: RetryAfter = %s, want (0, %s]", i+1, res.RetryAfter, period/limit)
+				}
+			}
+			if l.breaker.State() != breakerClosed {
+				t.Error("Redis calls failed, buckets were kept in memory")
+			}
+		})
+	}
+}
+
+func TestRateLimiterFallsBackToLocalBuckets(t *testing.T) {
+	m := miniredis.RunT(t)
+	l := NewRateLimiter(NewRedisClient(m.Addr()))
+	m.Close()
+
+	for i, want := range []bool{true, true, false} {
+		if res := l.Take(context.Background(), "rl:test", 2, time.Hour); res.Allowed != want {
+			t.Fatalf("take %d: allowed = %v, want %v", i+1, res.Allowed, want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package grpcDelivery
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -12,11 +13,17 @@ type RedisClient struct {
 	client *redis.Client
 }
 
-func NewRedisClient() *RedisClient {
+func NewRedisClient(addr string) *RedisClient {
 	client := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379",
+		Addr:     addr,
 		Password: "", // no password set
 		DB:       0,  // use default DB
+
+		// Короткие таймауты: недоступный Redis не должен тормозить запросы
+		DialTimeout:  200 * time.Millisecond,
+		ReadTimeout:  100 * time.Millisecond,
+		WriteTimeout: 100 * time.Millisecond,
+		MaxRetries:   -1,
 	})
 
 	return &RedisClient{
@@ -24,12 +31,16 @@ func NewRedisClient() *RedisClient {
 	}
 }
 
+func (r *RedisClient) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
 func (r *RedisClient) Get(ctx context.Context, key string, dest interface{}) error {
-	val, err := r.client.Get(ctx, key).Result()
+	val, err := r.getRaw(ctx, key)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal([]byte(val), dest)
+	return json.Unmarshal(val, dest)
 }
 
 func (r *RedisClient) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
@@ -37,9 +48,47 @@ func (r *RedisClient) Set(ctx context.Context, key string, value interface{}, ex
 	if err != nil {
 		return err
 	}
-	return r.client.Set(ctx, key, json, expiration).Err()
+	return r.setRaw(ctx, key, json, expiration)
+}
+
+// GetDel читает ключ и удаляет его одной командой GETDEL
+func (r *RedisClient) GetDel(ctx context.Context, key string, dest interface{}) error {
+	val, err := r.getDelRaw(ctx, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(val, dest)
 }
 
 func (r *RedisClient) Delete(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
-} 
\ No newline at end of file
+}
+
+func (r *RedisClient) Incr(ctx context.Context, key string) (int64, error) {
+	return r.client.Incr(ctx, key).Result()
+}
+
+func (r *RedisClient) getRaw(ctx context.Context, key string) ([]byte, error) {
+	val, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrCacheMiss
+	}
+	return val, err
+}
+
+func (r *RedisClient) getDelRaw(ctx context.Context, key string) ([]byte, error) {
+	val, err := r.client.GetDel(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrCacheMiss
+	}
+	return val, err
+}
+
+func (r *RedisClient) setRaw(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	return r.client.Set(ctx, key, value, expiration).Err()
+}
+
+// Close закрывает пул соединений с Redis
+func (r *RedisClient) Close() error {
+	return r.client.Close()
+}
@@ -3,16 +3,16 @@ package grpcDelivery
 import (
 	"apiGateway/internal/proto/inventory"
 	"apiGateway/internal/proto/order"
-	"log"
+	"log/slog"
 
 	"google.golang.org/grpc"
 )
 
 // NewInventoryClient creates a new gRPC client for inventory service
-func NewInventoryClient(address string) (inventory.InventoryServiceClient, error) {
-	conn, err := grpc.Dial(address, grpc.WithInsecure()) // In production, use grpc.WithTransportCredentials
+func NewInventoryClient(address string, opts ClientOptions) (inventory.InventoryServiceClient, error) {
+	conn, err := grpc.Dial(address, dialOptions(opts, InventoryServiceIdentity)...)
 	if err != nil {
-		log.Printf("failed to connect to inventory service: %v", err)
+		slog.Error("failed to connect to inventory service", "error", err)
 		return nil, err
 	}
 
@@ -21,10 +21,10 @@ func NewInventoryClient(address string) (inventory.InventoryServiceClient, error
 }
 
 // NewOrderClient creates a new gRPC client for order service
-func NewOrderClient(address string) (order.OrderServiceClient, error) {
-	conn, err := grpc.Dial(address, grpc.WithInsecure()) // In production, use grpc.WithTransportCredentials
+func NewOrderClient(address string, opts ClientOptions) (order.OrderServiceClient, error) {
+	conn, err := grpc.Dial(address, dialOptions(opts, OrderServiceIdentity)...)
 	if err != nil {
-		log.Printf("failed to connect to order service: %v", err)
+		slog.Error("failed to connect to order service", "error", err)
 		return nil, err
 	}
 
@@ -0,0 +1,124 @@
+package grpcDelivery
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ErrSessionRevoked возвращается для сессии из denylist
+var ErrSessionRevoked = errors.New("session revoked")
+
+// sessionCacheTTL — сколько gateway доверяет проверенной сессии без обращения
+// к UserService. Столько же живет запись в denylist: позже закешированных
+// проверок отозванной сессии уже не останется.
+const sessionCacheTTL = time.Minute
+
+// SessionInfo — проверенная сессия, от имени которой выполняется запрос
+type SessionInfo struct {
+	SessionID int32  `json:"session_id"`
+	UserID    int32  `json:"user_id"`
+	Role      string `json:"role"`
+	// Gen — поколение сессий пользователя, с которым сессия попала в кеш (см. EvictUser)
+	Gen int64 `json:"gen"`
+}
+
+// SessionValidator проверяет токены сессий через UserService и кеширует
+// результат. Отозванные сессии попадают в denylist в Redis, поэтому
+// перестают действовать сразу, не дожидаясь истечения кеша. Кеш всех
+// сессий пользователя сбрасывает EvictUser.
+type SessionValidator struct {
+	users *UserClient
+	cache Cache
+}
+
+func NewSessionValidator(users *UserClient, cache Cache) *SessionValidator {
+	return &SessionValidator{users: users, cache: cache}
+}
+
+// Validate возвращает сессию по токену. Ошибки UserService (например,
+// Unauthenticated для неизвестного токена) возвращаются как есть.
+func (v *SessionValidator) Validate(ctx context.Context, token string) (*SessionInfo, error) {
+	key := sessionKey(token)
+
+	var info SessionInfo
+	if err := v.cache.Get(ctx, key, &info); err == nil {
+		if v.isRevoked(ctx, info.SessionID) {
+			return nil, ErrSessionRevoked
+		}
+		// Поколение сменилось — пользователя отключили или сменили ему роль,
+		// сессия проверяется в UserService заново
+		if info.Gen == v.userGen(ctx, info.UserID) {
+			return &info, nil
+		}
+	}
+
+	resp, err := v.users.ValidateSession(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	info = SessionInfo{
+		SessionID: resp.Session.GetId(),
+		UserID:    resp.User.GetId(),
+		Role:      resp.User.GetRole(),
+	}
+	info.Gen = v.userGen(ctx, info.UserID)
+	if err := v.cache.Set(ctx, key, info, sessionCacheTTL); err != nil {
+		slog.WarnContext(ctx, "failed to cache session", "session_id", info.SessionID, "error", err)
+	}
+	return &info, nil
+}
+
+// Revoke добавляет сессии в denylist. Ошибка означает, что запись не дойдет
+// до Redis и другие экземпляры gateway будут принимать отозванное до
+// истечения своего кеша.
+func (v *SessionValidator) Revoke(ctx context.Context, sessionIDs ...int32) error {
+	var errs []error
+	for _, id := range sessionIDs {
+		if err := v.cache.Set(ctx, revokedSessionKey(id), true, sessionCacheTTL); err != nil {
+			slog.ErrorContext(ctx, "failed to add session to denylist", "session_id", id, "error", err)
+			errs = append(errs, lost(err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// EvictUser сбрасывает кеш всех сессий пользователя, чтобы закешированная
+// роль перестала действовать. Сессии по ID для этого знать не нужно, в кеше
+// меняется только поколение пользователя.
+func (v *SessionValidator) EvictUser(ctx context.Context, userID int32) error {
+	if _, err := v.cache.Incr(ctx, sessionUserGenKey(userID)); err != nil {
+		slog.ErrorContext(ctx, "failed to evict cached sessions", "user_id", userID, "error", err)
+		return lost(err)
+	}
+	return nil
+}
+
+func (v *SessionValidator) userGen(ctx context.Context, userID int32) int64 {
+	var gen int64
+	_ = v.cache.Get(ctx, sessionUserGenKey(userID), &gen)
+	return gen
+}
+
+func (v *SessionValidator) isRevoked(ctx context.Context, sessionID int32) bool {
+	var revoked bool
+	return v.cache.Get(ctx, revokedSessionKey(sessionID), &revoked) == nil && revoked
+}
+
+// В ключе хранится хеш токена, чтобы сами токены не оседали в Redis
+func sessionKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "session:v1:" + hex.EncodeToString(sum[:])
+}
+
+func sessionUserGenKey(userID int32) string {
+	return fmt.Sprintf("session:user:%d:gen", userID)
+}
+
+func revokedSessionKey(id int32) string {
+	return fmt.Sprintf("session:revoked:%d", id)
+}
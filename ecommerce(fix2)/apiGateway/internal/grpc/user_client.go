@@ -1,20 +1,33 @@
 package grpcDelivery
 
 import (
+	"apiGateway/internal/logging"
 	"apiGateway/internal/proto"
 	"context"
 	"google.golang.org/grpc"
-	"log"
+	"google.golang.org/grpc/metadata"
 )
 
+const (
+	// ClientIPMetadataKey — метаданные, в которых UserService получает IP клиента
+	ClientIPMetadataKey = "x-client-ip"
+	// ChallengeMetadataKey — trailer, в котором UserService возвращает токен второго шага входа
+	ChallengeMetadataKey = "x-2fa-challenge"
+)
+
+// WithClientIP добавляет IP клиента в исходящие метаданные gRPC
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, ClientIPMetadataKey, ip)
+}
+
 type UserClient struct {
 	client proto.UserServiceClient
 }
 
-func NewUserClient(address string) (*UserClient, error) {
-	conn, err := grpc.Dial(address, grpc.WithInsecure()) // В реальной ситуации использовать grpc.WithTransportCredentials
+func NewUserClient(address string, opts ClientOptions) (*UserClient, error) {
+	conn, err := grpc.Dial(address, dialOptions(opts, UserServiceIdentity)...)
 	if err != nil {
-		log.Fatalf("failed to connect to user service: %v", err)
+		logging.Fatal("failed to connect to user service", "error", err)
 		return nil, err
 	}
 
@@ -23,28 +36,212 @@ func NewUserClient(address string) (*UserClient, error) {
 }
 
 // Authenticate перенаправляет запрос авторизации на gRPC сервис
-func (u *UserClient) Authenticate(ctx context.Context, req *proto.AuthRequest) (*proto.UserResponse, error) {
-	return u.client.Authenticate(ctx, req)
+func (u *UserClient) Authenticate(ctx context.Context, req *proto.AuthRequest, opts ...grpc.CallOption) (*proto.UserResponse, error) {
+	return u.client.Authenticate(ctx, req, opts...)
 }
 
 // Register перенаправляет запрос регистрации на gRPC сервис
-func (u *UserClient) Register(username, password string) (*proto.UserResponse, error) {
+func (u *UserClient) Register(ctx context.Context, username, password, email string) (*proto.UserResponse, error) {
 	req := &proto.RegisterRequest{
 		Username: username,
 		Password: password,
+		Email:    email,
 	}
-	return u.client.Register(context.Background(), req)
+	return u.client.Register(ctx, req)
 }
 
 // GetProfile получает профиль пользователя по ID
-func (u *UserClient) GetProfile(id int32) (*proto.UserResponse, error) {
+func (u *UserClient) GetProfile(ctx context.Context, id int32) (*proto.UserResponse, error) {
 	req := &proto.UserID{
 		Id: id,
 	}
-	return u.client.GetProfile(context.Background(), req)
+	return u.client.GetProfile(ctx, req)
 }
 
 // UpdateProfile обновляет профиль пользователя
 func (u *UserClient) UpdateProfile(ctx context.Context, req *proto.UpdateProfileRequest) (*proto.UserResponse, error) {
 	return u.client.UpdateProfile(ctx, req)
 }
+
+// UnlockUser снимает блокировку входа с пользователя
+func (u *UserClient) UnlockUser(ctx context.Context, username string) error {
+	_, err := u.client.UnlockUser(ctx, &proto.UnlockUserRequest{Username: username})
+	return err
+}
+
+// RequestPasswordReset отправляет пользователю письмо со ссылкой для сброса пароля
+func (u *UserClient) RequestPasswordReset(ctx context.Context, email string) error {
+	_, err := u.client.RequestPasswordReset(ctx, &proto.PasswordResetRequest{Email: email})
+	return err
+}
+
+// ResetPassword устанавливает новый пароль по токену из письма
+func (u *UserClient) ResetPassword(ctx context.Context, token, newPassword string) error {
+	_, err := u.client.ResetPassword(ctx, &proto.ResetPasswordRequest{Token: token, NewPassword: newPassword})
+	return err
+}
+
+// VerifyEmail подтверждает email по токену из письма
+func (u *UserClient) VerifyEmail(ctx context.Context, token string) error {
+	_, err := u.client.VerifyEmail(ctx, &proto.VerifyEmailRequest{Token: token})
+	return err
+}
+
+// VerifyTOTP завершает вход с 2FA: обменивает токен второго шага и одноразовый код на пользователя
+func (u *UserClient) VerifyTOTP(ctx context.Context, challengeToken, code string, opts ...grpc.CallOption) (*proto.UserResponse, error) {
+	req := &proto.VerifyTOTPRequest{ChallengeToken: challengeToken, Code: code}
+	return u.client.VerifyTOTP(ctx, req, opts...)
+}
+
+// EnrollTOTP выдает новый TOTP-секрет; 2FA включается после ConfirmTOTP
+func (u *UserClient) EnrollTOTP(ctx context.Context, userID int32) (*proto.EnrollTOTPResponse, error) {
+	return u.client.EnrollTOTP(ctx, &proto.UserID{Id: userID})
+}
+
+// ConfirmTOTP включает 2FA и возвращает резервные коды
+func (u *UserClient) ConfirmTOTP(ctx context.Context, userID int32, code string) ([]string, error) {
+	resp, err := u.client.ConfirmTOTP(ctx, &proto.ConfirmTOTPRequest{UserId: userID, Code: code})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Codes, nil
+}
+
+// CreateSession открывает сессию пользователя и возвращает ее токен
+func (u *UserClient) CreateSession(ctx context.Context, req *proto.CreateSessionRequest) (*proto.CreateSessionResponse, error) {
+	return u.client.CreateSession(ctx, req)
+}
+
+// ValidateSession проверяет токен сессии
+func (u *UserClient) ValidateSession(ctx context.Context, token string) (*proto.ValidateSessionResponse, error) {
+	return u.client.ValidateSession(ctx, &proto.ValidateSessionRequest{Token: token})
+}
+
+// ListSessions возвращает действующие сессии пользователя
+func (u *UserClient) ListSessions(ctx context.Context, userID int32) ([]*proto.Session, error) {
+	resp, err := u.client.ListSessions(ctx, &proto.UserID{Id: userID})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Sessions, nil
+}
+
+// RevokeSession отзывает сессию пользователя
+func (u *UserClient) RevokeSession(ctx context.Context, userID, sessionID int32) error {
+	_, err := u.client.RevokeSession(ctx, &proto.RevokeSessionRequest{UserId: userID, SessionId: sessionID})
+	return err
+}
+
+// RevokeAllSessions отзывает все сессии пользователя, кроме exceptSessionID, и возвращает их ID
+func (u *UserClient) RevokeAllSessions(ctx context.Context, userID, exceptSessionID int32) ([]int32, error) {
+	resp, err := u.client.RevokeAllSessions(ctx, &proto.RevokeAllSessionsRequest{UserId: userID, ExceptSessionId: exceptSessionID})
+	if err != nil {
+		return nil, err
+	}
+	return resp.SessionIds, nil
+}
+
+// CreateAddress добавляет адрес в адресную книгу пользователя
+func (u *UserClient) CreateAddress(ctx context.Context, a *proto.Address) (*proto.Address, error) {
+	return u.client.CreateAddress(ctx, a)
+}
+
+// GetAddress возвращает адрес пользователя
+func (u *UserClient) GetAddress(ctx context.Context, userID, addressID int32) (*proto.Address, error) {
+	return u.client.GetAddress(ctx, &proto.AddressRequest{UserId: userID, AddressId: addressID})
+}
+
+// ListAddresses возвращает адресную книгу пользователя; основной адрес первый
+func (u *UserClient) ListAddresses(ctx context.Context, userID int32) ([]*proto.Address, error) {
+	resp, err := u.client.ListAddresses(ctx, &proto.UserID{Id: userID})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Addresses, nil
+}
+
+// UpdateAddress заменяет поля адреса
+func (u *UserClient) UpdateAddress(ctx context.Context, a *proto.Address) (*proto.Address, error) {
+	return u.client.UpdateAddress(ctx, a)
+}
+
+// DeleteAddress удаляет адрес пользователя
+func (u *UserClient) DeleteAddress(ctx context.Context, userID, addressID int32) error {
+	_, err := u.client.DeleteAddress(ctx, &proto.AddressRequest{UserId: userID, AddressId: addressID})
+	return err
+}
+
+// SetDefaultAddress делает адрес основным
+func (u *UserClient) SetDefaultAddress(ctx context.Context, userID, addressID int32) (*proto.Address, error) {
+	return u.client.SetDefaultAddress(ctx, &proto.AddressRequest{UserId: userID, AddressId: addressID})
+}
+
+// ExportMyData создает запрос на выгрузку данных пользователя
+func (u *UserClient) ExportMyData(ctx context.Context, userID int32) (*proto.PrivacyRequest, error) {
+	return u.client.ExportMyData(ctx, &proto.UserID{Id: userID})
+}
+
+// GetDataExport возвращает состояние выгрузки и архив, если он готов
+func (u *UserClient) GetDataExport(ctx context.Context, userID, requestID int32) (*proto.DataExport, error) {
+	return u.client.GetDataExport(ctx, &proto.PrivacyRequestID{UserId: userID, RequestId: requestID})
+}
+
+// RequestAccountDeletion удаляет учетную запись пользователя
+func (u *UserClient) RequestAccountDeletion(ctx context.Context, userID int32, password string) (*proto.PrivacyRequest, error) {
+	return u.client.RequestAccountDeletion(ctx, &proto.AccountDeletionRequest{UserId: userID, Password: password})
+}
+
+// ListUsers возвращает страницу пользователей для администратора
+func (u *UserClient) ListUsers(ctx context.Context, req *proto.ListUsersRequest) (*proto.UserList, error) {
+	return u.client.ListUsers(ctx, req)
+}
+
+// DisableUser запрещает пользователю вход
+func (u *UserClient) DisableUser(ctx context.Context, id int32) (*proto.UserResponse, error) {
+	return u.client.DisableUser(ctx, &proto.UserID{Id: id})
+}
+
+// EnableUser снова разрешает пользователю вход
+func (u *UserClient) EnableUser(ctx context.Context, id int32) (*proto.UserResponse, error) {
+	return u.client.EnableUser(ctx, &proto.UserID{Id: id})
+}
+
+// SetUserRole меняет роль пользователя
+func (u *UserClient) SetUserRole(ctx context.Context, id int32, role string) (*proto.UserResponse, error) {
+	return u.client.SetUserRole(ctx, &proto.SetUserRoleRequest{Id: id, Role: role})
+}
+
+// CreateApiKey выпускает API-ключ; сам ключ есть только в этом ответе
+func (u *UserClient) CreateApiKey(ctx context.Context, req *proto.CreateApiKeyRequest) (*proto.CreateApiKeyResponse, error) {
+	return u.client.CreateApiKey(ctx, req)
+}
+
+// ListApiKeys возвращает действующие ключи пользователя
+func (u *UserClient) ListApiKeys(ctx context.Context, userID int32) ([]*proto.ApiKey, error) {
+	resp, err := u.client.ListApiKeys(ctx, &proto.UserID{Id: userID})
+	if err != nil {
+		return nil, err
+	}
+	return resp.ApiKeys, nil
+}
+
+// RevokeApiKey отзывает ключ пользователя
+func (u *UserClient) RevokeApiKey(ctx context.Context, userID, keyID int32) error {
+	_, err := u.client.RevokeApiKey(ctx, &proto.ApiKeyRequest{UserId: userID, KeyId: keyID})
+	return err
+}
+
+// RotateApiKey выпускает замену ключа; старый действует еще gracePeriodHours
+func (u *UserClient) RotateApiKey(ctx context.Context, userID, keyID, gracePeriodHours int32) (*proto.CreateApiKeyResponse, error) {
+	return u.client.RotateApiKey(ctx, &proto.RotateApiKeyRequest{UserId: userID, KeyId: keyID, GracePeriodHours: gracePeriodHours})
+}
+
+// ValidateApiKey проверяет ключ и возвращает его вместе с владельцем
+func (u *UserClient) ValidateApiKey(ctx context.Context, key string) (*proto.ValidateApiKeyResponse, error) {
+	return u.client.ValidateApiKey(ctx, &proto.ValidateApiKeyRequest{Key: key})
+}
+
+// LoginWithOIDC находит или создает пользователя по проверенному ID token
+func (u *UserClient) LoginWithOIDC(ctx context.Context, req *proto.OIDCLoginRequest) (*proto.UserResponse, error) {
+	return u.client.LoginWithOIDC(ctx, req)
+}
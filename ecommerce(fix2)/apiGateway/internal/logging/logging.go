@@ -0,0 +1,72 @@
+// Package logging настраивает структурированные логи (log/slog, JSON) и
+// передает request id запроса дальше: в метаданных gRPC и заголовках
+// сообщений RabbitMQ, — чтобы по нему находились все строки одного запроса
+// во всех сервисах.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Setup делает JSON-логгер с уровнем level (debug, info, warn или error)
+// логгером по умолчанию. Стандартный log после этого тоже пишет через него.
+func Setup(level string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q", level)
+	}
+	slog.SetDefault(New(os.Stderr, l))
+	return nil
+}
+
+// New возвращает JSON-логгер, который добавляет к записям request id и
+// trace id из контекста (для этого писать нужно через *Context-методы:
+// slog.InfoContext(ctx, ...)) и скрывает значения секретных полей
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(contextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{
+		AddSource:   true,
+		Level:       level,
+		ReplaceAttr: redact,
+	})})
+}
+
+// Fatal пишет ошибку и завершает процесс — замена log.Fatalf
+func Fatal(msg string, args ...any) {
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:]) // строка, откуда вызван Fatal
+	r := slog.NewRecord(time.Now(), slog.LevelError, msg, pcs[0])
+	r.Add(args...)
+	_ = slog.Default().Handler().Handle(context.Background(), r)
+	os.Exit(1)
+}
+
+// contextHandler дописывает к записи поля из контекста
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
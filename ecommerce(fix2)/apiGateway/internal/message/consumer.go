@@ -0,0 +1,69 @@
+package message
+
+import (
+	"context"
+	"log/slog"
+)
+
+// ProductCacheInvalidator сбрасывает закешированные данные товара
+type ProductCacheInvalidator interface {
+	InvalidateProduct(ctx context.Context, id int32) error
+}
+
+// StockConsumer сбрасывает кеш товаров по событиям изменения остатков
+type StockConsumer struct {
+	cache        ProductCacheInvalidator
+	rabbitClient *RabbitMQClient
+}
+
+// NewStockConsumer создает новый обработчик событий инвентаря
+func NewStockConsumer(cache ProductCacheInvalidator, rabbitClient *RabbitMQClient) *StockConsumer {
+	return &StockConsumer{
+		cache:        cache,
+		rabbitClient: rabbitClient,
+	}
+}
+
+// Start запускает потребителя сообщений
+func (c *StockConsumer) Start() error {
+	return c.rabbitClient.ConsumeStockChanged(c.handleStockChanged)
+}
+
+func (c *StockConsumer) handleStockChanged(ctx context.Context, payload StockChangedPayload) error {
+	slog.InfoContext(ctx, "invalidating cached product", "product_id", payload.ProductID)
+	// Если сброс не дошел до Redis, событие вернется в очередь и будет повторено
+	return c.cache.InvalidateProduct(ctx, int32(payload.ProductID))
+}
+
+// UserEvictor сбрасывает все, что gateway закешировал о пользователе
+type UserEvictor interface {
+	EvictUser(ctx context.Context, userID int32, sessionIDs []int32) error
+}
+
+// UserDeletedConsumer сбрасывает кеш профиля и сессии удаленного пользователя
+type UserDeletedConsumer struct {
+	evictor      UserEvictor
+	rabbitClient *RabbitMQClient
+}
+
+// NewUserDeletedConsumer создает новый обработчик событий удаления учетных записей
+func NewUserDeletedConsumer(evictor UserEvictor, rabbitClient *RabbitMQClient) *UserDeletedConsumer {
+	return &UserDeletedConsumer{
+		evictor:      evictor,
+		rabbitClient: rabbitClient,
+	}
+}
+
+// Start запускает потребителя сообщений
+func (c *UserDeletedConsumer) Start() error {
+	return c.rabbitClient.ConsumeUserDeleted(c.handleUserDeleted)
+}
+
+func (c *UserDeletedConsumer) handleUserDeleted(ctx context.Context, payload UserDeletedPayload) error {
+	slog.InfoContext(ctx, "evicting cached data of deleted user", "user_id", payload.UserID)
+	sessionIDs := make([]int32, 0, len(payload.SessionIDs))
+	for _, id := range payload.SessionIDs {
+		sessionIDs = append(sessionIDs, int32(id))
+	}
+	return c.evictor.EvictUser(ctx, int32(payload.UserID), sessionIDs)
+}
@@ -0,0 +1,339 @@
+package message
+
+import (
+	"apiGateway/internal/logging"
+	"apiGateway/internal/metrics"
+	"apiGateway/internal/tracing"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// RabbitMQClient представляет клиент для работы с RabbitMQ
+type RabbitMQClient struct {
+	conn    *amqp.Connection
+	channel *amqp.Channel
+	queue   amqp.Queue
+
+	// Потребители, которых отменяет StopConsumers, и их обработчики
+	mu        sync.Mutex
+	consumers []string
+	handlers  sync.WaitGroup
+	queues    []string // очереди потребителей, для метрик глубины очередей
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+const (
+	requeueBackoffMin = 100 * time.Millisecond
+	requeueBackoffMax = 10 * time.Second
+)
+
+// requeueBackoff задерживает возврат сообщения в очередь после ошибки
+// обработчика. Сообщение возвращается сразу же, и без паузы потребитель
+// крутился бы на нем, пока Redis недоступен. Пауза удваивается с каждой
+// ошибкой подряд и сбрасывается после успешной обработки.
+type requeueBackoff struct {
+	wait time.Duration
+	stop <-chan struct{}
+}
+
+// failed ждет перед nack; StopConsumers прерывает ожидание
+func (b *requeueBackoff) failed() {
+	b.wait = min(max(b.wait*2, requeueBackoffMin), requeueBackoffMax)
+	timer := time.NewTimer(b.wait/2 + rand.N(b.wait/2+1))
+	defer timer.Stop()
+	select {
+	case <-b.stop:
+	case <-timer.C:
+	}
+}
+
+func (b *requeueBackoff) succeeded() {
+	b.wait = 0
+}
+
+// StockChangedPayload представляет событие изменения остатка товара
+type StockChangedPayload struct {
+	ProductID int       `json:"product_id"`
+	Stock     int       `json:"stock"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// UserDeletedPayload — событие удаления учетной записи
+type UserDeletedPayload struct {
+	RequestID  int       `json:"request_id"`
+	UserID     int       `json:"user_id"`
+	SessionIDs []int     `json:"session_ids,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// NewRabbitMQClient создаёт новый клиент RabbitMQ и подписывается на события инвентаря
+func NewRabbitMQClient(url string) (*RabbitMQClient, error) {
+	// Подключение к RabbitMQ
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+
+	// Создание канала
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open a channel: %w", err)
+	}
+
+	err = channel.ExchangeDeclare(
+		"inventory_events", // name
+		"direct",           // type
+		true,               // durable
+		false,              // auto-deleted
+		false,              // internal
+		false,              // no-wait
+		nil,                // arguments
+	)
+	if err != nil {
+		channel.Close()
+		conn.Close()
+		return nil, fmt.Errorf("failed to declare an exchange: %w", err)
+	}
+
+	// Каждый экземпляр gateway получает свою временную очередь,
+	// чтобы событие сбросило кеш во всех экземплярах
+	queue, err := channel.QueueDeclare(
+		"",    // name
+		false, // durable
+		true,  // delete when unused
+		true,  // exclusive
+		false, // no-wait
+		nil,   // arguments
+	)
+	if err != nil {
+		channel.Close()
+		conn.Close()
+		return nil, fmt.Errorf("failed to declare a queue: %w", err)
+	}
+
+	err = channel.QueueBind(
+		queue.Name,              // queue name
+		"product.stock_changed", // routing key
+		"inventory_events",      // exchange
+		false,
+		nil,
+	)
+	if err != nil {
+		channel.Close()
+		conn.Close()
+		return nil, fmt.Errorf("failed to bind queue: %w", err)
+	}
+
+	return &RabbitMQClient{
+		conn:    conn,
+		channel: channel,
+		queue:   queue,
+		stop:    make(chan struct{}),
+	}, nil
+}
+
+// ConsumeStockChanged потребляет события изменения остатков. handler получает
+// контекст со спаном обработки, продолжающим трассу отправителя.
+func (c *RabbitMQClient) ConsumeStockChanged(handler func(context.Context, StockChangedPayload) error) error {
+	msgs, err := c.channel.Consume(
+		c.queue.Name,                // queue
+		c.consumerTag(c.queue.Name), // consumer
+		false,                       // auto-ack
+		true,                        // exclusive
+		false,                       // no-local
+		false,                       // no-wait
+		nil,                         // args
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register a consumer: %w", err)
+	}
+
+	c.handlers.Add(1)
+	go func() {
+		defer c.handlers.Done()
+		backoff := requeueBackoff{stop: c.stop}
+		for d := range msgs {
+			metrics.Consumed(c.queue.Name, d.Timestamp)
+			ctx, span := tracing.StartConsume(c.queue.Name, d)
+			ctx = logging.ContextFromHeaders(ctx, d.Headers)
+			var payload StockChangedPayload
+			if err := json.Unmarshal(d.Body, &payload); err != nil {
+				slog.ErrorContext(ctx, "failed to parse stock changed event", "error", err)
+				nack(c.queue.Name, d, false) // Некорректное сообщение, повтор не поможет
+				tracing.End(span, err)
+				continue
+			}
+
+			if err := handler(ctx, payload); err != nil {
+				slog.ErrorContext(ctx, "failed to handle stock changed event", "product_id", payload.ProductID, "error", err)
+				backoff.failed()
+				nack(c.queue.Name, d, true)
+				tracing.End(span, err)
+				continue
+			}
+
+			backoff.succeeded()
+			ack(c.queue.Name, d)
+			tracing.End(span, nil)
+		}
+	}()
+
+	slog.Info("waiting for stock changed events", "queue", c.queue.Name)
+	return nil
+}
+
+// ConsumeUserDeleted потребляет события удаления учетных записей. Как и для
+// событий инвентаря, у каждого экземпляра gateway своя временная очередь.
+func (c *RabbitMQClient) ConsumeUserDeleted(handler func(context.Context, UserDeletedPayload) error) error {
+	err := c.channel.ExchangeDeclare(
+		"user_events", // name
+		"direct",      // type
+		true,          // durable
+		false,         // auto-deleted
+		false,         // internal
+		false,         // no-wait
+		nil,           // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare an exchange: %w", err)
+	}
+
+	queue, err := c.channel.QueueDeclare(
+		"",    // name
+		false, // durable
+		true,  // delete when unused
+		true,  // exclusive
+		false, // no-wait
+		nil,   // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare a queue: %w", err)
+	}
+
+	err = c.channel.QueueBind(
+		queue.Name,                // queue name
+		"user.deletion_requested", // routing key
+		"user_events",             // exchange
+		false,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to bind queue: %w", err)
+	}
+
+	msgs, err := c.channel.Consume(
+		queue.Name,                // queue
+		c.consumerTag(queue.Name), // consumer
+		false,                     // auto-ack
+		true,                      // exclusive
+		false,                     // no-local
+		false,                     // no-wait
+		nil,                       // args
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register a consumer: %w", err)
+	}
+
+	c.handlers.Add(1)
+	go func() {
+		defer c.handlers.Done()
+		backoff := requeueBackoff{stop: c.stop}
+		for d := range msgs {
+			metrics.Consumed(queue.Name, d.Timestamp)
+			ctx, span := tracing.StartConsume(queue.Name, d)
+			ctx = logging.ContextFromHeaders(ctx, d.Headers)
+			var payload UserDeletedPayload
+			if err := json.Unmarshal(d.Body, &payload); err != nil {
+				slog.ErrorContext(ctx, "failed to parse user deleted event", "error", err)
+				nack(queue.Name, d, false) // Некорректное сообщение, повтор не поможет
+				tracing.End(span, err)
+				continue
+			}
+
+			if err := handler(ctx, payload); err != nil {
+				slog.ErrorContext(ctx, "failed to handle user deleted event", "user_id", payload.UserID, "error", err)
+				backoff.failed()
+				nack(queue.Name, d, true)
+				tracing.End(span, err)
+				continue
+			}
+
+			backoff.succeeded()
+			ack(queue.Name, d)
+			tracing.End(span, nil)
+		}
+	}()
+
+	slog.Info("waiting for user deleted events", "queue", queue.Name)
+	return nil
+}
+
+// Ping сообщает, живо ли соединение с брокером; сигнатура подходит для health-проверок
+func (c *RabbitMQClient) Ping(context.Context) error {
+	if c.conn.IsClosed() {
+		return errors.New("connection is closed")
+	}
+	return nil
+}
+
+// consumerTag возвращает уникальный тег потребителя и запоминает его для StopConsumers
+func (c *RabbitMQClient) consumerTag(queue string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tag := fmt.Sprintf("%s-%d-%d", queue, os.Getpid(), len(c.consumers)+1)
+	c.consumers = append(c.consumers, tag)
+	c.queues = append(c.queues, queue)
+	return tag
+}
+
+// StopConsumers отменяет потребителей: брокер перестает присылать сообщения,
+// а полученные, но еще не подтвержденные вернутся в очередь при закрытии
+// канала. Затем ждет, пока обработчики закончат текущие сообщения, но не
+// дольше ctx.
+func (c *RabbitMQClient) StopConsumers(ctx context.Context) error {
+	c.mu.Lock()
+	tags := c.consumers
+	c.consumers = nil
+	c.mu.Unlock()
+	// Обработчики, ждущие перед повтором, возвращают сообщение сразу
+	c.stopOnce.Do(func() { close(c.stop) })
+
+	for _, tag := range tags {
+		if err := c.channel.Cancel(tag, false); err != nil {
+			slog.Warn("failed to cancel consumer", "consumer", tag, "error", err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.handlers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("consumers did not finish: %w", ctx.Err())
+	}
+}
+
+// Close закрывает соединение с RabbitMQ
+func (c *RabbitMQClient) Close() {
+	if c.channel != nil {
+		c.channel.Close()
+	}
+	if c.conn != nil {
+		c.conn.Close()
+	}
+}
@@ -0,0 +1,28 @@
+// Package metrics собирает метрики Prometheus: HTTP-запросы к gateway,
+// вызовы сервисов по gRPC и RabbitMQ. Метрики регистрируются в реестре по
+// умолчанию, вместе с метриками рантайма Go и процесса.
+package metrics
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Serve запускает в фоне HTTP-сервер с /metrics. Сервер отдельный от
+// основного, чтобы метрики не были доступны снаружи вместе с API.
+func Serve(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("metrics server stopped", "error", err)
+		}
+	}()
+	slog.Info("serving /metrics", "addr", addr)
+	return srv
+}
@@ -4,24 +4,101 @@ import (
 	grpcDelivery "apiGateway/internal/grpc"
 	"apiGateway/internal/proto"
 	"encoding/base64"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
-func Auth(userClient *grpcDelivery.UserClient) gin.HandlerFunc {
+// publicPaths — маршруты, которые не требуют аутентификации
+var publicPaths = map[string]bool{
+	"/register":               true,
+	"/login":                  true,
+	"/login/2fa":              true,
+	"/login/oidc":             true,
+	"/login/oidc/callback":    true,
+	"/health":                 true,
+	"/healthz":                true,
+	"/readyz":                 true,
+	"/password/reset-request": true,
+	"/password/reset":         true,
+	"/email/verify":           true,
+}
+
+// APIKeyScopes — какой scope нужен API-ключу для маршрута. Ключ задается
+// как метод и шаблон пути gin, например "GET /products/:id". Маршруты, которых
+// нет в таблице, по API-ключу недоступны.
+type APIKeyScopes map[string]string
+
+// Auth пропускает запросы с API-ключом (X-API-Key), токеном сессии (Bearer)
+// или с Basic auth и передает дальше user_id, role и, для сессий, session_id,
+// а для ключей — api_key_id
+func Auth(userClient *grpcDelivery.UserClient, sessions *grpcDelivery.SessionValidator, apiKeys *grpcDelivery.APIKeyValidator, scopes APIKeyScopes) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Public routes that don't require authentication
-		if c.Request.URL.Path == "/register" || c.Request.URL.Path == "/login" {
+		if publicPaths[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		// API-ключ для интеграций: действует от имени владельца, но только в пределах своих scopes
+		if key := c.GetHeader("X-API-Key"); key != "" {
+			info, err := apiKeys.Validate(c.Request.Context(), key)
+			if err != nil {
+				switch {
+				case errors.Is(err, grpcDelivery.ErrAPIKeyRevoked) || status.Code(err) == codes.Unauthenticated:
+					c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or revoked api key"})
+				case status.Code(err) == codes.PermissionDenied:
+					c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "account is disabled"})
+				default:
+					c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "api key check failed"})
+				}
+				return
+			}
+			scope, ok := scopes[c.Request.Method+" "+c.FullPath()]
+			if !ok {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "route is not available with an api key"})
+				return
+			}
+			if !info.HasScope(scope) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "api key lacks scope " + scope})
+				return
+			}
+			c.Set("user_id", strconv.Itoa(int(info.UserID)))
+			c.Set("role", info.Role)
+			c.Set("api_key_id", info.KeyID)
 			c.Next()
 			return
 		}
 
 		// Получаем Authorization заголовок
 		auth := c.GetHeader("Authorization")
-		if auth == "" || !strings.HasPrefix(auth, "Basic ") {
+
+		// Токен сессии, выданный /login
+		if strings.HasPrefix(auth, "Bearer ") {
+			info, err := sessions.Validate(c.Request.Context(), strings.TrimPrefix(auth, "Bearer "))
+			if err != nil {
+				if errors.Is(err, grpcDelivery.ErrSessionRevoked) || status.Code(err) == codes.Unauthenticated {
+					c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or revoked session"})
+					return
+				}
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "session check failed"})
+				return
+			}
+			c.Set("user_id", strconv.Itoa(int(info.UserID)))
+			c.Set("role", info.Role)
+			c.Set("session_id", info.SessionID)
+			c.Next()
+			return
+		}
+
+		if !strings.HasPrefix(auth, "Basic ") {
 			c.Header("WWW-Authenticate", `Basic realm="Restricted"`)
 			c.AbortWithStatus(http.StatusUnauthorized)
 			return
@@ -36,23 +113,61 @@ func Auth(userClient *grpcDelivery.UserClient) gin.HandlerFunc {
 			return
 		}
 
-		// Проверка через gRPC
+		// Проверка через gRPC; при включенной 2FA код передается в заголовке X-OTP
 		req := &proto.AuthRequest{
 			Username: pair[0],
 			Password: pair[1],
+			OtpCode:  c.GetHeader("X-OTP"),
 		}
 
 		// Запрашиваем у UserService авторизацию
-		resp, err := userClient.Authenticate(c, req)
+		var trailer metadata.MD
+		resp, err := userClient.Authenticate(grpcDelivery.WithClientIP(c, c.ClientIP()), req, grpc.Trailer(&trailer))
 		if err != nil {
+			if AbortIfLocked(c, err, trailer) {
+				return
+			}
+			if status.Code(err) == codes.FailedPrecondition {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "one-time code required in X-OTP header"})
+				return
+			}
+			if status.Code(err) == codes.PermissionDenied {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "account is disabled"})
+				return
+			}
 			c.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
 
-		// Если аутентификация успешна, извлекаем user_id и передаем его в контекст
+		// Если аутентификация успешна, извлекаем user_id и роль и передаем их в контекст
 		c.Set("user_id", strconv.Itoa(int(resp.GetId())))
+		c.Set("role", resp.GetRole())
 
 		// Переходим к следующему обработчику
 		c.Next()
 	}
 }
+
+// AbortIfLocked отвечает 429, если UserService временно заблокировал вход
+// после серии неудачных попыток или придержал выдачу токена второго шага
+func AbortIfLocked(c *gin.Context, err error, trailer metadata.MD) bool {
+	if status.Code(err) != codes.ResourceExhausted {
+		return false
+	}
+	if v := trailer.Get("retry-after"); len(v) > 0 {
+		c.Header("Retry-After", v[0])
+	}
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": status.Convert(err).Message()})
+	return true
+}
+
+// RequireRole пропускает только пользователей с указанной ролью. Должен стоять после Auth.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("role") != role {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
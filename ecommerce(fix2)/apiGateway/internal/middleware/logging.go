@@ -1,25 +1,67 @@
 package middleware
 
 import (
-	"log"
+	"apiGateway/internal/logging"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// RequestID берет request id из заголовка X-Request-ID или создает новый,
+// кладет его в контекст запроса (оттуда он попадает в логи и в вызовы
+// сервисов) и возвращает клиенту в том же заголовке. Должен стоять первым.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(logging.RequestIDKey)
+		if !logging.ValidRequestID(id) {
+			id = logging.NewRequestID()
+		}
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(logging.RequestIDKey, id)
+		c.Next()
+	}
+}
+
+// Logger пишет по строке на запрос. Пробы /healthz и /readyz идут на уровне
+// debug, ответы 5xx — на уровне error.
 func Logger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 
 		c.Next()
 
-		duration := time.Since(start)
-		log.Printf("[%s] %s %s %d %s",
-			c.ClientIP(),
-			c.Request.Method,
-			c.Request.URL.Path,
-			c.Writer.Status(),
-			duration,
-		)
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case c.Request.URL.Path == "/healthz" || c.Request.URL.Path == "/readyz":
+			level = slog.LevelDebug
+		}
+		attrs := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", c.FullPath(),
+			"status", status,
+			"duration", time.Since(start),
+			"client_ip", c.ClientIP(),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.String())
+		}
+		slog.Log(c.Request.Context(), level, "request", attrs...)
 	}
 }
+
+// Recovery отвечает 500 на панику в обработчике и пишет ее в лог со стеком
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		slog.ErrorContext(c.Request.Context(), "panic while handling request",
+			"panic", recovered, "stack", string(debug.Stack()))
+		c.AbortWithStatus(http.StatusInternalServerError)
+	})
+}
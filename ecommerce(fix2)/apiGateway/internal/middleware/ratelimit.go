@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	grpcDelivery "apiGateway/internal/grpc"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitRule — limit запросов за period; корзина пополняется равномерно
+type RateLimitRule struct {
+	Limit  int
+	Period time.Duration
+}
+
+// RateLimitPolicy задает лимиты по группам маршрутов. Группа — префикс пути,
+// выбирается самый длинный подходящий; для остальных маршрутов действует Default.
+type RateLimitPolicy struct {
+	Default RateLimitRule
+	Groups  map[string]RateLimitRule
+}
+
+func (p RateLimitPolicy) ruleFor(path string) (string, RateLimitRule) {
+	group, rule := "default", p.Default
+	for prefix, r := range p.Groups {
+		if strings.HasPrefix(path, prefix) && (group == "default" || len(prefix) > len(group)) {
+			group, rule = prefix, r
+		}
+	}
+	return group, rule
+}
+
+// RateLimitKeyFunc возвращает идентификатор клиента; пустая строка — не ограничивать
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// ByIP ограничивает запросы по IP клиента
+func ByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUser ограничивает запросы по аутентифицированному пользователю.
+// Должен стоять после Auth.
+func ByUser(c *gin.Context) string {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("user:%v", userID)
+}
+
+// RateLimit ограничивает частоту запросов по ключу из keyFunc и выставляет
+// заголовки RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset и Retry-After
+func RateLimit(limiter *grpcDelivery.RateLimiter, policy RateLimitPolicy, keyFunc RateLimitKeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := keyFunc(c)
+		if id == "" {
+			c.Next()
+			return
+		}
+
+		group, rule := policy.ruleFor(c.Request.URL.Path)
+		if rule.Limit <= 0 {
+			c.Next()
+			return
+		}
+
+		key := "ratelimit:" + group + ":" + id
+		res := limiter.Take(c.Request.Context(), key, rule.Limit, rule.Period)
+		setRateLimitHeaders(c, res)
+
+		if !res.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many requests"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// setRateLimitHeaders выставляет заголовки; если ответ уже прошел через другой
+// лимит (например, по IP и по пользователю), остаются значения более строгого
+func setRateLimitHeaders(c *gin.Context, res grpcDelivery.RateLimitResult) {
+	if prev := c.Writer.Header().Get("RateLimit-Remaining"); prev != "" {
+		if n, err := strconv.Atoi(prev); err == nil && n < res.Remaining {
+			return
+		}
+	}
+	c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,50 @@
+package mtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+// ClientConfig — TLS соединения с сервисом identity: gateway предъявляет свой
+// сертификат и принимает только сертификат нашего CA с CN = identity.
+// Проверяется имя сервиса, а не адрес, по которому он доступен, поэтому
+// сертификаты не зависят от того, как сервисы развернуты.
+//
+// Сертификат и CA берутся на каждое рукопожатие: стандартная проверка
+// работает с неизменяемым RootCAs, поэтому она выключена и заменена своей
+// в VerifyConnection.
+func (s *Store) ClientConfig(identity string) *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS13,
+		InsecureSkipVerify: true,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := s.current()
+			return cert, nil
+		},
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("mtls: server presented no certificate")
+			}
+			_, pool := s.current()
+			leaf := cs.PeerCertificates[0]
+			intermediates := x509.NewCertPool()
+			for _, cert := range cs.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+			_, err := leaf.Verify(x509.VerifyOptions{
+				Roots:         pool,
+				Intermediates: intermediates,
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			})
+			if err != nil {
+				return fmt.Errorf("mtls: verify %s: %w", identity, err)
+			}
+			if leaf.Subject.CommonName != identity {
+				return fmt.Errorf("mtls: expected %s, server is %q", identity, leaf.Subject.CommonName)
+			}
+			return nil
+		},
+	}
+}
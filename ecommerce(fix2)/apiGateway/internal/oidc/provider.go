@@ -0,0 +1,151 @@
+// Package oidc реализует вход через внешнего OpenID Connect провайдера:
+// authorization code flow с PKCE и проверку ID token.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// discoveryTimeout ограничивает запросы к провайдеру за discovery-документом и ключами
+const discoveryTimeout = 5 * time.Second
+
+// Config — настройки клиента у провайдера
+type Config struct {
+	Issuer       string // например https://sso.example.com/realms/staff
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string   // адрес /login/oidc/callback на gateway
+	Scopes       []string // по умолчанию openid, email, profile
+}
+
+// discovery — нужная часть /.well-known/openid-configuration
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider — клиент OIDC-провайдера. Discovery-документ загружается при
+// первом входе, а не при старте, чтобы недоступный провайдер не мешал
+// запуску gateway.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu     sync.Mutex
+	oauth2 *oauth2.Config
+	keys   *keySet
+}
+
+func NewProvider(cfg Config) *Provider {
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &Provider{cfg: cfg, client: &http.Client{Timeout: discoveryTimeout}}
+}
+
+// Issuer возвращает адрес провайдера; по нему UserService различает учетные записи
+func (p *Provider) Issuer() string {
+	return p.cfg.Issuer
+}
+
+// RedirectURL возвращает адрес callback на gateway
+func (p *Provider) RedirectURL() string {
+	return p.cfg.RedirectURL
+}
+
+// AuthCodeURL возвращает адрес страницы входа у провайдера. state и nonce
+// защищают от подмены ответа, verifier — код PKCE, который понадобится в Exchange.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	cfg, _, err := p.config(ctx)
+	if err != nil {
+		return "", err
+	}
+	return cfg.AuthCodeURL(state,
+		oauth2.S256ChallengeOption(verifier),
+		oauth2.SetAuthURLParam("nonce", nonce),
+	), nil
+}
+
+// Exchange обменивает код из callback на токены и возвращает проверенные claims ID token
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error) {
+	cfg, keys, err := p.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := cfg.Exchange(context.WithValue(ctx, oauth2.HTTPClient, p.client), code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("exchange code: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, fmt.Errorf("%w: token response has no id_token", ErrInvalidIDToken)
+	}
+	return verifyIDToken(ctx, keys, rawIDToken, p.cfg.Issuer, p.cfg.ClientID, nonce, time.Now())
+}
+
+// config загружает discovery-документ при первом обращении. Неудачная
+// попытка не кешируется: следующий вход попробует снова.
+func (p *Provider) config(ctx context.Context) (*oauth2.Config, *keySet, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.oauth2 != nil {
+		return p.oauth2, p.keys, nil
+	}
+
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	p.oauth2 = &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Scopes:       p.cfg.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  d.AuthorizationEndpoint,
+			TokenURL: d.TokenEndpoint,
+		},
+	}
+	p.keys = newKeySet(p.client, d.JWKSURI)
+	return p.oauth2, p.keys, nil
+}
+
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc discovery: unexpected status %s", resp.Status)
+	}
+
+	var d discovery
+	if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	// Провайдер обязан назвать себя тем же адресом, по которому его настроили
+	if strings.TrimSuffix(d.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match configured %q", d.Issuer, p.cfg.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("oidc discovery: document is missing endpoints")
+	}
+	return &d, nil
+}
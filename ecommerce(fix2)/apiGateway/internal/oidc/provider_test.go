@@ -0,0 +1,149 @@
+package oidc
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	testClientID    = "gateway"
+	testRedirectURL = "http://gateway.test/login/oidc/callback"
+)
+
+var alice = MockUser{Subject: "u1", Email: "alice@example.com", EmailVerified: true, Name: "Alice"}
+
+// startMock запускает мок-провайдер и клиент, настроенный на него
+func startMock(t *testing.T) (*MockProvider, *Provider) {
+	t.Helper()
+	mock, err := NewMockProvider(testClientID, "secret", alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(mock)
+	t.Cleanup(srv.Close)
+	mock.Issuer = srv.URL
+
+	provider := NewProvider(Config{
+		Issuer:       srv.URL,
+		ClientID:     testClientID,
+		ClientSecret: "secret",
+		RedirectURL:  testRedirectURL,
+	})
+	return mock, provider
+}
+
+// authorize проходит страницу входа провайдера и возвращает код из redirect
+func authorize(t *testing.T, p *Provider, state, nonce, verifier string) string {
+	t.Helper()
+	target, err := p.AuthCodeURL(context.Background(), state, nonce, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: status = %d, want 302", resp.StatusCode)
+	}
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := callback.Query().Get("state"); got != state {
+		t.Fatalf("authorize: state = %q, want %q", got, state)
+	}
+	return callback.Query().Get("code")
+}
+
+func TestExchange(t *testing.T) {
+	_, p := startMock(t)
+	verifier := oauth2.GenerateVerifier()
+	code := authorize(t, p, "state-1", "nonce-1", verifier)
+
+	claims, err := p.Exchange(context.Background(), code, verifier, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != alice.Subject || claims.Email != alice.Email || !claims.EmailVerified {
+		t.Errorf("claims = %+v, want subject and verified email of %+v", claims, alice)
+	}
+
+	// Код одноразовый
+	if _, err := p.Exchange(context.Background(), code, verifier, "nonce-1"); err == nil {
+		t.Error("code was exchanged twice")
+	}
+}
+
+func TestExchangeNonceMismatch(t *testing.T) {
+	_, p := startMock(t)
+	verifier := oauth2.GenerateVerifier()
+	code := authorize(t, p, "state-1", "nonce-1", verifier)
+
+	_, err := p.Exchange(context.Background(), code, verifier, "nonce-2")
+	if !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("err = %v, want ErrInvalidIDToken", err)
+	}
+}
+
+func TestExchangeBadVerifier(t *testing.T) {
+	_, p := startMock(t)
+	code := authorize(t, p, "state-1", "nonce-1", oauth2.GenerateVerifier())
+
+	if _, err := p.Exchange(context.Background(), code, oauth2.GenerateVerifier(), "nonce-1"); err == nil {
+		t.Fatal("code was exchanged with another PKCE verifier")
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	mock, _ := startMock(t)
+	keys := newKeySet(http.DefaultClient, mock.Issuer+"/jwks")
+	raw, err := mock.signIDToken(mockGrant{user: alice, nonce: "nonce-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	// Тот же токен с заголовком alg=none
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + raw[strings.Index(raw, "."):]
+
+	tests := []struct {
+		name     string
+		raw      string
+		issuer   string
+		clientID string
+		nonce    string
+		now      time.Time
+		wantErr  bool
+	}{
+		{name: "valid", raw: raw, issuer: mock.Issuer, clientID: testClientID, nonce: "nonce-1", now: now},
+		{name: "within clock skew", raw: raw, issuer: mock.Issuer, clientID: testClientID, nonce: "nonce-1", now: now.Add(mockTokenTTL + clockSkew/2)},
+		{name: "expired", raw: raw, issuer: mock.Issuer, clientID: testClientID, nonce: "nonce-1", now: now.Add(mockTokenTTL + 2*clockSkew), wantErr: true},
+		{name: "wrong audience", raw: raw, issuer: mock.Issuer, clientID: "another-client", nonce: "nonce-1", now: now, wantErr: true},
+		{name: "wrong issuer", raw: raw, issuer: "https://sso.example.com", clientID: testClientID, nonce: "nonce-1", now: now, wantErr: true},
+		{name: "nonce mismatch", raw: raw, issuer: mock.Issuer, clientID: testClientID, nonce: "nonce-2", now: now, wantErr: true},
+		{name: "tampered signature", raw: raw[:len(raw)-4] + "AAAA", issuer: mock.Issuer, clientID: testClientID, nonce: "nonce-1", now: now, wantErr: true},
+		{name: "alg none", raw: unsigned, issuer: mock.Issuer, clientID: testClientID, nonce: "nonce-1", now: now, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifyIDToken(context.Background(), keys, tt.raw, tt.issuer, tt.clientID, tt.nonce, tt.now)
+			if tt.wantErr && !errors.Is(err, ErrInvalidIDToken) {
+				t.Errorf("err = %v, want ErrInvalidIDToken", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("err = %v, want nil", err)
+			}
+		})
+	}
+}
@@ -89,19 +89,123 @@ func (x *OrderItem) GetQuantity() int32 {
 	return 0
 }
 
-type Order struct {
+// ShippingAddress — копия адреса на момент оформления заказа;
+// последующие правки адреса в профиле на заказ не влияют
+type ShippingAddress struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	UserId        int32                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Status        string       `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
-	Items         []*OrderItem `protobuf:"bytes,4,rep,name=items,proto3" json:"items,omitempty"`
+	FullName      string                 `protobuf:"bytes,1,opt,name=full_name,json=fullName,proto3" json:"full_name,omitempty"`
+	Line1         string                 `protobuf:"bytes,2,opt,name=line1,proto3" json:"line1,omitempty"`
+	Line2         string                 `protobuf:"bytes,3,opt,name=line2,proto3" json:"line2,omitempty"`
+	City          string                 `protobuf:"bytes,4,opt,name=city,proto3" json:"city,omitempty"`
+	Region        string                 `protobuf:"bytes,5,opt,name=region,proto3" json:"region,omitempty"`
+	PostalCode    string                 `protobuf:"bytes,6,opt,name=postal_code,json=postalCode,proto3" json:"postal_code,omitempty"`
+	Country       string                 `protobuf:"bytes,7,opt,name=country,proto3" json:"country,omitempty"`
+	Phone         string                 `protobuf:"bytes,8,opt,name=phone,proto3" json:"phone,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
+func (x *ShippingAddress) Reset() {
+	*x = ShippingAddress{}
+	mi := &file_internal_proto_order_order_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShippingAddress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShippingAddress) ProtoMessage() {}
+
+func (x *ShippingAddress) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_order_order_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShippingAddress.ProtoReflect.Descriptor instead.
+func (*ShippingAddress) Descriptor() ([]byte, []int) {
+	return file_internal_proto_order_order_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ShippingAddress) GetFullName() string {
+	if x != nil {
+		return x.FullName
+	}
+	return ""
+}
+
+func (x *ShippingAddress) GetLine1() string {
+	if x != nil {
+		return x.Line1
+	}
+	return ""
+}
+
+func (x *ShippingAddress) GetLine2() string {
+	if x != nil {
+		return x.Line2
+	}
+	return ""
+}
+
+func (x *ShippingAddress) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *ShippingAddress) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *ShippingAddress) GetPostalCode() string {
+	if x != nil {
+		return x.PostalCode
+	}
+	return ""
+}
+
+func (x *ShippingAddress) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *ShippingAddress) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+type Order struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId          int32                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Status          string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Items           []*OrderItem           `protobuf:"bytes,4,rep,name=items,proto3" json:"items,omitempty"`
+	AddressId       int32                  `protobuf:"varint,5,opt,name=address_id,json=addressId,proto3" json:"address_id,omitempty"`
+	ShippingAddress *ShippingAddress       `protobuf:"bytes,6,opt,name=shipping_address,json=shippingAddress,proto3" json:"shipping_address,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
 func (x *Order) Reset() {
 	*x = Order{}
-	mi := &file_internal_proto_order_order_proto_msgTypes[1]
+	mi := &file_internal_proto_order_order_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -113,7 +217,7 @@ func (x *Order) String() string {
 func (*Order) ProtoMessage() {}
 
 func (x *Order) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_order_order_proto_msgTypes[1]
+	mi := &file_internal_proto_order_order_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -126,7 +230,7 @@ func (x *Order) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Order.ProtoReflect.Descriptor instead.
 func (*Order) Descriptor() ([]byte, []int) {
-	return file_internal_proto_order_order_proto_rawDescGZIP(), []int{1}
+	return file_internal_proto_order_order_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *Order) GetId() int32 {
@@ -157,6 +261,20 @@ func (x *Order) GetItems() []*OrderItem {
 	return nil
 }
 
+func (x *Order) GetAddressId() int32 {
+	if x != nil {
+		return x.AddressId
+	}
+	return 0
+}
+
+func (x *Order) GetShippingAddress() *ShippingAddress {
+	if x != nil {
+		return x.ShippingAddress
+	}
+	return nil
+}
+
 type OrderID struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -166,7 +284,7 @@ type OrderID struct {
 
 func (x *OrderID) Reset() {
 	*x = OrderID{}
-	mi := &file_internal_proto_order_order_proto_msgTypes[2]
+	mi := &file_internal_proto_order_order_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -178,7 +296,7 @@ func (x *OrderID) String() string {
 func (*OrderID) ProtoMessage() {}
 
 func (x *OrderID) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_order_order_proto_msgTypes[2]
+	mi := &file_internal_proto_order_order_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -191,7 +309,7 @@ func (x *OrderID) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OrderID.ProtoReflect.Descriptor instead.
 func (*OrderID) Descriptor() ([]byte, []int) {
-	return file_internal_proto_order_order_proto_rawDescGZIP(), []int{2}
+	return file_internal_proto_order_order_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *OrderID) GetId() int32 {
@@ -210,7 +328,7 @@ type ListOrdersRequest struct {
 
 func (x *ListOrdersRequest) Reset() {
 	*x = ListOrdersRequest{}
-	mi := &file_internal_proto_order_order_proto_msgTypes[3]
+	mi := &file_internal_proto_order_order_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -222,7 +340,7 @@ func (x *ListOrdersRequest) String() string {
 func (*ListOrdersRequest) ProtoMessage() {}
 
 func (x *ListOrdersRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_order_order_proto_msgTypes[3]
+	mi := &file_internal_proto_order_order_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -235,7 +353,7 @@ func (x *ListOrdersRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListOrdersRequest.ProtoReflect.Descriptor instead.
 func (*ListOrdersRequest) Descriptor() ([]byte, []int) {
-	return file_internal_proto_order_order_proto_rawDescGZIP(), []int{3}
+	return file_internal_proto_order_order_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *ListOrdersRequest) GetUserId() int32 {
@@ -253,7 +371,7 @@ type Empty struct {
 
 func (x *Empty) Reset() {
 	*x = Empty{}
-	mi := &file_internal_proto_order_order_proto_msgTypes[4]
+	mi := &file_internal_proto_order_order_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -265,7 +383,7 @@ func (x *Empty) String() string {
 func (*Empty) ProtoMessage() {}
 
 func (x *Empty) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_order_order_proto_msgTypes[4]
+	mi := &file_internal_proto_order_order_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -278,7 +396,7 @@ func (x *Empty) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Empty.ProtoReflect.Descriptor instead.
 func (*Empty) Descriptor() ([]byte, []int) {
-	return file_internal_proto_order_order_proto_rawDescGZIP(), []int{4}
+	return file_internal_proto_order_order_proto_rawDescGZIP(), []int{5}
 }
 
 type OrderList struct {
@@ -290,7 +408,7 @@ type OrderList struct {
 
 func (x *OrderList) Reset() {
 	*x = OrderList{}
-	mi := &file_internal_proto_order_order_proto_msgTypes[5]
+	mi := &file_internal_proto_order_order_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -302,7 +420,7 @@ func (x *OrderList) String() string {
 func (*OrderList) ProtoMessage() {}
 
 func (x *OrderList) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_order_order_proto_msgTypes[5]
+	mi := &file_internal_proto_order_order_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -315,7 +433,7 @@ func (x *OrderList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OrderList.ProtoReflect.Descriptor instead.
 func (*OrderList) Descriptor() ([]byte, []int) {
-	return file_internal_proto_order_order_proto_rawDescGZIP(), []int{5}
+	return file_internal_proto_order_order_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *OrderList) GetOrders() []*Order {
@@ -335,12 +453,25 @@ const file_internal_proto_order_order_proto_rawDesc = "" +
 	"\border_id\x18\x02 \x01(\x05R\aorderId\x12\x1d\n" +
 	"\n" +
 	"product_id\x18\x03 \x01(\x05R\tproductId\x12\x1a\n" +
-	"\bquantity\x18\x04 \x01(\x05R\bquantity\"p\n" +
+	"\bquantity\x18\x04 \x01(\x05R\bquantity\"\xd7\x01\n" +
+	"\x0fShippingAddress\x12\x1b\n" +
+	"\tfull_name\x18\x01 \x01(\tR\bfullName\x12\x14\n" +
+	"\x05line1\x18\x02 \x01(\tR\x05line1\x12\x14\n" +
+	"\x05line2\x18\x03 \x01(\tR\x05line2\x12\x12\n" +
+	"\x04city\x18\x04 \x01(\tR\x04city\x12\x16\n" +
+	"\x06region\x18\x05 \x01(\tR\x06region\x12\x1f\n" +
+	"\vpostal_code\x18\x06 \x01(\tR\n" +
+	"postalCode\x12\x18\n" +
+	"\acountry\x18\a \x01(\tR\acountry\x12\x14\n" +
+	"\x05phone\x18\b \x01(\tR\x05phone\"\xd2\x01\n" +
 	"\x05Order\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x17\n" +
 	"\auser_id\x18\x02 \x01(\x05R\x06userId\x12\x16\n" +
 	"\x06status\x18\x03 \x01(\tR\x06status\x12&\n" +
-	"\x05items\x18\x04 \x03(\v2\x10.order.OrderItemR\x05items\"\x19\n" +
+	"\x05items\x18\x04 \x03(\v2\x10.order.OrderItemR\x05items\x12\x1d\n" +
+	"\n" +
+	"address_id\x18\x05 \x01(\x05R\taddressId\x12A\n" +
+	"\x10shipping_address\x18\x06 \x01(\v2\x16.order.ShippingAddressR\x0fshippingAddress\"\x19\n" +
 	"\aOrderID\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\",\n" +
 	"\x11ListOrdersRequest\x12\x17\n" +
@@ -352,7 +483,7 @@ const file_internal_proto_order_order_proto_rawDesc = "" +
 	"\vCreateOrder\x12\f.order.Order\x1a\f.order.Order\x12(\n" +
 	"\bGetOrder\x12\x0e.order.OrderID\x1a\f.order.Order\x12/\n" +
 	"\x11UpdateOrderStatus\x12\f.order.Order\x1a\f.order.Order\x12>\n" +
-	"\x10ListOrdersByUser\x12\x18.order.ListOrdersRequest\x1a\x10.order.OrderListB(Z&orderService/internal/delivery/grpc/pbb\x06proto3"
+	"\x10ListOrdersByUser\x12\x18.order.ListOrdersRequest\x1a\x10.order.OrderListB!Z\x1fapiGateway/internal/proto/orderb\x06proto3"
 
 var (
 	file_internal_proto_order_order_proto_rawDescOnce sync.Once
@@ -366,31 +497,33 @@ func file_internal_proto_order_order_proto_rawDescGZIP() []byte {
 	return file_internal_proto_order_order_proto_rawDescData
 }
 
-var file_internal_proto_order_order_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_internal_proto_order_order_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
 var file_internal_proto_order_order_proto_goTypes = []any{
 	(*OrderItem)(nil),         // 0: order.OrderItem
-	(*Order)(nil),             // 1: order.Order
-	(*OrderID)(nil),           // 2: order.OrderID
-	(*ListOrdersRequest)(nil), // 3: order.ListOrdersRequest
-	(*Empty)(nil),             // 4: order.Empty
-	(*OrderList)(nil),         // 5: order.OrderList
+	(*ShippingAddress)(nil),   // 1: order.ShippingAddress
+	(*Order)(nil),             // 2: order.Order
+	(*OrderID)(nil),           // 3: order.OrderID
+	(*ListOrdersRequest)(nil), // 4: order.ListOrdersRequest
+	(*Empty)(nil),             // 5: order.Empty
+	(*OrderList)(nil),         // 6: order.OrderList
 }
 var file_internal_proto_order_order_proto_depIdxs = []int32{
 	0, // 0: order.Order.items:type_name -> order.OrderItem
-	1, // 1: order.OrderList.orders:type_name -> order.Order
-	1, // 2: order.OrderService.CreateOrder:input_type -> order.Order
-	2, // 3: order.OrderService.GetOrder:input_type -> order.OrderID
-	1, // 4: order.OrderService.UpdateOrderStatus:input_type -> order.Order
-	3, // 5: order.OrderService.ListOrdersByUser:input_type -> order.ListOrdersRequest
-	1, // 6: order.OrderService.CreateOrder:output_type -> order.Order
-	1, // 7: order.OrderService.GetOrder:output_type -> order.Order
-	1, // 8: order.OrderService.UpdateOrderStatus:output_type -> order.Order
-	5, // 9: order.OrderService.ListOrdersByUser:output_type -> order.OrderList
-	6, // [6:10] is the sub-list for method output_type
-	2, // [2:6] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	1, // 1: order.Order.shipping_address:type_name -> order.ShippingAddress
+	2, // 2: order.OrderList.orders:type_name -> order.Order
+	2, // 3: order.OrderService.CreateOrder:input_type -> order.Order
+	3, // 4: order.OrderService.GetOrder:input_type -> order.OrderID
+	2, // 5: order.OrderService.UpdateOrderStatus:input_type -> order.Order
+	4, // 6: order.OrderService.ListOrdersByUser:input_type -> order.ListOrdersRequest
+	2, // 7: order.OrderService.CreateOrder:output_type -> order.Order
+	2, // 8: order.OrderService.GetOrder:output_type -> order.Order
+	2, // 9: order.OrderService.UpdateOrderStatus:output_type -> order.Order
+	6, // 10: order.OrderService.ListOrdersByUser:output_type -> order.OrderList
+	7, // [7:11] is the sub-list for method output_type
+	3, // [3:7] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_internal_proto_order_order_proto_init() }
@@ -404,7 +537,7 @@ func file_internal_proto_order_order_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_proto_order_order_proto_rawDesc), len(file_internal_proto_order_order_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   7,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -2,6 +2,8 @@ package grpc
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	pb "inventoryService/internal/delivery/grpc/pb"
 	"inventoryService/internal/domain"
 
@@ -23,16 +25,19 @@ func (h *InventoryHandler) CreateProduct(ctx context.Context, req *pb.Product) (
 		Name: req.Name, Description: req.Description,
 		Price: req.Price, Stock: int(req.Stock),
 	}
-	if err := h.productUC.Create(p); err != nil {
+	if err := h.productUC.Create(ctx, p); err != nil {
 		return nil, status.Errorf(codes.Internal, "create failed: %v", err)
 	}
 	return toProto(p), nil
 }
 
 func (h *InventoryHandler) GetProduct(ctx context.Context, req *pb.ProductID) (*pb.Product, error) {
-	p, err := h.productUC.GetByID(int(req.Id))
+	p, err := h.productUC.GetByID(ctx, int(req.Id))
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "not found: %v", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "product %d not found", req.Id)
+		}
+		return nil, status.Errorf(codes.Internal, "get failed: %v", err)
 	}
 	return toProto(p), nil
 }
@@ -42,21 +47,21 @@ func (h *InventoryHandler) UpdateProduct(ctx context.Context, req *pb.Product) (
 		ID: int(req.Id), Name: req.Name,
 		Description: req.Description, Price: req.Price, Stock: int(req.Stock),
 	}
-	if err := h.productUC.Update(p); err != nil {
+	if err := h.productUC.Update(ctx, p); err != nil {
 		return nil, status.Errorf(codes.Internal, "update failed: %v", err)
 	}
 	return toProto(p), nil
 }
 
 func (h *InventoryHandler) DeleteProduct(ctx context.Context, req *pb.ProductID) (*pb.Empty, error) {
-	if err := h.productUC.Delete(int(req.Id)); err != nil {
+	if err := h.productUC.Delete(ctx, int(req.Id)); err != nil {
 		return nil, status.Errorf(codes.Internal, "delete failed: %v", err)
 	}
 	return &pb.Empty{}, nil
 }
 
 func (h *InventoryHandler) ListProducts(ctx context.Context, _ *pb.Empty) (*pb.ProductList, error) {
-	products, err := h.productUC.List()
+	products, err := h.productUC.List(ctx)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "list failed: %v", err)
 	}
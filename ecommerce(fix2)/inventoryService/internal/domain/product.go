@@ -1,5 +1,7 @@
 package domain
 
+import "context"
+
 type Product struct {
 	ID          int     `json:"id" db:"id"`
 	Name        string  `json:"name" db:"name"`
@@ -10,17 +12,17 @@ type Product struct {
 
 // 7. Интерфейсы, которые показывают как именно устроены слои для хранения данных и какие usecase-методы они поддерживают
 type ProductRepository interface {
-	Create(product *Product) error
-	GetByID(id int) (*Product, error)
-	Update(product *Product) error
-	Delete(id int) error
-	List() ([]Product, error)
+	Create(ctx context.Context, product *Product) error
+	GetByID(ctx context.Context, id int) (*Product, error)
+	Update(ctx context.Context, product *Product) error
+	Delete(ctx context.Context, id int) error
+	List(ctx context.Context) ([]Product, error)
 }
 
 type ProductUsecase interface {
-	Create(product *Product) error
-	GetByID(id int) (*Product, error)
-	Update(product *Product) error
-	Delete(id int) error
-	List() ([]Product, error)
+	Create(ctx context.Context, product *Product) error
+	GetByID(ctx context.Context, id int) (*Product, error)
+	Update(ctx context.Context, product *Product) error
+	Delete(ctx context.Context, id int) error
+	List(ctx context.Context) ([]Product, error)
 }
@@ -0,0 +1,22 @@
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// UnaryMaxDeadline ограничивает время обработки вызова: дедлайн клиента
+// сокращается до limit, а вызов без дедлайна получает limit. Так запрос, о
+// котором клиент давно забыл, не держит соединение с базой.
+func UnaryMaxDeadline(limit time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > limit {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, limit)
+			defer cancel()
+		}
+		return handler(ctx, req)
+	}
+}
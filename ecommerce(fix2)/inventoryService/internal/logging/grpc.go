@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor кладет в контекст вызова request id, который gateway
+// передает в метаданных, или новый, если вызов пришел без него, и пишет по
+// строке на вызов
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var id string
+		if values := metadata.ValueFromIncomingContext(ctx, RequestIDKey); len(values) > 0 {
+			id = values[0]
+		}
+		if !ValidRequestID(id) {
+			id = NewRequestID()
+		}
+		ctx = WithRequestID(ctx, id)
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		attrs := []any{"method", info.FullMethod, "code", code.String(), "duration", time.Since(start)}
+		if err != nil {
+			attrs = append(attrs, "error", status.Convert(err).Message())
+		}
+		slog.Log(ctx, callLevel(info.FullMethod, code), "grpc call", attrs...)
+		return resp, err
+	}
+}
+
+// callLevel: ошибки сервиса — error, проверки health — debug, остальное,
+// включая ошибки клиента вроде NotFound, — info
+func callLevel(method string, code codes.Code) slog.Level {
+	switch {
+	case code == codes.Internal || code == codes.Unknown || code == codes.DataLoss:
+		return slog.LevelError
+	case strings.HasPrefix(method, "/grpc.health.v1."):
+		return slog.LevelDebug
+	default:
+		return slog.LevelInfo
+	}
+}
@@ -1,14 +1,23 @@
 package message
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"inventoryService/internal/domain"
-	"log"
+	"inventoryService/internal/metrics"
+	"log/slog"
+	"time"
 )
 
 // 6) Получение и обработка сообщения в Inventory Service
 
+// errOrderRejected — заказ не пройдет и при повторе: товара нет, его не
+// хватает или в заказе неверные данные. Такое сообщение не возвращается в
+// очередь, иначе оно обрабатывалось бы по кругу.
+var errOrderRejected = errors.New("order rejected")
+
 // MessageConsumer обрабатывает сообщения из очереди
 type MessageConsumer struct {
 	productUsecase domain.ProductUsecase
@@ -29,12 +38,12 @@ func (c *MessageConsumer) Start() error {
 }
 
 // handleOrderCreated обрабатывает событие создания заказа
-func (c *MessageConsumer) handleOrderCreated(payload MessagePayload) error {
-	log.Printf("[Inventory Consumer] Processing order %d with %d items", payload.OrderID, len(payload.Items))
+func (c *MessageConsumer) handleOrderCreated(ctx context.Context, payload MessagePayload) error {
+	slog.InfoContext(ctx, "processing order", "order_id", payload.OrderID, "items", len(payload.Items))
 
 	// Validate order data
 	if len(payload.Items) == 0 {
-		log.Printf("[Inventory Consumer] Order %d has no items, skipping", payload.OrderID)
+		slog.WarnContext(ctx, "order has no items, skipping", "order_id", payload.OrderID)
 		return nil
 	}
 
@@ -42,47 +51,61 @@ func (c *MessageConsumer) handleOrderCreated(payload MessagePayload) error {
 	for _, item := range payload.Items {
 		// Validate product ID
 		if item.ProductID <= 0 {
-			log.Printf("[Inventory Consumer] Invalid product ID %d in order %d", item.ProductID, payload.OrderID)
-			return fmt.Errorf("invalid product ID %d", item.ProductID)
+			slog.WarnContext(ctx, "invalid product ID in order", "order_id", payload.OrderID, "product_id", item.ProductID)
+			return fmt.Errorf("%w: invalid product ID %d", errOrderRejected, item.ProductID)
 		}
 
 		// Validate quantity
 		if item.Quantity <= 0 {
-			log.Printf("[Inventory Consumer] Invalid quantity %d for product %d in order %d",
-				item.Quantity, item.ProductID, payload.OrderID)
-			return fmt.Errorf("invalid quantity %d for product %d", item.Quantity, item.ProductID)
+			slog.WarnContext(ctx, "invalid quantity in order",
+				"order_id", payload.OrderID, "product_id", item.ProductID, "quantity", item.Quantity)
+			return fmt.Errorf("%w: invalid quantity %d for product %d", errOrderRejected, item.Quantity, item.ProductID)
 		}
 
 		// Получаем текущий товар
-		product, err := c.productUsecase.GetByID(item.ProductID)
+		product, err := c.productUsecase.GetByID(ctx, item.ProductID)
 		if err != nil {
-			if err == sql.ErrNoRows {
-				log.Printf("[Inventory Consumer] Product %d not found", item.ProductID)
-				return fmt.Errorf("product %d not found", item.ProductID)
+			if errors.Is(err, sql.ErrNoRows) {
+				slog.WarnContext(ctx, "product not found", "product_id", item.ProductID)
+				return fmt.Errorf("%w: product %d not found", errOrderRejected, item.ProductID)
 			}
-			log.Printf("[Inventory Consumer] Error getting product %d: %v", item.ProductID, err)
+			slog.ErrorContext(ctx, "failed to get product", "product_id", item.ProductID, "error", err)
 			return fmt.Errorf("failed to get product %d: %w", item.ProductID, err)
 		}
 
 		// Проверяем достаточность запаса
 		if product.Stock < item.Quantity {
-			log.Printf("[Inventory Consumer] Insufficient stock for product %d: requested %d, available %d",
-				item.ProductID, item.Quantity, product.Stock)
-			return fmt.Errorf("insufficient stock for product %d: requested %d, available %d",
-				item.ProductID, item.Quantity, product.Stock)
+			metrics.StockRejections.Inc()
+			slog.WarnContext(ctx, "insufficient stock",
+				"order_id", payload.OrderID, "product_id", item.ProductID, "requested", item.Quantity, "available", product.Stock)
+			return fmt.Errorf("%w: insufficient stock for product %d: requested %d, available %d",
+				errOrderRejected, item.ProductID, item.Quantity, product.Stock)
 		}
 
 		// Обновляем запас
 		product.Stock -= item.Quantity
-		if err := c.productUsecase.Update(product); err != nil {
-			log.Printf("[Inventory Consumer] Error updating product %d stock: %v", item.ProductID, err)
+		if err := c.productUsecase.Update(ctx, product); err != nil {
+			slog.ErrorContext(ctx, "failed to update stock", "product_id", item.ProductID, "error", err)
 			return fmt.Errorf("failed to update product %d stock: %w", item.ProductID, err)
 		}
 
-		log.Printf("[Inventory Consumer] Updated stock for product %d: new stock %d",
-			item.ProductID, product.Stock)
+		slog.InfoContext(ctx, "updated stock", "product_id", item.ProductID, "stock", product.Stock)
+		metrics.StockDecremented.Add(float64(item.Quantity))
+		if product.Stock < metrics.LowStockThreshold && product.Stock+item.Quantity >= metrics.LowStockThreshold {
+			metrics.LowStock.Inc()
+			slog.WarnContext(ctx, "product is running low", "product_id", item.ProductID, "stock", product.Stock)
+		}
+
+		// Сообщаем об изменении остатка, чтобы API Gateway сбросил кеш товара
+		if err := c.rabbitClient.PublishStockChanged(ctx, StockChangedPayload{
+			ProductID: product.ID,
+			Stock:     product.Stock,
+			Timestamp: time.Now(),
+		}); err != nil {
+			slog.ErrorContext(ctx, "failed to publish stock changed event", "product_id", product.ID, "error", err)
+		}
 	}
 
-	log.Printf("[Inventory Consumer] Successfully processed order %d", payload.OrderID)
+	slog.InfoContext(ctx, "processed order", "order_id", payload.OrderID)
 	return nil
 }
@@ -0,0 +1,72 @@
+package message
+
+import (
+	"inventoryService/internal/metrics"
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/streadway/amqp"
+)
+
+// ack и nack подтверждают сообщение и учитывают это в метриках
+func ack(queue string, d amqp.Delivery) {
+	d.Ack(false)
+	metrics.Acked(queue)
+}
+
+func nack(queue string, d amqp.Delivery, requeue bool) {
+	d.Nack(false, requeue)
+	metrics.Nacked(queue, requeue)
+}
+
+var (
+	queueMessagesDesc = prometheus.NewDesc("rabbitmq_queue_messages",
+		"Messages waiting in a consumed queue, not counting unacknowledged ones.", []string{"queue"}, nil)
+	queueConsumersDesc = prometheus.NewDesc("rabbitmq_queue_consumers",
+		"Consumers attached to a consumed queue across all instances.", []string{"queue"}, nil)
+)
+
+// queueCollector отдает глубину очередей, которые слушает клиент. Очереди
+// проверяются при каждом сборе метрик через отдельный канал: ошибка
+// пассивного объявления закрывает канал, и канал потребителей от нее не
+// пострадает.
+type queueCollector struct {
+	client *RabbitMQClient
+}
+
+// RegisterMetrics регистрирует метрики глубины очередей клиента
+func (c *RabbitMQClient) RegisterMetrics() {
+	prometheus.MustRegister(queueCollector{client: c})
+}
+
+func (q queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueMessagesDesc
+	ch <- queueConsumersDesc
+}
+
+func (q queueCollector) Collect(ch chan<- prometheus.Metric) {
+	q.client.mu.Lock()
+	queues := append([]string(nil), q.client.queues...)
+	q.client.mu.Unlock()
+	if len(queues) == 0 || q.client.conn.IsClosed() {
+		return
+	}
+
+	channel, err := q.client.conn.Channel()
+	if err != nil {
+		slog.Warn("failed to open a channel for queue metrics", "error", err)
+		return
+	}
+	defer channel.Close()
+
+	for _, name := range queues {
+		state, err := channel.QueueInspect(name)
+		if err != nil {
+			// После ошибки канал закрыт брокером, остальные очереди не проверить
+			slog.Warn("failed to inspect queue", "queue", name, "error", err)
+			return
+		}
+		ch <- prometheus.MustNewConstMetric(queueMessagesDesc, prometheus.GaugeValue, float64(state.Messages), name)
+		ch <- prometheus.MustNewConstMetric(queueConsumersDesc, prometheus.GaugeValue, float64(state.Consumers), name)
+	}
+}
@@ -1,9 +1,16 @@
 package message
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"inventoryService/internal/logging"
+	"inventoryService/internal/metrics"
+	"inventoryService/internal/tracing"
+	"log/slog"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/streadway/amqp"
@@ -14,6 +21,12 @@ type RabbitMQClient struct {
 	conn    *amqp.Connection
 	channel *amqp.Channel
 	queue   amqp.Queue
+
+	// Потребители, которых отменяет StopConsumers, и их обработчики
+	mu        sync.Mutex
+	consumers []string
+	handlers  sync.WaitGroup
+	queues    []string // очереди потребителей, для метрик глубины очередей
 }
 
 // MessagePayload представляет полезную нагрузку сообщения
@@ -31,6 +44,13 @@ type Item struct {
 	Quantity  int `json:"quantity"`
 }
 
+// StockChangedPayload представляет событие изменения остатка товара
+type StockChangedPayload struct {
+	ProductID int       `json:"product_id"`
+	Stock     int       `json:"stock"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
 // NewRabbitMQClient создаёт новый клиент RabbitMQ
 func NewRabbitMQClient(url, queueName string) (*RabbitMQClient, error) {
 	// Подключение к RabbitMQ
@@ -90,6 +110,22 @@ func NewRabbitMQClient(url, queueName string) (*RabbitMQClient, error) {
 		return nil, fmt.Errorf("failed to bind queue: %w", err)
 	}
 
+	// Exchange для событий инвентаря (изменение остатков), на него подписывается API Gateway
+	err = channel.ExchangeDeclare(
+		"inventory_events", // name
+		"direct",           // type
+		true,               // durable
+		false,              // auto-deleted
+		false,              // internal
+		false,              // no-wait
+		nil,                // arguments
+	)
+	if err != nil {
+		channel.Close()
+		conn.Close()
+		return nil, fmt.Errorf("failed to declare an exchange: %w", err)
+	}
+
 	return &RabbitMQClient{
 		conn:    conn,
 		channel: channel,
@@ -98,9 +134,8 @@ func NewRabbitMQClient(url, queueName string) (*RabbitMQClient, error) {
 }
 
 // PublishOrderCreated публикует сообщение о создании заказа
-func (c *RabbitMQClient) PublishOrderCreated(payload MessagePayload) error {
-	// Логирование начала публикации
-	log.Printf("[RabbitMQ Producer] Publishing order created event for OrderID: %d", payload.OrderID)
+func (c *RabbitMQClient) PublishOrderCreated(ctx context.Context, payload MessagePayload) error {
+	slog.DebugContext(ctx, "publishing order created event", "order_id", payload.OrderID)
 
 	// Сериализация полезной нагрузки
 	body, err := json.Marshal(payload)
@@ -108,77 +143,170 @@ func (c *RabbitMQClient) PublishOrderCreated(payload MessagePayload) error {
 		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	// Публикация сообщения
+	// Публикация сообщения; в заголовках — контекст трассировки
+	span, headers := tracing.StartPublish(ctx, "order_events", "order.created")
+	logging.InjectHeaders(ctx, headers)
 	err = c.channel.Publish(
 		"order_events",  // exchange
 		"order.created", // routing key
 		false,           // mandatory
 		false,           // immediate
 		amqp.Publishing{
+			Headers:      headers,
 			ContentType:  "application/json",
 			Body:         body,
 			DeliveryMode: amqp.Persistent,
 			Timestamp:    time.Now(),
 		},
 	)
+	tracing.End(span, err)
+	metrics.Published("order_events", "order.created", err)
 	if err != nil {
 		return fmt.Errorf("failed to publish a message: %w", err)
 	}
 
-	// Логирование успешной публикации
-	log.Printf("[RabbitMQ Producer] Successfully published order created event for OrderID: %d", payload.OrderID)
+	slog.InfoContext(ctx, "published order created event", "order_id", payload.OrderID)
 	return nil
 }
 
-// ConsumeOrderCreated потребляет сообщения о создании заказа
-func (c *RabbitMQClient) ConsumeOrderCreated(handler func(MessagePayload) error) error {
-	// Логирование начала потребления
-	log.Printf("[RabbitMQ Consumer] Starting to consume order created events")
+// PublishStockChanged публикует событие об изменении остатка товара
+func (c *RabbitMQClient) PublishStockChanged(ctx context.Context, payload StockChangedPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
 
+	span, headers := tracing.StartPublish(ctx, "inventory_events", "product.stock_changed")
+	logging.InjectHeaders(ctx, headers)
+	err = c.channel.Publish(
+		"inventory_events",      // exchange
+		"product.stock_changed", // routing key
+		false,                   // mandatory
+		false,                   // immediate
+		amqp.Publishing{
+			Headers:      headers,
+			ContentType:  "application/json",
+			Body:         body,
+			DeliveryMode: amqp.Persistent,
+			Timestamp:    time.Now(),
+		},
+	)
+	tracing.End(span, err)
+	metrics.Published("inventory_events", "product.stock_changed", err)
+	if err != nil {
+		return fmt.Errorf("failed to publish a message: %w", err)
+	}
+
+	slog.InfoContext(ctx, "published stock changed event", "product_id", payload.ProductID)
+	return nil
+}
+
+// ConsumeOrderCreated потребляет сообщения о создании заказа. handler получает
+// контекст со спаном обработки, продолжающим трассу отправителя.
+func (c *RabbitMQClient) ConsumeOrderCreated(handler func(context.Context, MessagePayload) error) error {
 	// Настройка потребления сообщений
 	msgs, err := c.channel.Consume(
-		c.queue.Name, // queue
-		"",           // consumer
-		false,        // auto-ack
-		false,        // exclusive
-		false,        // no-local
-		false,        // no-wait
-		nil,          // args
+		c.queue.Name,                // queue
+		c.consumerTag(c.queue.Name), // consumer
+		false,                       // auto-ack
+		false,                       // exclusive
+		false,                       // no-local
+		false,                       // no-wait
+		nil,                         // args
 	)
 	if err != nil {
 		return fmt.Errorf("failed to register a consumer: %w", err)
 	}
 
 	// Обработка сообщений
+	c.handlers.Add(1)
 	go func() {
+		defer c.handlers.Done()
 		for d := range msgs {
-			log.Printf("[RabbitMQ Consumer] Received message: %s", d.Body)
+			metrics.Consumed(c.queue.Name, d.Timestamp)
+			ctx, span := tracing.StartConsume(c.queue.Name, d)
+			ctx = logging.ContextFromHeaders(ctx, d.Headers)
+			slog.DebugContext(ctx, "received message", "queue", c.queue.Name, "size", len(d.Body))
 
 			// Десериализация полезной нагрузки
 			var payload MessagePayload
 			if err := json.Unmarshal(d.Body, &payload); err != nil {
-				log.Printf("[RabbitMQ Consumer] Error parsing message: %v", err)
-				d.Nack(false, true) // Ошибка обработки, вернуть в очередь
+				slog.ErrorContext(ctx, "failed to parse order created event", "error", err)
+				nack(c.queue.Name, d, true) // Ошибка обработки, вернуть в очередь
+				tracing.End(span, err)
 				continue
 			}
 
 			// Обработка сообщения
-			log.Printf("[RabbitMQ Consumer] Processing order created event for OrderID: %d", payload.OrderID)
-			if err := handler(payload); err != nil {
-				log.Printf("[RabbitMQ Consumer] Error handling message: %v", err)
-				d.Nack(false, true) // Ошибка обработки, вернуть в очередь
+			if err := handler(ctx, payload); err != nil {
+				if errors.Is(err, errOrderRejected) {
+					slog.WarnContext(ctx, "rejected order created event", "order_id", payload.OrderID, "error", err)
+					nack(c.queue.Name, d, false) // Повтор не поможет, не возвращать в очередь
+				} else {
+					slog.ErrorContext(ctx, "failed to handle order created event", "order_id", payload.OrderID, "error", err)
+					nack(c.queue.Name, d, true) // Ошибка обработки, вернуть в очередь
+				}
+				tracing.End(span, err)
+				continue
 			}
 
 			// Подтверждение обработки
-			d.Ack(false)
-			log.Printf("[RabbitMQ Consumer] Successfully processed order created event for OrderID: %d", payload.OrderID)
+			ack(c.queue.Name, d)
+			tracing.End(span, nil)
 		}
 	}()
 
-	log.Printf("[RabbitMQ Consumer] Waiting for order created events...")
+	slog.Info("waiting for order created events", "queue", c.queue.Name)
+	return nil
+}
+
+// Ping сообщает, живо ли соединение с брокером; сигнатура подходит для health-проверок
+func (c *RabbitMQClient) Ping(context.Context) error {
+	if c.conn.IsClosed() {
+		return errors.New("connection is closed")
+	}
 	return nil
 }
 
+// consumerTag возвращает уникальный тег потребителя и запоминает его для StopConsumers
+func (c *RabbitMQClient) consumerTag(queue string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tag := fmt.Sprintf("%s-%d-%d", queue, os.Getpid(), len(c.consumers)+1)
+	c.consumers = append(c.consumers, tag)
+	c.queues = append(c.queues, queue)
+	return tag
+}
+
+// StopConsumers отменяет потребителей: брокер перестает присылать сообщения,
+// а полученные, но еще не подтвержденные вернутся в очередь при закрытии
+// канала. Затем ждет, пока обработчики закончат текущие сообщения, но не
+// дольше ctx.
+func (c *RabbitMQClient) StopConsumers(ctx context.Context) error {
+	c.mu.Lock()
+	tags := c.consumers
+	c.consumers = nil
+	c.mu.Unlock()
+
+	for _, tag := range tags {
+		if err := c.channel.Cancel(tag, false); err != nil {
+			slog.Warn("failed to cancel consumer", "consumer", tag, "error", err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.handlers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("consumers did not finish: %w", ctx.Err())
+	}
+}
+
 // Close закрывает соединение с RabbitMQ
 func (c *RabbitMQClient) Close() {
 	if c.channel != nil {
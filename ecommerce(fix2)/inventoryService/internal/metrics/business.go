@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// LowStockThreshold — остаток, ниже которого товар считается заканчивающимся
+const LowStockThreshold = 5
+
+var (
+	// StockRejections — позиции заказов, для которых не хватило остатка
+	StockRejections = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "inventory_stock_rejections_total",
+		Help: "Order items rejected because the product did not have enough stock.",
+	})
+
+	// LowStock — списания, после которых остаток опустился ниже LowStockThreshold
+	LowStock = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "inventory_low_stock_total",
+		Help: "Stock decrements that left a product below the low stock threshold.",
+	})
+
+	// StockDecremented — сколько единиц товара списано по заказам
+	StockDecremented = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "inventory_stock_decremented_total",
+		Help: "Units of stock taken by orders.",
+	})
+)
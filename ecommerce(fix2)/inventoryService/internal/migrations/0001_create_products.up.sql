@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS products (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    price DECIMAL NOT NULL,
+    stock INT NOT NULL
+);
@@ -0,0 +1,353 @@
+// Package migrations хранит схему БД сервиса в виде версионированных
+// SQL-миграций, встроенных в бинарник. Файл NNNN_name.up.sql применяет
+// версию NNNN, NNNN_name.down.sql ее откатывает. Примененные версии
+// записываются в таблицу schema_migrations в схеме сервиса.
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Migration — одна версия схемы
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+// State — миграция и время ее применения; AppliedAt пуст, если она еще не применена
+type State struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// load читает миграции из встроенных файлов по возрастанию версии. У каждой
+// версии должны быть оба файла: откат, которого нет, обнаружится не в момент
+// аварии.
+func load() ([]Migration, error) {
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*Migration)
+	for _, name := range names {
+		m := fileName.FindStringSubmatch(path.Base(name))
+		if m == nil {
+			return nil, fmt.Errorf("migration %s: name must look like 0001_create_table.up.sql", name)
+		}
+		version, _ := strconv.Atoi(m[1])
+		body, err := files.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.up = string(body)
+		} else {
+			mig.down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.up == "" || mig.down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both up and down files", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator применяет и откатывает миграции в схеме schema
+type Migrator struct {
+	db     *sqlx.DB
+	schema string
+}
+
+func New(db *sqlx.DB, schema string) *Migrator {
+	return &Migrator{db: db, schema: schema}
+}
+
+// Up применяет не больше limit новых миграций (0 — все) и возвращает примененные
+func (m *Migrator) Up(ctx context.Context, limit int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sqlx.Conn) error {
+		states, err := m.states(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, s := range states {
+			if s.AppliedAt != nil {
+				continue
+			}
+			if limit > 0 && len(done) == limit {
+				break
+			}
+			if err := m.apply(ctx, conn, s.Migration, true); err != nil {
+				return err
+			}
+			done = append(done, s.Migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down откатывает steps последних примененных миграций и возвращает откаченные
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sqlx.Conn) error {
+		states, err := m.states(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(states) - 1; i >= 0 && len(done) < steps; i-- {
+			if states[i].AppliedAt == nil {
+				continue
+			}
+			if err := m.apply(ctx, conn, states[i].Migration, false); err != nil {
+				return err
+			}
+			done = append(done, states[i].Migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status возвращает все известные миграции и отметку о применении
+func (m *Migrator) Status(ctx context.Context) ([]State, error) {
+	var states []State
+	err := m.locked(ctx, func(conn *sqlx.Conn) error {
+		var err error
+		states, err = m.states(ctx, conn)
+		return err
+	})
+	return states, err
+}
+
+// Pending возвращает миграции, которые еще не применены
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	states, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, s := range states {
+		if s.AppliedAt == nil {
+			pending = append(pending, s.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// OnStart вызывается при запуске сервиса: с apply применяет новые миграции,
+// без него только предупреждает, что схема отстает от кода
+func (m *Migrator) OnStart(ctx context.Context, apply bool) error {
+	if apply {
+		_, err := m.Up(ctx, 0)
+		return err
+	}
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		slog.WarnContext(ctx, "database schema is behind this build, run migrate up",
+			"schema", m.schema, "pending", len(pending), "latest", pending[len(pending)-1].Version)
+	}
+	return nil
+}
+
+// locked выполняет fn на отдельном соединении под advisory-блокировкой схемы,
+// чтобы несколько экземпляров, запущенных одновременно, не применяли одну
+// миграцию дважды. Схема и таблица версий создаются при первом вызове.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	lockKey := "migrations:" + m.schema
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock(hashtext($1))`, lockKey); err != nil {
+		return fmt.Errorf("lock migrations: %w", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock(hashtext($1))`, lockKey)
+
+	schema := pq.QuoteIdentifier(m.schema)
+	_, err = conn.ExecContext(ctx, `CREATE SCHEMA IF NOT EXISTS `+schema+`;
+		CREATE TABLE IF NOT EXISTS `+schema+`.schema_migrations (
+			version INT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return fn(conn)
+}
+
+func (m *Migrator) states(ctx context.Context, conn *sqlx.Conn) ([]State, error) {
+	migrations, err := load()
+	if err != nil {
+		return nil, err
+	}
+	var applied []struct {
+		Version   int       `db:"version"`
+		AppliedAt time.Time `db:"applied_at"`
+	}
+	err = conn.SelectContext(ctx, &applied, `SELECT version, applied_at FROM `+pq.QuoteIdentifier(m.schema)+`.schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	appliedAt := make(map[int]time.Time, len(applied))
+	for _, a := range applied {
+		appliedAt[a.Version] = a.AppliedAt
+	}
+
+	states := make([]State, len(migrations))
+	for i, mig := range migrations {
+		states[i].Migration = mig
+		if t, ok := appliedAt[mig.Version]; ok {
+			states[i].AppliedAt = &t
+		}
+		delete(appliedAt, mig.Version)
+	}
+	// Версия в базе, которой нет в бинарнике: схему обновил более новый
+	// релиз. Откатывать ее этот бинарник не умеет, но и работе это не мешает.
+	for version := range appliedAt {
+		slog.WarnContext(ctx, "database has a migration unknown to this build", "schema", m.schema, "version", version)
+	}
+	return states, nil
+}
+
+// apply выполняет миграцию и запись о ней в одной транзакции: DDL в Postgres
+// транзакционен, поэтому упавшая миграция не оставляет схему наполовину
+// измененной
+func (m *Migrator) apply(ctx context.Context, conn *sqlx.Conn, mig Migration, up bool) error {
+	start := time.Now()
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	schema := pq.QuoteIdentifier(m.schema)
+	if _, err := tx.ExecContext(ctx, `SET LOCAL search_path TO `+schema); err != nil {
+		return err
+	}
+	body := mig.up
+	if !up {
+		body = mig.down
+	}
+	if _, err := tx.ExecContext(ctx, body); err != nil {
+		return fmt.Errorf("migration %04d_%s: %w", mig.Version, mig.Name, err)
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, `INSERT INTO `+schema+`.schema_migrations (version, name) VALUES ($1, $2)`, mig.Version, mig.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM `+schema+`.schema_migrations WHERE version=$1`, mig.Version)
+	}
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	msg := "applied migration"
+	if !up {
+		msg = "rolled back migration"
+	}
+	slog.InfoContext(ctx, msg, "schema", m.schema, "version", mig.Version, "name", mig.Name, "duration", time.Since(start))
+	return nil
+}
+
+// ErrUsage — неверные аргументы подкоманды migrate
+var ErrUsage = errors.New("usage: migrate up [N] | down [N] | status")
+
+// Command выполняет подкоманду migrate и пишет итог в out:
+//
+//	migrate up [N]    применить N новых миграций, по умолчанию все
+//	migrate down [N]  откатить N последних, по умолчанию одну
+//	migrate status    список миграций и время применения
+func (m *Migrator) Command(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 || len(args) > 2 {
+		return ErrUsage
+	}
+	n := 0
+	if len(args) == 2 {
+		var err error
+		if n, err = strconv.Atoi(args[1]); err != nil || n <= 0 {
+			return ErrUsage
+		}
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := m.Up(ctx, n)
+		for _, mig := range done {
+			fmt.Fprintf(out, "applied     %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Fprintln(out, "schema is up to date")
+		}
+		return err
+	case "down":
+		if n == 0 {
+			n = 1
+		}
+		done, err := m.Down(ctx, n)
+		for _, mig := range done {
+			fmt.Fprintf(out, "rolled back %04d_%s\n", mig.Version, mig.Name)
+		}
+		return err
+	case "status":
+		if n != 0 {
+			return ErrUsage
+		}
+		states, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range states {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(out, "%04d_%-40s %s\n", s.Version, s.Name, applied)
+		}
+		return nil
+	default:
+		return ErrUsage
+	}
+}
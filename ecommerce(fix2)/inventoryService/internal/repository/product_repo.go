@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"github.com/jmoiron/sqlx"
 	"inventoryService/internal/domain"
 )
@@ -15,31 +16,31 @@ func NewProductRepo(db *sqlx.DB) domain.ProductRepository {
 	return &productRepo{db}
 }
 
-func (r *productRepo) Create(p *domain.Product) error {
+func (r *productRepo) Create(ctx context.Context, p *domain.Product) error {
 	query := `INSERT INTO products (name, description, price, stock)
 			  VALUES ($1, $2, $3, $4) RETURNING id`
-	return r.db.QueryRow(query, p.Name, p.Description, p.Price, p.Stock).Scan(&p.ID)
+	return r.db.QueryRowContext(ctx, query, p.Name, p.Description, p.Price, p.Stock).Scan(&p.ID)
 }
 
-func (r *productRepo) GetByID(id int) (*domain.Product, error) {
+func (r *productRepo) GetByID(ctx context.Context, id int) (*domain.Product, error) {
 	var p domain.Product
-	err := r.db.Get(&p, "SELECT * FROM products WHERE id=$1", id)
+	err := r.db.GetContext(ctx, &p, "SELECT * FROM products WHERE id=$1", id)
 	return &p, err
 }
 
-func (r *productRepo) Update(p *domain.Product) error {
+func (r *productRepo) Update(ctx context.Context, p *domain.Product) error {
 	query := `UPDATE products SET name=$1, description=$2, price=$3, stock=$4 WHERE id=$5`
-	_, err := r.db.Exec(query, p.Name, p.Description, p.Price, p.Stock, p.ID)
+	_, err := r.db.ExecContext(ctx, query, p.Name, p.Description, p.Price, p.Stock, p.ID)
 	return err
 }
 
-func (r *productRepo) Delete(id int) error {
-	_, err := r.db.Exec("DELETE FROM products WHERE id=$1", id)
+func (r *productRepo) Delete(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM products WHERE id=$1", id)
 	return err
 }
 
-func (r *productRepo) List() ([]domain.Product, error) {
+func (r *productRepo) List(ctx context.Context) ([]domain.Product, error) {
 	var products []domain.Product
-	err := r.db.Select(&products, "SELECT * FROM products")
+	err := r.db.SelectContext(ctx, &products, "SELECT * FROM products")
 	return products, err
 }
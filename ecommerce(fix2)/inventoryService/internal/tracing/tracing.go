@@ -0,0 +1,74 @@
+// Package tracing настраивает OpenTelemetry: провайдер спанов, их экспорт и
+// передачу контекста трассировки (W3C Trace Context) через gRPC и RabbitMQ.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// tracer создает спаны, которые не покрывают библиотеки инструментирования
+// (сообщения RabbitMQ). Глобальный провайдер подхватывается и после Setup.
+var tracer = otel.Tracer("inventoryService/internal/tracing")
+
+// Setup устанавливает глобальный провайдер спанов для service. exporter —
+// "otlp" (коллектор по gRPC на endpoint), "stdout" или "none". Спаны
+// создаются и без экспорта: trace id все равно уходит в соседние сервисы.
+// Возвращает остановку провайдера, которая отправляет накопленные спаны.
+func Setup(ctx context.Context, service, exporter, endpoint string) (func(context.Context) error, error) {
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(newResource(service))}
+	switch exporter {
+	case "otlp":
+		exp, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(endpoint))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	case "stdout":
+		exp, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	case "none":
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+	install(provider)
+	return provider.Shutdown, nil
+}
+
+// InMemory устанавливает провайдер, который складывает завершенные спаны в
+// память, — для тестов, проверяющих, какие спаны создает код и как они
+// связаны между собой
+func InMemory(service string) *tracetest.InMemoryExporter {
+	exp := tracetest.NewInMemoryExporter()
+	install(sdktrace.NewTracerProvider(
+		sdktrace.WithResource(newResource(service)),
+		sdktrace.WithSyncer(exp),
+	))
+	return exp
+}
+
+func install(provider *sdktrace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+}
+
+func newResource(service string) *resource.Resource {
+	return resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(service))
+}
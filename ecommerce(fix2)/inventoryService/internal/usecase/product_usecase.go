@@ -1,6 +1,9 @@
 package usecase
 
-import "inventoryService/internal/domain"
+import (
+	"context"
+	"inventoryService/internal/domain"
+)
 
 // 7) Обновление товара в базе данных с использованием бизнес-логики
 type productUsecase struct {
@@ -11,22 +14,22 @@ func NewProductUsecase(r domain.ProductRepository) domain.ProductUsecase {
 	return &productUsecase{r}
 }
 
-func (uc *productUsecase) Create(p *domain.Product) error {
-	return uc.repo.Create(p)
+func (uc *productUsecase) Create(ctx context.Context, p *domain.Product) error {
+	return uc.repo.Create(ctx, p)
 }
 
-func (uc *productUsecase) GetByID(id int) (*domain.Product, error) {
-	return uc.repo.GetByID(id)
+func (uc *productUsecase) GetByID(ctx context.Context, id int) (*domain.Product, error) {
+	return uc.repo.GetByID(ctx, id)
 }
 
-func (uc *productUsecase) Update(p *domain.Product) error {
-	return uc.repo.Update(p)
+func (uc *productUsecase) Update(ctx context.Context, p *domain.Product) error {
+	return uc.repo.Update(ctx, p)
 }
 
-func (uc *productUsecase) Delete(id int) error {
-	return uc.repo.Delete(id)
+func (uc *productUsecase) Delete(ctx context.Context, id int) error {
+	return uc.repo.Delete(ctx, id)
 }
 
-func (uc *productUsecase) List() ([]domain.Product, error) {
-	return uc.repo.List()
+func (uc *productUsecase) List(ctx context.Context) ([]domain.Product, error) {
+	return uc.repo.List(ctx)
 }
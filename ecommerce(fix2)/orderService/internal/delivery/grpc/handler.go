@@ -4,7 +4,7 @@ import (
 	"context"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-	"log"
+	"log/slog"
 	pb "orderService/internal/delivery/grpc/pb"
 	"orderService/internal/domain"
 )
@@ -21,16 +21,17 @@ func NewOrderHandler(uc domain.OrderUsecase) *OrderHandler {
 
 func (h *OrderHandler) CreateOrder(ctx context.Context, req *pb.Order) (*pb.Order, error) {
 	// 3.1) Логирование запроса
-	log.Printf("[gRPC] Received CreateOrder request for user %d", req.UserId)
+	slog.InfoContext(ctx, "CreateOrder request", "user_id", req.UserId, "items", len(req.Items))
 
-	// Validate request
+	// Validate request: Order общий с UpdateOrderStatus, поэтому обязательность
+	// user_id и items проверяется здесь, а не аннотациями
 	if req.UserId <= 0 {
-		log.Printf("[gRPC] Invalid user ID: %d", req.UserId)
+		slog.WarnContext(ctx, "invalid user ID", "user_id", req.UserId)
 		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID")
 	}
 
 	if len(req.Items) == 0 {
-		log.Printf("[gRPC] Order has no items")
+		slog.WarnContext(ctx, "order has no items", "user_id", req.UserId)
 		return nil, status.Errorf(codes.InvalidArgument, "order must have at least one item")
 	}
 
@@ -40,18 +41,19 @@ func (h *OrderHandler) CreateOrder(ctx context.Context, req *pb.Order) (*pb.Orde
 		Status: req.Status,
 	}
 
-	// Validate each item
-	for _, item := range req.Items {
-		if item.ProductId <= 0 {
-			log.Printf("[gRPC] Invalid product ID: %d", item.ProductId)
-			return nil, status.Errorf(codes.InvalidArgument, "invalid product ID: %d", item.ProductId)
-		}
-
-		if item.Quantity <= 0 {
-			log.Printf("[gRPC] Invalid quantity for product %d: %d", item.ProductId, item.Quantity)
-			return nil, status.Errorf(codes.InvalidArgument, "invalid quantity for product %d: %d", item.ProductId, item.Quantity)
+	// Адрес доставки приходит уже разрешенным: gateway берет его из адресной
+	// книги пользователя и передает вместе с address_id
+	if req.AddressId > 0 {
+		if req.ShippingAddress == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "shipping_address is required with address_id")
 		}
+		addressID := int(req.AddressId)
+		domainOrder.AddressID = &addressID
+		domainOrder.ShippingAddress = shippingFromProto(req.ShippingAddress)
+	}
 
+	// product_id и quantity уже проверены по аннотациям в order.proto
+	for _, item := range req.Items {
 		domainOrder.Items = append(domainOrder.Items, domain.OrderItem{
 			ProductID: int(item.ProductId),
 			Quantity:  int(item.Quantity),
@@ -59,112 +61,100 @@ func (h *OrderHandler) CreateOrder(ctx context.Context, req *pb.Order) (*pb.Orde
 	}
 
 	// 3.3) Создание заказа через бизнес-логику(также вызовет публикацию сообщения в RabbitMQ)
-	err := h.orderUC.Create(domainOrder)
+	err := h.orderUC.Create(ctx, domainOrder)
 	if err != nil {
-		log.Printf("[gRPC] Error creating order: %v", err)
+		slog.ErrorContext(ctx, "failed to create order", "user_id", req.UserId, "error", err)
 		return nil, status.Errorf(codes.Internal, "failed to create order: %v", err)
 	}
 
 	// Преобразование обратно в gRPC модель для ответа
-	resp := &pb.Order{
-		Id:     int32(domainOrder.ID),
-		UserId: int32(domainOrder.UserID),
-		Status: domainOrder.Status,
-	}
-
-	for _, item := range domainOrder.Items {
-		resp.Items = append(resp.Items, &pb.OrderItem{
-			Id:        int32(item.ID),
-			OrderId:   int32(item.OrderID),
-			ProductId: int32(item.ProductID),
-			Quantity:  int32(item.Quantity),
-		})
-	}
+	resp := toProto(domainOrder)
 
-	log.Printf("[gRPC] Successfully created order %d", domainOrder.ID)
+	slog.InfoContext(ctx, "created order", "order_id", domainOrder.ID, "user_id", domainOrder.UserID)
 	return resp, nil
 }
 
 func (h *OrderHandler) GetOrder(ctx context.Context, req *pb.OrderID) (*pb.Order, error) {
-	order, err := h.orderUC.GetByID(int(req.Id))
+	order, err := h.orderUC.GetByID(ctx, int(req.Id))
 	if err != nil {
 		return nil, err
 	}
 
 	// Преобразование модели домена в gRPC ответ
-	resp := &pb.Order{
-		Id:     int32(order.ID),
-		UserId: int32(order.UserID),
-		Status: order.Status,
-	}
-
-	for _, item := range order.Items {
-		resp.Items = append(resp.Items, &pb.OrderItem{
-			Id:        int32(item.ID),
-			OrderId:   int32(item.OrderID),
-			ProductId: int32(item.ProductID),
-			Quantity:  int32(item.Quantity),
-		})
-	}
-
-	return resp, nil
+	return toProto(order), nil
 }
 
 func (h *OrderHandler) UpdateOrderStatus(ctx context.Context, req *pb.Order) (*pb.Order, error) {
-	err := h.orderUC.UpdateStatus(int(req.Id), req.Status)
+	err := h.orderUC.UpdateStatus(ctx, int(req.Id), req.Status)
 	if err != nil {
 		return nil, err
 	}
 
-	order, err := h.orderUC.GetByID(int(req.Id))
+	order, err := h.orderUC.GetByID(ctx, int(req.Id))
 	if err != nil {
 		return nil, err
 	}
 
 	// Преобразование модели домена в gRPC ответ
-	resp := &pb.Order{
-		Id:     int32(order.ID),
-		UserId: int32(order.UserID),
-		Status: order.Status,
-	}
-
-	for _, item := range order.Items {
-		resp.Items = append(resp.Items, &pb.OrderItem{
-			Id:        int32(item.ID),
-			OrderId:   int32(item.OrderID),
-			ProductId: int32(item.ProductID),
-			Quantity:  int32(item.Quantity),
-		})
-	}
-
-	return resp, nil
+	return toProto(order), nil
 }
 
 func (h *OrderHandler) ListOrdersByUser(ctx context.Context, req *pb.ListOrdersRequest) (*pb.OrderList, error) {
-	orders, err := h.orderUC.ListByUser(int(req.UserId))
+	orders, err := h.orderUC.ListByUser(ctx, int(req.UserId))
 	if err != nil {
 		return nil, err
 	}
 
 	resp := &pb.OrderList{}
 	for _, order := range orders {
-		pbOrder := &pb.Order{
-			Id:     int32(order.ID),
-			UserId: int32(order.UserID),
-			Status: order.Status,
-		}
+		resp.Orders = append(resp.Orders, toProto(&order))
+	}
 
-		for _, item := range order.Items {
-			pbOrder.Items = append(pbOrder.Items, &pb.OrderItem{
-				Id:        int32(item.ID),
-				OrderId:   int32(item.OrderID),
-				ProductId: int32(item.ProductID),
-				Quantity:  int32(item.Quantity),
-			})
+	return resp, nil
+}
+
+func toProto(o *domain.Order) *pb.Order {
+	resp := &pb.Order{
+		Id:     int32(o.ID),
+		UserId: int32(o.UserID),
+		Status: o.Status,
+	}
+	if o.AddressID != nil {
+		resp.AddressId = int32(*o.AddressID)
+	}
+	if a := o.ShippingAddress; a != nil {
+		resp.ShippingAddress = &pb.ShippingAddress{
+			FullName:   a.FullName,
+			Line1:      a.Line1,
+			Line2:      a.Line2,
+			City:       a.City,
+			Region:     a.Region,
+			PostalCode: a.PostalCode,
+			Country:    a.Country,
+			Phone:      a.Phone,
 		}
+	}
 
-		resp.Orders = append(resp.Orders, pbOrder)
+	for _, item := range o.Items {
+		resp.Items = append(resp.Items, &pb.OrderItem{
+			Id:        int32(item.ID),
+			OrderId:   int32(item.OrderID),
+			ProductId: int32(item.ProductID),
+			Quantity:  int32(item.Quantity),
+		})
 	}
+	return resp
+}
 
-	return resp, nil
+func shippingFromProto(a *pb.ShippingAddress) *domain.ShippingAddress {
+	return &domain.ShippingAddress{
+		FullName:   a.FullName,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+		Phone:      a.Phone,
+	}
 }
@@ -1,6 +1,16 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// DeletedUserID — владелец заказов удаленного пользователя. Сами заказы
+// остаются: они нужны для учета и статистики продаж.
+const DeletedUserID = 0
 
 type Order struct {
 	ID        int         `json:"id" db:"id"`
@@ -8,6 +18,41 @@ type Order struct {
 	Status    string      `json:"status" db:"status"`
 	CreatedAt time.Time   `json:"created_at" db:"created_at"`
 	Items     []OrderItem `json:"items"`
+
+	// AddressID — адрес из адресной книги пользователя, ShippingAddress — его копия
+	// на момент заказа. Копия не меняется, даже если адрес потом отредактируют или удалят.
+	AddressID       *int             `json:"address_id,omitempty" db:"address_id"`
+	ShippingAddress *ShippingAddress `json:"shipping_address,omitempty" db:"shipping_address"`
+}
+
+// ShippingAddress хранится в заказе как JSONB
+type ShippingAddress struct {
+	FullName   string `json:"full_name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	Country    string `json:"country"`
+	Phone      string `json:"phone,omitempty"`
+}
+
+func (a *ShippingAddress) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	return json.Marshal(a)
+}
+
+func (a *ShippingAddress) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return errors.New("shipping_address: unsupported type")
+	}
 }
 
 type OrderItem struct {
@@ -18,15 +63,18 @@ type OrderItem struct {
 }
 
 type OrderRepository interface {
-	Create(order *Order) error
-	GetByID(id int) (*Order, error)
-	UpdateStatus(id int, status string) error
-	ListByUser(userID int) ([]Order, error)
+	Create(ctx context.Context, order *Order) error
+	GetByID(ctx context.Context, id int) (*Order, error)
+	UpdateStatus(ctx context.Context, id int, status string) error
+	ListByUser(ctx context.Context, userID int) ([]Order, error)
+	// AnonymizeUser отвязывает заказы от пользователя и стирает адрес доставки
+	AnonymizeUser(ctx context.Context, userID int) (int, error)
 }
 
 type OrderUsecase interface {
-	Create(order *Order) error
-	GetByID(id int) (*Order, error)
-	UpdateStatus(id int, status string) error
-	ListByUser(userID int) ([]Order, error)
+	Create(ctx context.Context, order *Order) error
+	GetByID(ctx context.Context, id int) (*Order, error)
+	UpdateStatus(ctx context.Context, id int, status string) error
+	ListByUser(ctx context.Context, userID int) ([]Order, error)
+	AnonymizeUser(ctx context.Context, userID int) error
 }
@@ -0,0 +1,64 @@
+package interceptor
+
+import (
+	"context"
+	"log/slog"
+	"orderService/internal/mtls"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AnyCaller в Policy разрешает вызов любому сервису с сертификатом нашего CA
+const AnyCaller = "*"
+
+// Policy — кому какие вызовы разрешены. Ключ — полное имя метода
+// (/pkg.Service/Method) или имя сервиса (pkg.Service), значение — имена
+// вызывающих сервисов из их сертификатов. Правило метода важнее правила
+// сервиса; вызов, для которого правила нет, запрещен.
+type Policy map[string][]string
+
+func (p Policy) allows(fullMethod, caller string) bool {
+	callers, ok := p[fullMethod]
+	if !ok {
+		service, _, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+		callers, ok = p[service]
+	}
+	if !ok || caller == "" {
+		return false
+	}
+	return slices.Contains(callers, caller) || slices.Contains(callers, AnyCaller)
+}
+
+func (p Policy) authorize(ctx context.Context, fullMethod string) error {
+	caller := mtls.Identity(ctx)
+	if p.allows(fullMethod, caller) {
+		return nil
+	}
+	slog.WarnContext(ctx, "RPC denied", "method", fullMethod, "caller", caller)
+	return status.Errorf(codes.PermissionDenied, "caller %q may not call %s", caller, fullMethod)
+}
+
+// UnaryAuthorize пропускает вызов, только если policy разрешает его
+// сервису, предъявившему клиентский сертификат
+func UnaryAuthorize(policy Policy) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := policy.authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthorize — то же для потоковых вызовов
+func StreamAuthorize(policy Policy) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := policy.authorize(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
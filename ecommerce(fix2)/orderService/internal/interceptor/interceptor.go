@@ -0,0 +1,36 @@
+// Package interceptor собирает цепочку перехватчиков gRPC-сервера: request id
+// и лог вызова, метрики, восстановление после паники, предельный дедлайн,
+// проверка вызывающего сервиса по сертификату и запроса по аннотациям
+// buf.validate из .proto.
+package interceptor
+
+import (
+	"orderService/internal/logging"
+	"orderService/internal/metrics"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Unary — цепочка для унарных вызовов. Порядок важен: лог и метрики стоят
+// снаружи и видят код Internal после паники, PermissionDenied и InvalidArgument
+// после проверок, а дедлайн ограничивает и проверку, и обработчик.
+func Unary(maxDeadline time.Duration, policy Policy) grpc.ServerOption {
+	return grpc.ChainUnaryInterceptor(
+		logging.UnaryServerInterceptor(),
+		metrics.UnaryServerInterceptor(),
+		UnaryRecovery(),
+		UnaryAuthorize(policy),
+		UnaryMaxDeadline(maxDeadline),
+		UnaryValidate(),
+	)
+}
+
+// Stream — цепочка для потоковых вызовов (Watch из grpc.health.v1)
+func Stream(policy Policy) grpc.ServerOption {
+	return grpc.ChainStreamInterceptor(
+		metrics.StreamServerInterceptor(),
+		StreamRecovery(),
+		StreamAuthorize(policy),
+	)
+}
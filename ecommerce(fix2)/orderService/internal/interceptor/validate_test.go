@@ -0,0 +1,94 @@
+package interceptor
+
+import (
+	"context"
+	"orderService/internal/delivery/grpc/pb"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func validAddress() *pb.ShippingAddress {
+	return &pb.ShippingAddress{FullName: "Alice", Line1: "1 Main St", City: "Berlin", Country: "DE"}
+}
+
+func TestUnaryValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		req  interface{}
+		// want — нарушенные поля и их описания; пусто — запрос доходит до обработчика
+		want map[string]string
+	}{
+		{
+			name: "valid order",
+			req:  &pb.Order{Items: []*pb.OrderItem{{ProductId: 1, Quantity: 2}}, ShippingAddress: validAddress()},
+		},
+		{
+			name: "not a proto message",
+			req:  "raw",
+		},
+		{
+			name: "nested item",
+			req:  &pb.Order{Items: []*pb.OrderItem{{ProductId: 1, Quantity: 1}, {ProductId: 1}}},
+			want: map[string]string{"items[1].quantity": "value must be greater than 0"},
+		},
+		{
+			name: "address snapshot",
+			req: &pb.Order{ShippingAddress: &pb.ShippingAddress{
+				FullName: "Alice", Line1: "1 Main St", City: "Berlin", Country: "de",
+			}},
+			want: map[string]string{"shipping_address.country": "value does not match regex pattern `^[A-Z]{2}$`"},
+		},
+		{
+			name: "every violation is reported",
+			req:  &pb.Order{Id: -1, AddressId: -1},
+			want: map[string]string{
+				"id":         "value must be greater than or equal to 0",
+				"address_id": "value must be greater than or equal to 0",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := func(context.Context, interface{}) (interface{}, error) {
+				called = true
+				return nil, nil
+			}
+			_, err := UnaryValidate()(context.Background(), tt.req, &grpc.UnaryServerInfo{FullMethod: "/order.OrderService/CreateOrder"}, handler)
+
+			if len(tt.want) == 0 {
+				if err != nil || !called {
+					t.Fatalf("err = %v, handler called = %v; want the call to pass", err, called)
+				}
+				return
+			}
+			if called {
+				t.Fatal("handler called for an invalid request")
+			}
+			st := status.Convert(err)
+			if st.Code() != codes.InvalidArgument {
+				t.Fatalf("code = %v, want InvalidArgument", st.Code())
+			}
+			got := make(map[string]string)
+			for _, d := range st.Details() {
+				if br, ok := d.(*errdetails.BadRequest); ok {
+					for _, fv := range br.GetFieldViolations() {
+						got[fv.GetField()] = fv.GetDescription()
+					}
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("violations = %v, want %v", got, tt.want)
+			}
+			for field, desc := range tt.want {
+				if got[field] != desc {
+					t.Errorf("%s: description = %q, want %q", field, got[field], desc)
+				}
+			}
+		})
+	}
+}
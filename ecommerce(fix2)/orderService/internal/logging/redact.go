@@ -0,0 +1,33 @@
+package logging
+
+import (
+	"log/slog"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveParts — части имен полей, значения которых не попадают в логи
+var sensitiveParts = []string{
+	"password", "passwd", "secret", "token", "authorization", "cookie",
+	"api_key", "apikey", "recovery_code", "totp", "otp_code",
+}
+
+// redact заменяет значения секретных полей; подключается как ReplaceAttr
+func redact(_ []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() != slog.KindGroup && Sensitive(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	return a
+}
+
+// Sensitive сообщает, что поле с таким именем нельзя писать в логи как есть
+func Sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range sensitiveParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"orderService/internal/domain"
+	"time"
+)
+
+// privacyService — имя, под которым заказы попадают в архив выгрузки
+const privacyService = "orders"
+
+// PrivacyConsumer обрабатывает запросы UserService на выгрузку и удаление данных пользователя
+type PrivacyConsumer struct {
+	orders       domain.OrderUsecase
+	rabbitClient *RabbitMQClient
+}
+
+// NewPrivacyConsumer создает новый обработчик запросов
+func NewPrivacyConsumer(uc domain.OrderUsecase, rabbitClient *RabbitMQClient) *PrivacyConsumer {
+	return &PrivacyConsumer{
+		orders:       uc,
+		rabbitClient: rabbitClient,
+	}
+}
+
+// Start запускает потребителя сообщений
+func (c *PrivacyConsumer) Start() error {
+	return c.rabbitClient.ConsumePrivacyRequests(c.handleRequest)
+}
+
+func (c *PrivacyConsumer) handleRequest(ctx context.Context, routingKey string, payload PrivacyRequestPayload) error {
+	part := PrivacyPartPayload{
+		RequestID: payload.RequestID,
+		Service:   privacyService,
+	}
+
+	switch routingKey {
+	case routingExportRequested:
+		slog.InfoContext(ctx, "exporting orders", "user_id", payload.UserID, "privacy_request_id", payload.RequestID)
+		orders, err := c.orders.ListByUser(ctx, payload.UserID)
+		if err != nil {
+			return err
+		}
+		if orders == nil {
+			orders = []domain.Order{}
+		}
+		data, err := json.Marshal(orders)
+		if err != nil {
+			return err
+		}
+		part.Data = data
+	case routingDeletionRequested:
+		slog.InfoContext(ctx, "anonymizing orders", "user_id", payload.UserID, "privacy_request_id", payload.RequestID)
+		// Повторная доставка безопасна: заказов пользователя уже не останется
+		if err := c.orders.AnonymizeUser(ctx, payload.UserID); err != nil {
+			return err
+		}
+	default:
+		slog.WarnContext(ctx, "unexpected routing key, skipping", "routing_key", routingKey)
+		return nil
+	}
+
+	part.Timestamp = time.Now()
+	return c.rabbitClient.PublishPrivacyPart(ctx, part)
+}
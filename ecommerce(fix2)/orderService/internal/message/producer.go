@@ -1,6 +1,7 @@
 package message
 
 import (
+	"context"
 	"orderService/internal/domain"
 	"time"
 )
@@ -20,7 +21,7 @@ func NewMessageProducer(rabbitClient *RabbitMQClient) *MessageProducer {
 }
 
 // PublishOrderCreated публикует событие о создании заказа
-func (p *MessageProducer) PublishOrderCreated(order *domain.Order) error {
+func (p *MessageProducer) PublishOrderCreated(ctx context.Context, order *domain.Order) error {
 	// Подготовка данных товаров
 	var items []Item
 	for _, orderItem := range order.Items {
@@ -40,5 +41,5 @@ func (p *MessageProducer) PublishOrderCreated(order *domain.Order) error {
 	}
 
 	// Публикация сообщения в клиенте RabbitMQ
-	return p.rabbitClient.PublishOrderCreated(payload)
+	return p.rabbitClient.PublishOrderCreated(ctx, payload)
 }
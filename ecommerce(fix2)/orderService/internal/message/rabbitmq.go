@@ -1,9 +1,16 @@
 package message
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"orderService/internal/logging"
+	"orderService/internal/metrics"
+	"orderService/internal/tracing"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/streadway/amqp"
@@ -14,6 +21,12 @@ type RabbitMQClient struct {
 	conn    *amqp.Connection
 	channel *amqp.Channel
 	queue   amqp.Queue
+
+	// Потребители, которых отменяет StopConsumers, и их обработчики
+	mu        sync.Mutex
+	consumers []string
+	handlers  sync.WaitGroup
+	queues    []string // очереди потребителей, для метрик глубины очередей
 }
 
 // MessagePayload представляет полезную нагрузку сообщения
@@ -31,6 +44,27 @@ type Item struct {
 	Quantity  int `json:"quantity"`
 }
 
+// PrivacyRequestPayload — запрос UserService на выгрузку или удаление данных пользователя
+type PrivacyRequestPayload struct {
+	RequestID int       `json:"request_id"`
+	UserID    int       `json:"user_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// PrivacyPartPayload — ответ на запрос: заказы пользователя для выгрузки
+// или подтверждение, что заказы обезличены
+type PrivacyPartPayload struct {
+	RequestID int             `json:"request_id"`
+	Service   string          `json:"service"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+const (
+	routingExportRequested   = "user.export_requested"
+	routingDeletionRequested = "user.deletion_requested"
+)
+
 // NewRabbitMQClient создаёт новый клиент RabbitMQ
 func NewRabbitMQClient(url, queueName string) (*RabbitMQClient, error) {
 	// Подключение к RabbitMQ
@@ -98,9 +132,8 @@ func NewRabbitMQClient(url, queueName string) (*RabbitMQClient, error) {
 }
 
 // PublishOrderCreated публикует сообщение о создании заказа
-func (c *RabbitMQClient) PublishOrderCreated(payload MessagePayload) error {
-	// Логирование начала публикации
-	log.Printf("[RabbitMQ Producer] Publishing order created event for OrderID: %d", payload.OrderID)
+func (c *RabbitMQClient) PublishOrderCreated(ctx context.Context, payload MessagePayload) error {
+	slog.DebugContext(ctx, "publishing order created event", "order_id", payload.OrderID)
 
 	// Сериализация полезной нагрузки
 	body, err := json.Marshal(payload)
@@ -108,78 +141,245 @@ func (c *RabbitMQClient) PublishOrderCreated(payload MessagePayload) error {
 		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	// Публикация сообщения
+	// Публикация сообщения; в заголовках — контекст трассировки, по нему
+	// InventoryService продолжит трассу оформления заказа
+	span, headers := tracing.StartPublish(ctx, "order_events", "order.created")
+	logging.InjectHeaders(ctx, headers)
 	err = c.channel.Publish(
 		"order_events",  // exchange
 		"order.created", // routing key
 		false,           // mandatory
 		false,           // immediate
 		amqp.Publishing{
+			Headers:      headers,
 			ContentType:  "application/json",
 			Body:         body,
 			DeliveryMode: amqp.Persistent,
 			Timestamp:    time.Now(),
 		},
 	)
+	tracing.End(span, err)
+	metrics.Published("order_events", "order.created", err)
 	if err != nil {
 		return fmt.Errorf("failed to publish a message: %w", err)
 	}
 
-	// Логирование успешной публикации
-	log.Printf("[RabbitMQ Producer] Successfully published order created event for OrderID: %d", payload.OrderID)
+	slog.InfoContext(ctx, "published order created event", "order_id", payload.OrderID)
 	return nil
 }
 
-// ConsumeOrderCreated потребляет сообщения о создании заказа
-func (c *RabbitMQClient) ConsumeOrderCreated(handler func(MessagePayload) error) error {
-	// Логирование начала потребления
-	log.Printf("[RabbitMQ Consumer] Starting to consume order created events")
-
+// ConsumeOrderCreated потребляет сообщения о создании заказа. handler получает
+// контекст со спаном обработки, продолжающим трассу отправителя.
+func (c *RabbitMQClient) ConsumeOrderCreated(handler func(context.Context, MessagePayload) error) error {
 	// Настройка потребления сообщений
 	msgs, err := c.channel.Consume(
-		c.queue.Name, // queue
-		"",           // consumer
-		false,        // auto-ack
-		false,        // exclusive
-		false,        // no-local
-		false,        // no-wait
-		nil,          // args
+		c.queue.Name,                // queue
+		c.consumerTag(c.queue.Name), // consumer
+		false,                       // auto-ack
+		false,                       // exclusive
+		false,                       // no-local
+		false,                       // no-wait
+		nil,                         // args
 	)
 	if err != nil {
 		return fmt.Errorf("failed to register a consumer: %w", err)
 	}
 
 	// Обработка сообщений
+	c.handlers.Add(1)
 	go func() {
+		defer c.handlers.Done()
 		for d := range msgs {
-			log.Printf("[RabbitMQ Consumer] Received message: %s", d.Body)
+			metrics.Consumed(c.queue.Name, d.Timestamp)
+			ctx, span := tracing.StartConsume(c.queue.Name, d)
+			ctx = logging.ContextFromHeaders(ctx, d.Headers)
+			slog.DebugContext(ctx, "received message", "queue", c.queue.Name, "size", len(d.Body))
 
 			// Десериализация полезной нагрузки
 			var payload MessagePayload
 			if err := json.Unmarshal(d.Body, &payload); err != nil {
-				log.Printf("[RabbitMQ Consumer] Error parsing message: %v", err)
-				d.Nack(false, true) // Ошибка обработки, вернуть в очередь
+				slog.ErrorContext(ctx, "failed to parse order created event", "error", err)
+				nack(c.queue.Name, d, true) // Ошибка обработки, вернуть в очередь
+				tracing.End(span, err)
 				continue
 			}
 
 			// Обработка сообщения
-			log.Printf("[RabbitMQ Consumer] Processing order created event for OrderID: %d", payload.OrderID)
-			if err := handler(payload); err != nil {
-				log.Printf("[RabbitMQ Consumer] Error handling message: %v", err)
-				d.Nack(false, true) // Ошибка обработки, вернуть в очередь
+			if err := handler(ctx, payload); err != nil {
+				slog.ErrorContext(ctx, "failed to handle order created event", "order_id", payload.OrderID, "error", err)
+				nack(c.queue.Name, d, true) // Ошибка обработки, вернуть в очередь
+				tracing.End(span, err)
 				continue
 			}
 
 			// Подтверждение обработки
-			d.Ack(false)
-			log.Printf("[RabbitMQ Consumer] Successfully processed order created event for OrderID: %d", payload.OrderID)
+			ack(c.queue.Name, d)
+			tracing.End(span, nil)
 		}
 	}()
 
-	log.Printf("[RabbitMQ Consumer] Waiting for order created events...")
+	slog.Info("waiting for order created events", "queue", c.queue.Name)
 	return nil
 }
 
+// ConsumePrivacyRequests потребляет запросы на выгрузку и удаление данных
+// пользователя из exchange user_events. handler получает контекст со спаном
+// обработки и routing key события.
+func (c *RabbitMQClient) ConsumePrivacyRequests(handler func(ctx context.Context, routingKey string, payload PrivacyRequestPayload) error) error {
+	err := c.channel.ExchangeDeclare(
+		"user_events", // name
+		"direct",      // type
+		true,          // durable
+		false,         // auto-deleted
+		false,         // internal
+		false,         // no-wait
+		nil,           // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare an exchange: %w", err)
+	}
+
+	queue, err := c.channel.QueueDeclare(
+		"order_privacy_requests", // name
+		true,                     // durable
+		false,                    // delete when unused
+		false,                    // exclusive
+		false,                    // no-wait
+		nil,                      // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare a queue: %w", err)
+	}
+
+	for _, key := range []string{routingExportRequested, routingDeletionRequested} {
+		if err := c.channel.QueueBind(queue.Name, key, "user_events", false, nil); err != nil {
+			return fmt.Errorf("failed to bind queue: %w", err)
+		}
+	}
+
+	msgs, err := c.channel.Consume(
+		queue.Name,                // queue
+		c.consumerTag(queue.Name), // consumer
+		false,                     // auto-ack
+		false,                     // exclusive
+		false,                     // no-local
+		false,                     // no-wait
+		nil,                       // args
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register a consumer: %w", err)
+	}
+
+	c.handlers.Add(1)
+	go func() {
+		defer c.handlers.Done()
+		for d := range msgs {
+			metrics.Consumed(queue.Name, d.Timestamp)
+			ctx, span := tracing.StartConsume(queue.Name, d)
+			ctx = logging.ContextFromHeaders(ctx, d.Headers)
+			var payload PrivacyRequestPayload
+			if err := json.Unmarshal(d.Body, &payload); err != nil {
+				slog.ErrorContext(ctx, "failed to parse privacy request", "error", err)
+				nack(queue.Name, d, false) // Некорректное сообщение, повтор не поможет
+				tracing.End(span, err)
+				continue
+			}
+
+			if err := handler(ctx, d.RoutingKey, payload); err != nil {
+				slog.ErrorContext(ctx, "failed to handle privacy request", "privacy_request_id", payload.RequestID, "error", err)
+				nack(queue.Name, d, true)
+				tracing.End(span, err)
+				continue
+			}
+
+			ack(queue.Name, d)
+			tracing.End(span, nil)
+		}
+	}()
+
+	slog.Info("waiting for privacy requests", "queue", queue.Name)
+	return nil
+}
+
+// PublishPrivacyPart отправляет ответ на запрос выгрузки или удаления данных в UserService
+func (c *RabbitMQClient) PublishPrivacyPart(ctx context.Context, payload PrivacyPartPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	span, headers := tracing.StartPublish(ctx, "user_events", "user.privacy_part")
+	logging.InjectHeaders(ctx, headers)
+	err = c.channel.Publish(
+		"user_events",       // exchange
+		"user.privacy_part", // routing key
+		false,               // mandatory
+		false,               // immediate
+		amqp.Publishing{
+			Headers:      headers,
+			ContentType:  "application/json",
+			Body:         body,
+			DeliveryMode: amqp.Persistent,
+			Timestamp:    time.Now(),
+		},
+	)
+	tracing.End(span, err)
+	metrics.Published("user_events", "user.privacy_part", err)
+	if err != nil {
+		return fmt.Errorf("failed to publish a message: %w", err)
+	}
+
+	slog.InfoContext(ctx, "published privacy part", "privacy_request_id", payload.RequestID)
+	return nil
+}
+
+// Ping сообщает, живо ли соединение с брокером; сигнатура подходит для health-проверок
+func (c *RabbitMQClient) Ping(context.Context) error {
+	if c.conn.IsClosed() {
+		return errors.New("connection is closed")
+	}
+	return nil
+}
+
+// consumerTag возвращает уникальный тег потребителя и запоминает его для StopConsumers
+func (c *RabbitMQClient) consumerTag(queue string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tag := fmt.Sprintf("%s-%d-%d", queue, os.Getpid(), len(c.consumers)+1)
+	c.consumers = append(c.consumers, tag)
+	c.queues = append(c.queues, queue)
+	return tag
+}
+
+// StopConsumers отменяет потребителей: брокер перестает присылать сообщения,
+// а полученные, но еще не подтвержденные вернутся в очередь при закрытии
+// канала. Затем ждет, пока обработчики закончат текущие сообщения, но не
+// дольше ctx.
+func (c *RabbitMQClient) StopConsumers(ctx context.Context) error {
+	c.mu.Lock()
+	tags := c.consumers
+	c.consumers = nil
+	c.mu.Unlock()
+
+	for _, tag := range tags {
+		if err := c.channel.Cancel(tag, false); err != nil {
+			slog.Warn("failed to cancel consumer", "consumer", tag, "error", err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.handlers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("consumers did not finish: %w", ctx.Err())
+	}
+}
+
 // Close закрывает соединение с RabbitMQ
 func (c *RabbitMQClient) Close() {
 	if c.channel != nil {
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	rabbitPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rabbitmq_published_total",
+		Help: "Messages published to RabbitMQ, by exchange, routing key and result (ok or error).",
+	}, []string{"exchange", "routing_key", "result"})
+
+	rabbitConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rabbitmq_consumed_total",
+		Help: "Messages delivered to consumers.",
+	}, []string{"queue"})
+
+	rabbitAcked = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rabbitmq_acked_total",
+		Help: "Messages acknowledged after successful handling.",
+	}, []string{"queue"})
+
+	rabbitNacked = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rabbitmq_nacked_total",
+		Help: "Messages rejected by consumers; requeue=false drops the message.",
+	}, []string{"queue", "requeue"})
+
+	rabbitLag = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "rabbitmq_consume_lag_seconds",
+		Help:    "Time between publishing a message and its delivery to a consumer.",
+		Buckets: []float64{.01, .05, .1, .5, 1, 5, 15, 60, 300, 900},
+	}, []string{"queue"})
+)
+
+// Published учитывает публикацию сообщения
+func Published(exchange, routingKey string, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	rabbitPublished.WithLabelValues(exchange, routingKey, result).Inc()
+}
+
+// Consumed учитывает полученное сообщение и его задержку в очереди.
+// publishedAt — время публикации из свойств сообщения; нулевое не учитывается.
+func Consumed(queue string, publishedAt time.Time) {
+	rabbitConsumed.WithLabelValues(queue).Inc()
+	if !publishedAt.IsZero() {
+		rabbitLag.WithLabelValues(queue).Observe(time.Since(publishedAt).Seconds())
+	}
+}
+
+func Acked(queue string) {
+	rabbitAcked.WithLabelValues(queue).Inc()
+}
+
+func Nacked(queue string, requeue bool) {
+	rabbitNacked.WithLabelValues(queue, strconv.FormatBool(requeue)).Inc()
+}
@@ -0,0 +1,4 @@
+-- Адрес доставки: ссылка на адрес пользователя и его снимок на момент заказа
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS address_id INT,
+    ADD COLUMN IF NOT EXISTS shipping_address JSONB;
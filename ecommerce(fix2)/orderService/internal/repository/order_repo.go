@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"orderService/internal/domain"
 
 	"github.com/jmoiron/sqlx"
@@ -15,24 +16,24 @@ func NewOrderRepo(db *sqlx.DB) domain.OrderRepository {
 	return &orderRepo{db}
 }
 
-func (r *orderRepo) Create(order *domain.Order) error {
-	tx, err := r.db.Beginx()
+func (r *orderRepo) Create(ctx context.Context, order *domain.Order) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
 	// 4.4) Создаем запись в таблице orders и получаем ID
 	var orderID int
-	err = tx.QueryRowx(`
-		INSERT INTO orders (user_id, status) VALUES ($1, $2) RETURNING id
-	`, order.UserID, order.Status).Scan(&orderID)
+	err = tx.QueryRowxContext(ctx, `
+		INSERT INTO orders (user_id, status, address_id, shipping_address) VALUES ($1, $2, $3, $4) RETURNING id
+	`, order.UserID, order.Status, order.AddressID, order.ShippingAddress).Scan(&orderID)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
 	for _, item := range order.Items {
-		_, err := tx.Exec(`
+		_, err := tx.ExecContext(ctx, `
 			INSERT INTO order_items (order_id, product_id, quantity)
 			VALUES ($1, $2, $3)
 		`, orderID, item.ProductID, item.Quantity)
@@ -48,32 +49,43 @@ func (r *orderRepo) Create(order *domain.Order) error {
 	return nil
 }
 
-func (r *orderRepo) GetByID(id int) (*domain.Order, error) {
+func (r *orderRepo) GetByID(ctx context.Context, id int) (*domain.Order, error) {
 	var o domain.Order
-	err := r.db.Get(&o, "SELECT * FROM orders WHERE id=$1", id)
+	err := r.db.GetContext(ctx, &o, "SELECT * FROM orders WHERE id=$1", id)
 	if err != nil {
 		return nil, err
 	}
 
-	err = r.db.Select(&o.Items, "SELECT * FROM order_items WHERE order_id=$1", o.ID)
+	err = r.db.SelectContext(ctx, &o.Items, "SELECT * FROM order_items WHERE order_id=$1", o.ID)
 	return &o, err
 }
 
-func (r *orderRepo) UpdateStatus(id int, status string) error {
-	_, err := r.db.Exec("UPDATE orders SET status=$1 WHERE id=$2", status, id)
+func (r *orderRepo) UpdateStatus(ctx context.Context, id int, status string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE orders SET status=$1 WHERE id=$2", status, id)
 	return err
 }
 
-func (r *orderRepo) ListByUser(userID int) ([]domain.Order, error) {
+func (r *orderRepo) ListByUser(ctx context.Context, userID int) ([]domain.Order, error) {
 	var orders []domain.Order
-	err := r.db.Select(&orders, "SELECT * FROM orders WHERE user_id=$1", userID)
+	err := r.db.SelectContext(ctx, &orders, "SELECT * FROM orders WHERE user_id=$1", userID)
 	if err != nil {
 		return nil, err
 	}
 
 	for i, order := range orders {
-		r.db.Select(&orders[i].Items, "SELECT * FROM order_items WHERE order_id=$1", order.ID)
+		r.db.SelectContext(ctx, &orders[i].Items, "SELECT * FROM order_items WHERE order_id=$1", order.ID)
 	}
 
 	return orders, nil
 }
+
+func (r *orderRepo) AnonymizeUser(ctx context.Context, userID int) (int, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE orders SET user_id=$1, address_id=NULL, shipping_address=NULL WHERE user_id=$2
+	`, domain.DeletedUserID, userID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
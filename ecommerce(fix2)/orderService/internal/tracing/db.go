@@ -0,0 +1,40 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/XSAM/otelsql"
+	"github.com/jmoiron/sqlx"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ConnectPostgres открывает базу через драйвер с трассировкой: каждый запрос,
+// выполненный с контекстом (QueryRowxContext, ExecContext...), становится
+// дочерним спаном. Запросы вне трассы (без контекста, проверки health)
+// спанов не создают, чтобы не плодить трассы из одного запроса.
+func ConnectPostgres(dsn string) (*sqlx.DB, error) {
+	db, err := otelsql.Open("postgres", dsn,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+			SpanFilter:           inTrace,
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	dbx := sqlx.NewDb(db, "postgres")
+	if err := dbx.Ping(); err != nil {
+		dbx.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return dbx, nil
+}
+
+func inTrace(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+	return trace.SpanContextFromContext(ctx).IsValid()
+}
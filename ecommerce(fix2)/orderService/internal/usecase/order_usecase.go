@@ -1,9 +1,11 @@
 package usecase
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"orderService/internal/domain"
 	"orderService/internal/message"
+	"orderService/internal/metrics"
 )
 
 type orderUsecase struct {
@@ -16,32 +18,41 @@ func NewOrderUsecase(r domain.OrderRepository, p *message.MessageProducer) domai
 	return &orderUsecase{r, p}
 }
 
-func (uc *orderUsecase) Create(o *domain.Order) error {
+func (uc *orderUsecase) Create(ctx context.Context, o *domain.Order) error {
 	o.Status = "pending"
 
 	// 4.1) Сохраняем заказ в БД
-	if err := uc.repo.Create(o); err != nil {
+	if err := uc.repo.Create(ctx, o); err != nil {
 		return err
 	}
+	metrics.OrdersCreated.Inc()
 
 	// 4.2) Публикуем событие создания заказа
-	log.Printf("[OrderUsecase] Publishing order created event for OrderID: %d", o.ID)
-	if err := uc.producer.PublishOrderCreated(o); err != nil {
-		log.Printf("[OrderUsecase] Failed to publish order created event: %v", err)
+	if err := uc.producer.PublishOrderCreated(ctx, o); err != nil {
+		slog.ErrorContext(ctx, "failed to publish order created event", "order_id", o.ID, "error", err)
 		// Продолжаем выполнение даже при ошибке публикации
 	}
 
 	return nil
 }
 
-func (uc *orderUsecase) GetByID(id int) (*domain.Order, error) {
-	return uc.repo.GetByID(id)
+func (uc *orderUsecase) GetByID(ctx context.Context, id int) (*domain.Order, error) {
+	return uc.repo.GetByID(ctx, id)
 }
 
-func (uc *orderUsecase) UpdateStatus(id int, status string) error {
-	return uc.repo.UpdateStatus(id, status)
+func (uc *orderUsecase) UpdateStatus(ctx context.Context, id int, status string) error {
+	return uc.repo.UpdateStatus(ctx, id, status)
 }
 
-func (uc *orderUsecase) ListByUser(userID int) ([]domain.Order, error) {
-	return uc.repo.ListByUser(userID)
+func (uc *orderUsecase) ListByUser(ctx context.Context, userID int) ([]domain.Order, error) {
+	return uc.repo.ListByUser(ctx, userID)
+}
+
+func (uc *orderUsecase) AnonymizeUser(ctx context.Context, userID int) error {
+	n, err := uc.repo.AnonymizeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "anonymized orders", "user_id", userID, "orders", n)
+	return nil
 }
@@ -1,38 +1,209 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"net"
+	"os"
+	"userService/internal/config"
 	grpcDelivery "userService/internal/delivery/grpc"
 	pb "userService/internal/delivery/grpc/pb"
+	"userService/internal/domain"
+	"userService/internal/hasher"
+	"userService/internal/health"
+	"userService/internal/interceptor"
+	"userService/internal/lifecycle"
+	"userService/internal/logging"
+	"userService/internal/mailer"
+	"userService/internal/message"
+	"userService/internal/metrics"
+	"userService/internal/migrations"
+	"userService/internal/mtls"
 	"userService/internal/repository"
+	"userService/internal/tracing"
 	"userService/internal/usecase"
 
-	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
+// callers — какие сервисы могут вызывать UserService, по имени в их сертификатах
+var callers = interceptor.Policy{
+	pb.UserService_ServiceDesc.ServiceName: {"apiGateway"},
+	// Вход по паролю — только через gateway, даже если правило сервиса
+	// когда-нибудь расширят
+	pb.UserService_Authenticate_FullMethodName: {"apiGateway"},
+	// Проверки health доступны любому сервису с сертификатом нашего CA
+	healthpb.Health_ServiceDesc.ServiceName: {interceptor.AnyCaller},
+}
+
 func main() {
-	db, err := sqlx.Connect("postgres", "host=localhost port=5432 user=postgres password=0000 dbname=ecommerce sslmode=disable")
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.Fatalln("failed to connect to DB:", err)
+		logging.Fatal("invalid config", "error", err)
+	}
+	if cfg.PrintConfig {
+		fmt.Println(cfg.YAML())
+		return
+	}
+	if err := logging.Setup(cfg.Log.Level); err != nil {
+		logging.Fatal("invalid log level", "error", err)
+	}
+	slog.Info("UserService config", "config", cfg.String())
+
+	// Подкоманда migrate: применить или откатить миграции схемы и выйти
+	if len(cfg.Args) > 0 {
+		if cfg.Args[0] != "migrate" {
+			logging.Fatal("unknown command", "command", cfg.Args[0], "usage", migrations.ErrUsage.Error())
+		}
+		db, err := tracing.ConnectPostgres(cfg.Database.ConnString())
+		if err != nil {
+			logging.Fatal("failed to connect to database", "error", err)
+		}
+		err = migrations.New(db, cfg.Database.Schema).Command(context.Background(), cfg.Args[1:], os.Stdout)
+		db.Close()
+		if err != nil {
+			logging.Fatal("migrate failed", "error", err)
+		}
+		return
+	}
+
+	// Остановка по SIGTERM: шаги выполняются в обратном порядке регистрации
+	app := lifecycle.New(cfg.Shutdown.Timeout)
+
+	// /metrics для Prometheus; сервер останавливается последним
+	if cfg.Metrics.Addr != "" {
+		app.OnShutdown("metrics server", metrics.Serve(cfg.Metrics.Addr).Shutdown)
 	}
 
+	// Трассировка OpenTelemetry; провайдер останавливается после серверов и
+	// отправляет оставшиеся спаны
+	shutdownTracing, err := tracing.Setup(context.Background(), "userService", cfg.Tracing.Exporter, cfg.Tracing.Endpoint)
+	if err != nil {
+		logging.Fatal("failed to set up tracing", "error", err)
+	}
+	app.OnShutdown("tracing", shutdownTracing)
+
+	db, err := tracing.ConnectPostgres(cfg.Database.ConnString())
+	if err != nil {
+		logging.Fatal("failed to connect to database", "error", err)
+	}
+	app.OnShutdown("database", func(context.Context) error { return db.Close() })
+	metrics.RegisterDB(db.DB, "postgres")
+	if err := migrations.New(db, cfg.Database.Schema).OnStart(context.Background(), cfg.Database.MigrateOnStart); err != nil {
+		logging.Fatal("failed to migrate database", "error", err)
+	}
+
+	rabbitClient, err := message.NewRabbitMQClient(cfg.RabbitMQ.URL)
+	if err != nil {
+		logging.Fatal("failed to initialize RabbitMQ", "error", err)
+	}
+	app.OnShutdown("RabbitMQ connection", func(context.Context) error {
+		rabbitClient.Close()
+		return nil
+	})
+	rabbitClient.RegisterMetrics()
+
 	userRepo := repository.NewUserRepo(db)
-	userUC := usecase.NewUserUsecase(userRepo)
-	handler := grpcDelivery.NewUserHandler(userUC)
+	attemptRepo := repository.NewLoginAttemptRepo(db)
+	tokenRepo := repository.NewTokenRepo(db)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepo(db)
+	sessionRepo := repository.NewSessionRepo(db)
+	messageProducer := message.NewMessageProducer(rabbitClient)
+	passwordHasher := newPasswordHasher(cfg.Password)
+	userUC := usecase.NewUserUsecase(userRepo, attemptRepo, tokenRepo, recoveryCodeRepo, sessionRepo, newMailer(cfg.SMTP), messageProducer, passwordHasher, cfg.Mail.PublicURL)
+	addressRepo := repository.NewAddressRepo(db)
+	addressUC := usecase.NewAddressUsecase(addressRepo)
+	identityRepo := repository.NewIdentityRepo(db)
+	privacyUC := usecase.NewPrivacyUsecase(repository.NewPrivacyRepo(db), userRepo, addressRepo, sessionRepo, identityRepo, attemptRepo, messageProducer, passwordHasher)
+	apiKeyUC := usecase.NewAPIKeyUsecase(repository.NewAPIKeyRepo(db), userRepo)
+	identityUC := usecase.NewIdentityUsecase(identityRepo, userRepo, passwordHasher)
+	handler := grpcDelivery.NewUserHandler(userUC, addressUC, privacyUC, apiKeyUC, identityUC)
+
+	// Ответы сервисов на запросы выгрузки и удаления данных
+	if err := message.NewPrivacyConsumer(privacyUC, rabbitClient).Start(); err != nil {
+		logging.Fatal("failed to start privacy consumer", "error", err)
+	}
+	app.OnShutdown("RabbitMQ consumers", rabbitClient.StopConsumers)
 
-	lis, err := net.Listen("tcp", ":50053")
+	// mTLS: сертификаты перечитываются, когда их файлы заменяют
+	certs, err := mtls.Load(cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.CAFile)
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		logging.Fatal("failed to load TLS certificates", "error", err)
 	}
+	app.OnShutdown("TLS reload", certs.Watch(cfg.TLS.ReloadInterval))
 
-	server := grpc.NewServer()
+	lis, err := net.Listen("tcp", cfg.GRPC.Addr)
+	if err != nil {
+		logging.Fatal("failed to listen", "addr", cfg.GRPC.Addr, "error", err)
+	}
+
+	server := grpc.NewServer(
+		// Только mTLS: без сертификата нашего CA соединение не установится
+		grpc.Creds(credentials.NewTLS(certs.ServerConfig())),
+		// Спаны входящих RPC; проверки health трассы не создают
+		grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithFilter(filters.Not(filters.HealthCheck())))),
+		// Request id и лог вызова, метрики, восстановление после паники,
+		// права вызывающего сервиса, предельный дедлайн и проверка запроса
+		// по аннотациям из .proto
+		interceptor.Unary(cfg.GRPC.MaxDeadline, callers),
+		interceptor.Stream(callers),
+	)
 	pb.RegisterUserServiceServer(server, handler)
 
-	log.Println("UserService gRPC started on port 50053")
-	if err := server.Serve(lis); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+	// grpc.health.v1: Postgres и RabbitMQ проверяются в фоне
+	checker := health.NewChecker(pb.UserService_ServiceDesc.ServiceName)
+	checker.Add("postgres", db.PingContext)
+	checker.Add("rabbitmq", rabbitClient.Ping)
+	checker.Register(server)
+	checker.Start()
+
+	app.OnShutdown("gRPC server", lifecycle.GracefulStopGRPC(server))
+	// Регистрируется последним, чтобы при остановке первым перевести сервис в NOT_SERVING
+	app.OnShutdown("health checks", checker.Shutdown)
+
+	slog.Info("UserService gRPC started", "addr", cfg.GRPC.Addr)
+	if err := app.Run(func() error { return server.Serve(lis) }); err != nil {
+		logging.Fatal("UserService stopped with error", "error", err)
+	}
+	slog.Info("UserService stopped")
+}
+
+// newMailer отправляет письма через SMTP, если задан smtp.addr; иначе
+// складывает их в каталог outbox для локальной разработки
+func newMailer(cfg config.SMTPConfig) mailer.Mailer {
+	if cfg.Addr != "" {
+		return mailer.NewSMTPMailer(cfg.Addr, cfg.Username, cfg.Password, cfg.From)
+	}
+
+	outbox, err := mailer.NewFileOutbox(cfg.OutboxDir)
+	if err != nil {
+		logging.Fatal("failed to create mail outbox", "error", err)
+	}
+	slog.Info("smtp.addr is not set, writing emails to outbox", "dir", cfg.OutboxDir)
+	return outbox
+}
+
+// newPasswordHasher хеширует новые пароли алгоритмом из password.hash (argon2id
+// по умолчанию или bcrypt). Хеши другого алгоритма и со старыми параметрами
+// по-прежнему проверяются и пересчитываются при входе.
+func newPasswordHasher(cfg config.PasswordConfig) domain.PasswordHasher {
+	bcryptHasher := hasher.NewBcrypt(cfg.BcryptCost)
+	argon2idHasher := hasher.NewArgon2id(hasher.DefaultArgon2idParams)
+
+	// Значение проверено в config.Validate
+	if cfg.Hash == "bcrypt" {
+		return hasher.NewChain(bcryptHasher, argon2idHasher)
 	}
+	return hasher.NewChain(argon2idHasher, bcryptHasher)
 }
@@ -0,0 +1,105 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	pb "userService/internal/delivery/grpc/pb"
+	"userService/internal/domain"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *UserHandler) CreateAddress(ctx context.Context, req *pb.Address) (*pb.Address, error) {
+	a := addressFromProto(req)
+	if err := h.addresses.CreateAddress(ctx, a); err != nil {
+		return nil, addressError(ctx, err, "create address failed")
+	}
+	return addressToProto(a), nil
+}
+
+func (h *UserHandler) GetAddress(ctx context.Context, req *pb.AddressRequest) (*pb.Address, error) {
+	a, err := h.addresses.GetAddress(ctx, int(req.UserId), int(req.AddressId))
+	if err != nil {
+		return nil, addressError(ctx, err, "get address failed")
+	}
+	return addressToProto(a), nil
+}
+
+func (h *UserHandler) ListAddresses(ctx context.Context, req *pb.UserID) (*pb.AddressList, error) {
+	addresses, err := h.addresses.ListAddresses(ctx, int(req.Id))
+	if err != nil {
+		return nil, addressError(ctx, err, "list addresses failed")
+	}
+	resp := &pb.AddressList{}
+	for _, a := range addresses {
+		resp.Addresses = append(resp.Addresses, addressToProto(a))
+	}
+	return resp, nil
+}
+
+func (h *UserHandler) UpdateAddress(ctx context.Context, req *pb.Address) (*pb.Address, error) {
+	a := addressFromProto(req)
+	if err := h.addresses.UpdateAddress(ctx, a); err != nil {
+		return nil, addressError(ctx, err, "update address failed")
+	}
+	return addressToProto(a), nil
+}
+
+func (h *UserHandler) DeleteAddress(ctx context.Context, req *pb.AddressRequest) (*pb.Empty, error) {
+	if err := h.addresses.DeleteAddress(ctx, int(req.UserId), int(req.AddressId)); err != nil {
+		return nil, addressError(ctx, err, "delete address failed")
+	}
+	return &pb.Empty{}, nil
+}
+
+func (h *UserHandler) SetDefaultAddress(ctx context.Context, req *pb.AddressRequest) (*pb.Address, error) {
+	a, err := h.addresses.SetDefaultAddress(ctx, int(req.UserId), int(req.AddressId))
+	if err != nil {
+		return nil, addressError(ctx, err, "set default address failed")
+	}
+	return addressToProto(a), nil
+}
+
+func addressError(ctx context.Context, err error, msg string) error {
+	switch {
+	case errors.Is(err, domain.ErrAddressNotFound):
+		return status.Errorf(codes.NotFound, "address not found")
+	case errors.Is(err, domain.ErrAddressLimitReached):
+		return status.Errorf(codes.FailedPrecondition, "address book is full")
+	default:
+		return userError(ctx, err, msg)
+	}
+}
+
+func addressFromProto(a *pb.Address) *domain.Address {
+	return &domain.Address{
+		ID:         int(a.Id),
+		UserID:     int(a.UserId),
+		FullName:   a.FullName,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+		Phone:      a.Phone,
+		IsDefault:  a.IsDefault,
+	}
+}
+
+func addressToProto(a *domain.Address) *pb.Address {
+	return &pb.Address{
+		Id:         int32(a.ID),
+		UserId:     int32(a.UserID),
+		FullName:   a.FullName,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+		Phone:      a.Phone,
+		IsDefault:  a.IsDefault,
+	}
+}
@@ -0,0 +1,106 @@
+package grpc
+
+import (
+	"context"
+	"encoding/base64"
+	"strconv"
+	"time"
+	pb "userService/internal/delivery/grpc/pb"
+	"userService/internal/domain"
+	"userService/internal/validation"
+)
+
+func (h *UserHandler) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.UserList, error) {
+	f, err := userFilter(req)
+	if err != nil {
+		return nil, err
+	}
+	users, nextAfterID, err := h.uc.ListUsers(ctx, f)
+	if err != nil {
+		return nil, userError(ctx, err, "list users failed")
+	}
+
+	resp := &pb.UserList{}
+	for _, u := range users {
+		resp.Users = append(resp.Users, toProto(u))
+	}
+	if nextAfterID != 0 {
+		resp.NextPageToken = encodePageToken(nextAfterID)
+	}
+	return resp, nil
+}
+
+func (h *UserHandler) DisableUser(ctx context.Context, req *pb.UserID) (*pb.UserResponse, error) {
+	u, err := h.uc.DisableUser(ctx, int(req.Id))
+	if err != nil {
+		return nil, userError(ctx, err, "disable user failed")
+	}
+	return toProto(u), nil
+}
+
+func (h *UserHandler) EnableUser(ctx context.Context, req *pb.UserID) (*pb.UserResponse, error) {
+	u, err := h.uc.EnableUser(ctx, int(req.Id))
+	if err != nil {
+		return nil, userError(ctx, err, "enable user failed")
+	}
+	return toProto(u), nil
+}
+
+func (h *UserHandler) SetUserRole(ctx context.Context, req *pb.SetUserRoleRequest) (*pb.UserResponse, error) {
+	u, err := h.uc.SetUserRole(ctx, int(req.Id), req.Role)
+	if err != nil {
+		return nil, userError(ctx, err, "set role failed")
+	}
+	return toProto(u), nil
+}
+
+// userFilter разбирает запрос ListUsers; ошибки формата возвращаются так же,
+// как ошибки валидации
+func userFilter(req *pb.ListUsersRequest) (domain.UserFilter, error) {
+	f := domain.UserFilter{
+		Role:   req.Role,
+		Status: req.Status,
+		Query:  req.Query,
+		Limit:  int(req.PageSize),
+	}
+
+	var errs validation.Errors
+	if req.PageToken != "" {
+		id, err := decodePageToken(req.PageToken)
+		if err != nil {
+			errs = append(errs, validation.FieldError{Field: "page_token", Message: "is invalid"})
+		}
+		f.AfterID = id
+	}
+	f.CreatedAfter = parseFilterTime("created_after", req.CreatedAfter, &errs)
+	f.CreatedBefore = parseFilterTime("created_before", req.CreatedBefore, &errs)
+	if len(errs) > 0 {
+		return f, validationStatus(errs)
+	}
+	return f, nil
+}
+
+func parseFilterTime(field, value string, errs *validation.Errors) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		*errs = append(*errs, validation.FieldError{Field: field, Message: "must be an RFC 3339 timestamp"})
+		return nil
+	}
+	return &t
+}
+
+// Токен страницы непрозрачен для клиента: сейчас это ID последнего пользователя на странице
+func encodePageToken(afterID int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(afterID)))
+}
+
+func decodePageToken(token string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(b))
+}
@@ -0,0 +1,98 @@
+package grpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	pb "userService/internal/delivery/grpc/pb"
+	"userService/internal/domain"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *UserHandler) CreateApiKey(ctx context.Context, req *pb.CreateApiKeyRequest) (*pb.CreateApiKeyResponse, error) {
+	key, k, err := h.apiKeys.CreateAPIKey(ctx, domain.NewAPIKey{
+		UserID:    int(req.UserId),
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresIn: time.Duration(req.ExpiresInDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		return nil, apiKeyError(ctx, err, "create api key failed")
+	}
+	return &pb.CreateApiKeyResponse{Key: key, ApiKey: apiKeyToProto(k)}, nil
+}
+
+func (h *UserHandler) ListApiKeys(ctx context.Context, req *pb.UserID) (*pb.ApiKeyList, error) {
+	keys, err := h.apiKeys.ListAPIKeys(ctx, int(req.Id))
+	if err != nil {
+		return nil, apiKeyError(ctx, err, "list api keys failed")
+	}
+	resp := &pb.ApiKeyList{}
+	for _, k := range keys {
+		resp.ApiKeys = append(resp.ApiKeys, apiKeyToProto(k))
+	}
+	return resp, nil
+}
+
+func (h *UserHandler) RevokeApiKey(ctx context.Context, req *pb.ApiKeyRequest) (*pb.Empty, error) {
+	if err := h.apiKeys.RevokeAPIKey(ctx, int(req.UserId), int(req.KeyId)); err != nil {
+		return nil, apiKeyError(ctx, err, "revoke api key failed")
+	}
+	return &pb.Empty{}, nil
+}
+
+func (h *UserHandler) RotateApiKey(ctx context.Context, req *pb.RotateApiKeyRequest) (*pb.CreateApiKeyResponse, error) {
+	grace := time.Duration(req.GracePeriodHours) * time.Hour
+	key, k, err := h.apiKeys.RotateAPIKey(ctx, int(req.UserId), int(req.KeyId), grace)
+	if err != nil {
+		return nil, apiKeyError(ctx, err, "rotate api key failed")
+	}
+	return &pb.CreateApiKeyResponse{Key: key, ApiKey: apiKeyToProto(k)}, nil
+}
+
+func (h *UserHandler) ValidateApiKey(ctx context.Context, req *pb.ValidateApiKeyRequest) (*pb.ValidateApiKeyResponse, error) {
+	k, u, err := h.apiKeys.ValidateAPIKey(ctx, req.Key)
+	switch {
+	case errors.Is(err, domain.ErrAPIKeyNotFound), errors.Is(err, sql.ErrNoRows):
+		return nil, status.Errorf(codes.Unauthenticated, "invalid api key")
+	case errors.Is(err, domain.ErrUserDisabled):
+		return nil, status.Errorf(codes.PermissionDenied, "account is disabled")
+	case err != nil:
+		slog.ErrorContext(ctx, "validate api key failed", "error", err)
+		return nil, status.Errorf(codes.Internal, "validate api key failed")
+	}
+	return &pb.ValidateApiKeyResponse{ApiKey: apiKeyToProto(k), User: toProto(u)}, nil
+}
+
+func apiKeyError(ctx context.Context, err error, msg string) error {
+	switch {
+	case errors.Is(err, domain.ErrAPIKeyNotFound):
+		return status.Errorf(codes.NotFound, "api key not found")
+	case errors.Is(err, domain.ErrAPIKeyLimitReached):
+		return status.Errorf(codes.FailedPrecondition, "too many api keys, revoke an unused one first")
+	default:
+		return userError(ctx, err, msg)
+	}
+}
+
+func apiKeyToProto(k *domain.APIKey) *pb.ApiKey {
+	resp := &pb.ApiKey{
+		Id:        int32(k.ID),
+		UserId:    int32(k.UserID),
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    k.Scopes,
+		CreatedAt: k.CreatedAt.Format(time.RFC3339),
+	}
+	if k.ExpiresAt != nil {
+		resp.ExpiresAt = k.ExpiresAt.Format(time.RFC3339)
+	}
+	if k.LastUsedAt != nil {
+		resp.LastUsedAt = k.LastUsedAt.Format(time.RFC3339)
+	}
+	return resp
+}
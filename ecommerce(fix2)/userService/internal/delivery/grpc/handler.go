@@ -2,50 +2,298 @@ package grpc
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
 	pb "userService/internal/delivery/grpc/pb"
 	"userService/internal/domain"
+	"userService/internal/validation"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
+const (
+	// clientIPMetadataKey — метаданные, в которых gateway передает IP клиента
+	clientIPMetadataKey = "x-client-ip"
+	// challengeMetadataKey — trailer с токеном второго шага входа
+	challengeMetadataKey = "x-2fa-challenge"
+)
+
 type UserHandler struct {
 	pb.UnimplementedUserServiceServer
-	uc domain.UserUsecase
+	uc         domain.UserUsecase
+	addresses  domain.AddressUsecase
+	privacy    domain.PrivacyUsecase
+	apiKeys    domain.APIKeyUsecase
+	identities domain.IdentityUsecase
 }
 
-func NewUserHandler(uc domain.UserUsecase) *UserHandler {
-	return &UserHandler{uc: uc}
+func NewUserHandler(uc domain.UserUsecase, addresses domain.AddressUsecase, privacy domain.PrivacyUsecase, apiKeys domain.APIKeyUsecase, identities domain.IdentityUsecase) *UserHandler {
+	return &UserHandler{uc: uc, addresses: addresses, privacy: privacy, apiKeys: apiKeys, identities: identities}
 }
 
 func (h *UserHandler) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.UserResponse, error) {
-	u, err := h.uc.Register(req.Username, req.Password)
+	u, err := h.uc.Register(ctx, req.Username, req.Password, req.Email)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "register failed: %v", err)
+		return nil, userError(ctx, err, "register failed")
 	}
-	return &pb.UserResponse{Id: int32(u.ID), Username: u.Username}, nil
+	return toProto(u), nil
 }
 
 func (h *UserHandler) Authenticate(ctx context.Context, req *pb.AuthRequest) (*pb.UserResponse, error) {
-	u, err := h.uc.Authenticate(req.Username, req.Password)
+	u, err := h.uc.Authenticate(ctx, req.Username, req.Password, req.OtpCode, clientIP(ctx))
 	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "invalid credentials")
+		var totpRequired *domain.TOTPRequiredError
+		if errors.As(err, &totpRequired) {
+			grpc.SetTrailer(ctx, metadata.Pairs(challengeMetadataKey, totpRequired.ChallengeToken))
+			return nil, status.Errorf(codes.FailedPrecondition, "one-time code required")
+		}
+		return nil, authError(ctx, err)
 	}
-	return &pb.UserResponse{Id: int32(u.ID), Username: u.Username}, nil
+	return toProto(u), nil
 }
 
 func (h *UserHandler) GetProfile(ctx context.Context, req *pb.UserID) (*pb.UserResponse, error) {
-	u, err := h.uc.GetProfile(int(req.Id))
+	u, err := h.uc.GetProfile(ctx, int(req.Id))
 	if err != nil {
 		return nil, status.Errorf(codes.NotFound, "user not found")
 	}
-	return &pb.UserResponse{Id: int32(u.ID), Username: u.Username}, nil
+	return toProto(u), nil
 }
 
 func (h *UserHandler) UpdateProfile(ctx context.Context, req *pb.UpdateProfileRequest) (*pb.UserResponse, error) {
-	u, err := h.uc.UpdateProfile(int(req.Id), req.Username)
+	upd, err := profileUpdate(req)
+	if err != nil {
+		return nil, err
+	}
+	u, revoked, err := h.uc.UpdateProfile(ctx, int(req.Id), upd)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCredentials) {
+			return nil, status.Errorf(codes.PermissionDenied, "current password is incorrect")
+		}
+		return nil, userError(ctx, err, "update failed")
+	}
+	resp := toProto(u)
+	for _, id := range revoked {
+		resp.RevokedSessionIds = append(resp.RevokedSessionIds, int32(id))
+	}
+	return resp, nil
+}
+
+// profileUpdate собирает обновление по update_mask. Без маски обновляются
+// непустые поля — так ведут себя клиенты, которые о маске не знают.
+func profileUpdate(req *pb.UpdateProfileRequest) (domain.ProfileUpdate, error) {
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		for path, value := range map[string]string{
+			"username":     req.Username,
+			"email":        req.Email,
+			"display_name": req.DisplayName,
+			"password":     req.NewPassword,
+		} {
+			if value != "" {
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	upd := domain.ProfileUpdate{CurrentPassword: req.CurrentPassword, CurrentSessionID: int(req.CurrentSessionId)}
+	for _, path := range paths {
+		switch path {
+		case "username":
+			upd.Username = &req.Username
+		case "email":
+			upd.Email = &req.Email
+		case "display_name":
+			upd.DisplayName = &req.DisplayName
+		case "password":
+			upd.NewPassword = &req.NewPassword
+		default:
+			return upd, status.Errorf(codes.InvalidArgument, "unknown field in update_mask: %q", path)
+		}
+	}
+	return upd, nil
+}
+
+func (h *UserHandler) UnlockUser(ctx context.Context, req *pb.UnlockUserRequest) (*pb.Empty, error) {
+	if req.Username == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "username is required")
+	}
+	if err := h.uc.UnlockUser(ctx, req.Username); err != nil {
+		return nil, status.Errorf(codes.Internal, "unlock failed")
+	}
+	return &pb.Empty{}, nil
+}
+
+func (h *UserHandler) RequestPasswordReset(ctx context.Context, req *pb.PasswordResetRequest) (*pb.Empty, error) {
+	if req.Email == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "email is required")
+	}
+	if err := h.uc.RequestPasswordReset(ctx, req.Email); err != nil {
+		return nil, status.Errorf(codes.Internal, "password reset failed")
+	}
+	return &pb.Empty{}, nil
+}
+
+func (h *UserHandler) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.Empty, error) {
+	if req.Token == "" || req.NewPassword == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token and new_password are required")
+	}
+	if err := h.uc.ResetPassword(ctx, req.Token, req.NewPassword); err != nil {
+		return nil, tokenError(ctx, err)
+	}
+	return &pb.Empty{}, nil
+}
+
+func (h *UserHandler) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.Empty, error) {
+	if req.Token == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token is required")
+	}
+	if err := h.uc.VerifyEmail(ctx, req.Token); err != nil {
+		return nil, tokenError(ctx, err)
+	}
+	return &pb.Empty{}, nil
+}
+
+func (h *UserHandler) EnrollTOTP(ctx context.Context, req *pb.UserID) (*pb.EnrollTOTPResponse, error) {
+	secret, uri, err := h.uc.EnrollTOTP(ctx, int(req.Id))
+	if err != nil {
+		return nil, totpError(err)
+	}
+	return &pb.EnrollTOTPResponse{Secret: secret, OtpauthUri: uri}, nil
+}
+
+func (h *UserHandler) ConfirmTOTP(ctx context.Context, req *pb.ConfirmTOTPRequest) (*pb.RecoveryCodesResponse, error) {
+	if req.Code == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "code is required")
+	}
+	recoveryCodes, err := h.uc.ConfirmTOTP(ctx, int(req.UserId), req.Code)
+	if err != nil {
+		return nil, totpError(err)
+	}
+	return &pb.RecoveryCodesResponse{Codes: recoveryCodes}, nil
+}
+
+func (h *UserHandler) VerifyTOTP(ctx context.Context, req *pb.VerifyTOTPRequest) (*pb.UserResponse, error) {
+	if req.ChallengeToken == "" || req.Code == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "challenge_token and code are required")
+	}
+	u, err := h.uc.VerifyTOTP(ctx, req.ChallengeToken, req.Code, clientIP(ctx))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidToken) {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid or expired challenge")
+		}
+		return nil, authError(ctx, err)
+	}
+	return toProto(u), nil
+}
+
+// authError переводит ошибки входа в коды gRPC; для блокировки выставляет trailer retry-after
+func authError(ctx context.Context, err error) error {
+	var locked *domain.AccountLockedError
+	var throttled *domain.ChallengeThrottledError
+	switch {
+	case errors.As(err, &locked):
+		retryAfter := strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds())))
+		grpc.SetTrailer(ctx, metadata.Pairs("retry-after", retryAfter))
+		return status.Errorf(codes.ResourceExhausted, "too many failed login attempts")
+	case errors.As(err, &throttled):
+		retryAfter := strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds())))
+		grpc.SetTrailer(ctx, metadata.Pairs("retry-after", retryAfter))
+		return status.Errorf(codes.ResourceExhausted, "%v", err)
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		return status.Errorf(codes.Unauthenticated, "invalid credentials")
+	case errors.Is(err, domain.ErrUserDisabled):
+		return status.Errorf(codes.PermissionDenied, "account is disabled")
+	default:
+		return status.Errorf(codes.Internal, "authentication failed")
+	}
+}
+
+func totpError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return status.Errorf(codes.NotFound, "user not found")
+	case errors.Is(err, domain.ErrTOTPAlreadyEnabled), errors.Is(err, domain.ErrTOTPNotEnrolled):
+		return status.Errorf(codes.FailedPrecondition, "%v", err)
+	case errors.Is(err, domain.ErrInvalidOTP):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	default:
+		return status.Errorf(codes.Internal, "two-factor setup failed")
+	}
+}
+
+func tokenError(ctx context.Context, err error) error {
+	if errors.Is(err, domain.ErrInvalidToken) {
+		return status.Errorf(codes.InvalidArgument, "invalid or expired token")
+	}
+	return userError(ctx, err, "token check failed")
+}
+
+// userError переводит ошибки валидации и уникальности в коды gRPC. Прочие
+// ошибки возвращаются как Internal с общим сообщением, без деталей из базы.
+func userError(ctx context.Context, err error, msg string) error {
+	var verrs validation.Errors
+	switch {
+	case errors.As(err, &verrs):
+		return validationStatus(verrs)
+	case errors.Is(err, domain.ErrUsernameTaken):
+		return status.Errorf(codes.AlreadyExists, "username is already taken")
+	case errors.Is(err, domain.ErrEmailTaken):
+		return status.Errorf(codes.AlreadyExists, "email is already in use")
+	case errors.Is(err, sql.ErrNoRows):
+		return status.Errorf(codes.NotFound, "user not found")
+	default:
+		slog.ErrorContext(ctx, msg, "error", err)
+		return status.Errorf(codes.Internal, "%s", msg)
+	}
+}
+
+// validationStatus возвращает InvalidArgument с нарушениями по полям в деталях BadRequest
+func validationStatus(verrs validation.Errors) error {
+	br := &errdetails.BadRequest{}
+	for _, fe := range verrs {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       fe.Field,
+			Description: fe.Message,
+		})
+	}
+	st, err := status.New(codes.InvalidArgument, "validation failed").WithDetails(br)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "update failed: %v", err)
+		return status.Errorf(codes.InvalidArgument, "%v", verrs)
+	}
+	return st.Err()
+}
+
+// clientIP достает IP конечного клиента из метаданных запроса
+func clientIP(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if v := md.Get(clientIPMetadataKey); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func toProto(u *domain.User) *pb.UserResponse {
+	return &pb.UserResponse{
+		Id:            int32(u.ID),
+		Username:      u.Username,
+		Role:          u.Role,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		TotpEnabled:   u.TOTPEnabled,
+		DisplayName:   u.DisplayName,
+		Disabled:      u.Disabled(),
+		CreatedAt:     u.CreatedAt.Format(time.RFC3339),
 	}
-	return &pb.UserResponse{Id: int32(u.ID), Username: u.Username}, nil
 }
@@ -0,0 +1,31 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	pb "userService/internal/delivery/grpc/pb"
+	"userService/internal/domain"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LoginWithOIDC вызывается gateway после проверки ID token: сам токен сюда
+// не передается, поэтому метод доступен только внутри сети сервисов
+func (h *UserHandler) LoginWithOIDC(ctx context.Context, req *pb.OIDCLoginRequest) (*pb.UserResponse, error) {
+	u, err := h.identities.LoginWithOIDC(ctx, domain.ExternalIdentity{
+		Issuer:            req.Issuer,
+		Subject:           req.Subject,
+		Email:             req.Email,
+		EmailVerified:     req.EmailVerified,
+		Name:              req.Name,
+		PreferredUsername: req.PreferredUsername,
+	})
+	if errors.Is(err, domain.ErrUserDisabled) {
+		return nil, status.Errorf(codes.PermissionDenied, "account is disabled")
+	}
+	if err != nil {
+		return nil, userError(ctx, err, "oidc login failed")
+	}
+	return toProto(u), nil
+}
@@ -0,0 +1,65 @@
+package grpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	pb "userService/internal/delivery/grpc/pb"
+	"userService/internal/domain"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *UserHandler) ExportMyData(ctx context.Context, req *pb.UserID) (*pb.PrivacyRequest, error) {
+	r, err := h.privacy.ExportMyData(ctx, int(req.Id))
+	if err != nil {
+		return nil, privacyError(ctx, err, "export failed")
+	}
+	return privacyRequestToProto(r), nil
+}
+
+func (h *UserHandler) GetDataExport(ctx context.Context, req *pb.PrivacyRequestID) (*pb.DataExport, error) {
+	e, err := h.privacy.GetDataExport(ctx, int(req.UserId), int(req.RequestId))
+	if err != nil {
+		return nil, privacyError(ctx, err, "get export failed")
+	}
+	return &pb.DataExport{Request: privacyRequestToProto(e.Request), Archive: e.Archive}, nil
+}
+
+func (h *UserHandler) RequestAccountDeletion(ctx context.Context, req *pb.AccountDeletionRequest) (*pb.PrivacyRequest, error) {
+	r, err := h.privacy.RequestAccountDeletion(ctx, int(req.UserId), req.Password)
+	if err != nil {
+		return nil, privacyError(ctx, err, "account deletion failed")
+	}
+	return privacyRequestToProto(r), nil
+}
+
+func privacyError(ctx context.Context, err error, msg string) error {
+	switch {
+	case errors.Is(err, domain.ErrPrivacyRequestNotFound), errors.Is(err, domain.ErrExportExpired):
+		return status.Errorf(codes.NotFound, "%v", err)
+	case errors.Is(err, sql.ErrNoRows):
+		return status.Errorf(codes.NotFound, "user not found")
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		return status.Errorf(codes.PermissionDenied, "password is incorrect")
+	default:
+		slog.ErrorContext(ctx, msg, "error", err)
+		return status.Errorf(codes.Internal, "%s", msg)
+	}
+}
+
+func privacyRequestToProto(r *domain.PrivacyRequest) *pb.PrivacyRequest {
+	resp := &pb.PrivacyRequest{
+		Id:        int32(r.ID),
+		Kind:      r.Kind,
+		Status:    r.Status,
+		CreatedAt: r.CreatedAt.Format(time.RFC3339),
+	}
+	if r.CompletedAt != nil {
+		resp.CompletedAt = r.CompletedAt.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -0,0 +1,76 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"time"
+	pb "userService/internal/delivery/grpc/pb"
+	"userService/internal/domain"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *UserHandler) CreateSession(ctx context.Context, req *pb.CreateSessionRequest) (*pb.CreateSessionResponse, error) {
+	token, s, err := h.uc.CreateSession(ctx, int(req.UserId), req.Device, req.Ip, req.UserAgent)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "create session failed")
+	}
+	return &pb.CreateSessionResponse{Token: token, Session: sessionToProto(s)}, nil
+}
+
+func (h *UserHandler) ValidateSession(ctx context.Context, req *pb.ValidateSessionRequest) (*pb.ValidateSessionResponse, error) {
+	s, u, err := h.uc.ValidateSession(ctx, req.Token)
+	if err != nil {
+		if errors.Is(err, domain.ErrSessionNotFound) {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid session")
+		}
+		return nil, status.Errorf(codes.Internal, "validate session failed")
+	}
+	return &pb.ValidateSessionResponse{Session: sessionToProto(s), User: toProto(u)}, nil
+}
+
+func (h *UserHandler) ListSessions(ctx context.Context, req *pb.UserID) (*pb.SessionList, error) {
+	sessions, err := h.uc.ListSessions(ctx, int(req.Id))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list sessions failed")
+	}
+	resp := &pb.SessionList{}
+	for _, s := range sessions {
+		resp.Sessions = append(resp.Sessions, sessionToProto(s))
+	}
+	return resp, nil
+}
+
+func (h *UserHandler) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.Empty, error) {
+	if err := h.uc.RevokeSession(ctx, int(req.UserId), int(req.SessionId)); err != nil {
+		if errors.Is(err, domain.ErrSessionNotFound) {
+			return nil, status.Errorf(codes.NotFound, "session not found")
+		}
+		return nil, status.Errorf(codes.Internal, "revoke session failed")
+	}
+	return &pb.Empty{}, nil
+}
+
+func (h *UserHandler) RevokeAllSessions(ctx context.Context, req *pb.RevokeAllSessionsRequest) (*pb.RevokedSessions, error) {
+	ids, err := h.uc.RevokeAllSessions(ctx, int(req.UserId), int(req.ExceptSessionId))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "revoke sessions failed")
+	}
+	resp := &pb.RevokedSessions{}
+	for _, id := range ids {
+		resp.SessionIds = append(resp.SessionIds, int32(id))
+	}
+	return resp, nil
+}
+
+func sessionToProto(s *domain.Session) *pb.Session {
+	return &pb.Session{
+		Id:         int32(s.ID),
+		Device:     s.Device,
+		Ip:         s.IP,
+		UserAgent:  s.UserAgent,
+		CreatedAt:  s.CreatedAt.Format(time.RFC3339),
+		LastSeenAt: s.LastSeenAt.Format(time.RFC3339),
+	}
+}
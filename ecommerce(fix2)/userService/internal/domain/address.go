@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrAddressNotFound     = errors.New("address not found")
+	ErrAddressLimitReached = errors.New("address book is full")
+)
+
+// Address — адрес доставки из адресной книги пользователя
+type Address struct {
+	ID         int       `db:"id"`
+	UserID     int       `db:"user_id"`
+	FullName   string    `db:"full_name"`
+	Line1      string    `db:"line1"`
+	Line2      string    `db:"line2"`
+	City       string    `db:"city"`
+	Region     string    `db:"region"`
+	PostalCode string    `db:"postal_code"`
+	Country    string    `db:"country"` // ISO 3166-1 alpha-2
+	Phone      string    `db:"phone"`
+	IsDefault  bool      `db:"is_default"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+type AddressRepository interface {
+	// Create сохраняет адрес; если IsDefault, снимает флаг с остальных адресов пользователя
+	Create(ctx context.Context, a *Address) error
+	// GetByID возвращает адрес пользователя или ErrAddressNotFound
+	GetByID(ctx context.Context, userID, id int) (*Address, error)
+	List(ctx context.Context, userID int) ([]*Address, error)
+	Count(ctx context.Context, userID int) (int, error)
+	// Update сохраняет адрес; если IsDefault, снимает флаг с остальных адресов пользователя
+	Update(ctx context.Context, a *Address) error
+	// Delete удаляет адрес; если он был основным, основным становится самый новый из оставшихся
+	Delete(ctx context.Context, userID, id int) error
+	SetDefault(ctx context.Context, userID, id int) error
+}
+
+type AddressUsecase interface {
+	CreateAddress(ctx context.Context, a *Address) error
+	GetAddress(ctx context.Context, userID, id int) (*Address, error)
+	ListAddresses(ctx context.Context, userID int) ([]*Address, error)
+	UpdateAddress(ctx context.Context, a *Address) error
+	DeleteAddress(ctx context.Context, userID, id int) error
+	SetDefaultAddress(ctx context.Context, userID, id int) (*Address, error)
+}
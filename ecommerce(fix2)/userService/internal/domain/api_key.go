@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrAPIKeyNotFound возвращается для неизвестного, истекшего или отозванного ключа
+	ErrAPIKeyNotFound     = errors.New("api key not found")
+	ErrAPIKeyLimitReached = errors.New("too many api keys")
+)
+
+// Scopes API-ключей. Ключ действует от имени владельца, поэтому роль
+// владельца по-прежнему проверяется: scope только сужает права.
+const (
+	ScopeProductsRead  = "products:read"
+	ScopeProductsWrite = "products:write"
+	ScopeOrdersRead    = "orders:read"
+	ScopeOrdersWrite   = "orders:write"
+)
+
+var APIKeyScopes = []string{ScopeProductsRead, ScopeProductsWrite, ScopeOrdersRead, ScopeOrdersWrite}
+
+// APIKey — ключ для программного доступа без пароля. Сам ключ клиенту
+// выдается один раз, в базе хранятся его SHA-256 хеш и префикс, по которому
+// ключ можно узнать в списке.
+type APIKey struct {
+	ID         int        `db:"id"`
+	UserID     int        `db:"user_id"`
+	Name       string     `db:"name"`
+	Prefix     string     `db:"prefix"`
+	KeyHash    string     `db:"key_hash"`
+	Scopes     []string   `db:"-"`
+	CreatedAt  time.Time  `db:"created_at"`
+	ExpiresAt  *time.Time `db:"expires_at"` // nil — бессрочный
+	LastUsedAt *time.Time `db:"last_used_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+}
+
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, k *APIKey) error
+	// GetActiveByHash возвращает действующий ключ или ErrAPIKeyNotFound
+	GetActiveByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	// GetActive возвращает действующий ключ пользователя или ErrAPIKeyNotFound
+	GetActive(ctx context.Context, userID, id int) (*APIKey, error)
+	ListActive(ctx context.Context, userID int) ([]*APIKey, error)
+	CountActive(ctx context.Context, userID int) (int, error)
+	// Touch отмечает использование ключа
+	Touch(ctx context.Context, id int) error
+	// Revoke отзывает ключ пользователя; ErrAPIKeyNotFound, если такого действующего ключа нет
+	Revoke(ctx context.Context, userID, id int) error
+	// ExpireAt сокращает срок действия ключа до at, если он истекает позже
+	ExpireAt(ctx context.Context, id int, at time.Time) error
+}
+
+// NewAPIKey — параметры нового ключа; ExpiresIn == 0 — бессрочный
+type NewAPIKey struct {
+	UserID    int
+	Name      string
+	Scopes    []string
+	ExpiresIn time.Duration
+}
+
+type APIKeyUsecase interface {
+	// CreateAPIKey возвращает сам ключ; позже его узнать нельзя
+	CreateAPIKey(ctx context.Context, req NewAPIKey) (key string, k *APIKey, err error)
+	ListAPIKeys(ctx context.Context, userID int) ([]*APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, id int) error
+	// RotateAPIKey выпускает новый ключ с теми же именем и scopes; старый
+	// продолжает действовать еще grace, чтобы клиент успел переключиться
+	RotateAPIKey(ctx context.Context, userID, id int, grace time.Duration) (key string, k *APIKey, err error)
+	// ValidateAPIKey находит действующий ключ и его владельца
+	ValidateAPIKey(ctx context.Context, key string) (*APIKey, *User, error)
+}
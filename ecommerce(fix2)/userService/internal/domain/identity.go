@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrIdentityNotFound = errors.New("identity not found")
+	ErrIdentityExists   = errors.New("identity is already linked")
+)
+
+// ExternalIdentity — учетная запись у внешнего провайдера (OIDC) в том
+// виде, в каком ее описывает проверенный gateway ID token
+type ExternalIdentity struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+// Identity связывает учетную запись провайдера (issuer, subject) с пользователем.
+// Subject у провайдера не меняется, в отличие от email.
+type Identity struct {
+	Issuer      string    `db:"issuer"`
+	Subject     string    `db:"subject"`
+	UserID      int       `db:"user_id"`
+	Email       string    `db:"email"` // email на момент последнего входа
+	CreatedAt   time.Time `db:"created_at"`
+	LastLoginAt time.Time `db:"last_login_at"`
+}
+
+type IdentityRepository interface {
+	// Get возвращает привязку или ErrIdentityNotFound
+	Get(ctx context.Context, issuer, subject string) (*Identity, error)
+	// Create возвращает ErrIdentityExists, если (issuer, subject) уже привязан
+	Create(ctx context.Context, i *Identity) error
+	// Touch отмечает вход и запоминает текущий email у провайдера
+	Touch(ctx context.Context, issuer, subject, email string) error
+	ListByUser(ctx context.Context, userID int) ([]*Identity, error)
+}
+
+type IdentityUsecase interface {
+	// LoginWithOIDC находит пользователя по (issuer, subject). При первом
+	// входе привязывает учетную запись с тем же подтвержденным email или
+	// создает новую.
+	LoginWithOIDC(ctx context.Context, ext ExternalIdentity) (*User, error)
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrInvalidCredentials — единая ошибка для неверного логина и неверного пароля,
+// чтобы по ответу нельзя было узнать, существует ли пользователь
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// AccountLockedError возвращается, пока вход заблокирован после серии неудачных попыток
+type AccountLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *AccountLockedError) Error() string {
+	return "too many failed login attempts"
+}
+
+const (
+	AttemptByUsername = "username"
+	AttemptByIP       = "ip"
+)
+
+// LoginAttempt — счетчик неудачных входов по имени пользователя или по IP
+type LoginAttempt struct {
+	Kind          string     `db:"kind"`
+	Key           string     `db:"key"`
+	Failures      int        `db:"failures"`
+	LockCount     int        `db:"lock_count"`
+	LockedUntil   *time.Time `db:"locked_until"`
+	LastFailureAt time.Time  `db:"last_failure_at"`
+}
+
+type LoginAttemptRepository interface {
+	// Get возвращает nil, nil, если неудачных попыток не было
+	Get(ctx context.Context, kind, key string) (*LoginAttempt, error)
+	// RecordFailure одним запросом прибавляет неудачу к счетчику и возвращает
+	// его новое значение. Счетчик неудач начинается заново, если прошлая
+	// неудача была раньше failuresSince, счетчик блокировок — раньше lockCountSince.
+	RecordFailure(ctx context.Context, kind, key string, at, failuresSince, lockCountSince time.Time) (*LoginAttempt, error)
+	// Lock блокирует вход до until и обнуляет счетчик неудач, только если в нем
+	// все еще не меньше threshold: из параллельных попыток, перешедших порог,
+	// блокировку ставит одна. Возвращает, поставлена ли блокировка.
+	Lock(ctx context.Context, kind, key string, threshold int, until time.Time) (bool, error)
+	Delete(ctx context.Context, kind, key string) error
+}
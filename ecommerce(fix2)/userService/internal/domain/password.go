@@ -0,0 +1,13 @@
+package domain
+
+// PasswordHasher хеширует пароли. Хеш сам описывает алгоритм и параметры,
+// поэтому хеши, сделанные старыми настройками, продолжают проверяться.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify возвращает false для неверного пароля и ошибку для испорченного
+	// хеша или неизвестного алгоритма
+	Verify(hash, password string) (bool, error)
+	// NeedsRehash сообщает, что хеш сделан другим алгоритмом или с устаревшими
+	// параметрами и его стоит пересчитать при следующем входе
+	NeedsRehash(hash string) bool
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrPrivacyRequestNotFound = errors.New("privacy request not found")
+	ErrExportExpired          = errors.New("export has expired")
+)
+
+const (
+	PrivacyExport   = "export"
+	PrivacyDeletion = "deletion"
+
+	PrivacyPending   = "pending"
+	PrivacyCompleted = "completed"
+)
+
+// PrivacyServices — сервисы, которые хранят данные пользователя. Запрос
+// выполнен, когда каждый из них прислал свою часть.
+var PrivacyServices = []string{"users", "orders"}
+
+// PrivacyRequest — запрос пользователя на выгрузку или удаление своих данных
+type PrivacyRequest struct {
+	ID          int        `db:"id"`
+	UserID      int        `db:"user_id"` // без внешнего ключа: запрос на удаление переживает пользователя
+	Kind        string     `db:"kind"`
+	Status      string     `db:"status"`
+	CreatedAt   time.Time  `db:"created_at"`
+	CompletedAt *time.Time `db:"completed_at"`
+}
+
+// PrivacyRequestPart — ответ одного сервиса: его данные для выгрузки
+// или пустое подтверждение, что данные обезличены
+type PrivacyRequestPart struct {
+	RequestID  int             `db:"request_id"`
+	Service    string          `db:"service"`
+	Data       json.RawMessage `db:"data"`
+	ReceivedAt time.Time       `db:"received_at"`
+}
+
+type PrivacyRepository interface {
+	Create(ctx context.Context, r *PrivacyRequest) error
+	// Get возвращает запрос пользователя или ErrPrivacyRequestNotFound
+	Get(ctx context.Context, userID, id int) (*PrivacyRequest, error)
+	// SavePart сохраняет часть сервиса и отмечает запрос выполненным, когда
+	// пришли все части из PrivacyServices. Повторная доставка части ничего не меняет.
+	SavePart(ctx context.Context, requestID int, service string, data json.RawMessage) (completed bool, err error)
+	Parts(ctx context.Context, requestID int) ([]*PrivacyRequestPart, error)
+}
+
+// DataExport — запрос на выгрузку и, если он выполнен, JSON-архив с данными из всех сервисов
+type DataExport struct {
+	Request *PrivacyRequest
+	Archive []byte
+}
+
+type PrivacyUsecase interface {
+	// ExportMyData создает запрос на выгрузку; остальные сервисы присылают свои части асинхронно
+	ExportMyData(ctx context.Context, userID int) (*PrivacyRequest, error)
+	// GetDataExport возвращает запрос и, если все части собраны, архив
+	GetDataExport(ctx context.Context, userID, requestID int) (*DataExport, error)
+	// RequestAccountDeletion проверяет пароль, удаляет учетную запись и просит
+	// остальные сервисы обезличить данные пользователя
+	RequestAccountDeletion(ctx context.Context, userID int, password string) (*PrivacyRequest, error)
+	// CompletePart сохраняет ответ сервиса на запрос
+	CompletePart(ctx context.Context, requestID int, service string, data json.RawMessage) error
+}
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrSessionNotFound возвращается для неизвестной, истекшей или отозванной сессии
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session — вход пользователя с конкретного устройства. Токен сессии
+// клиенту выдается один раз, в базе хранится только его SHA-256 хеш.
+type Session struct {
+	ID         int        `db:"id"`
+	UserID     int        `db:"user_id"`
+	TokenHash  string     `db:"token_hash"`
+	Device     string     `db:"device"`
+	IP         string     `db:"ip"`
+	UserAgent  string     `db:"user_agent"`
+	CreatedAt  time.Time  `db:"created_at"`
+	LastSeenAt time.Time  `db:"last_seen_at"`
+	ExpiresAt  time.Time  `db:"expires_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+}
+
+type SessionRepository interface {
+	Create(ctx context.Context, s *Session) error
+	// GetActiveByTokenHash возвращает действующую сессию или ErrSessionNotFound
+	GetActiveByTokenHash(ctx context.Context, tokenHash string) (*Session, error)
+	// Touch обновляет время последней активности
+	Touch(ctx context.Context, id int) error
+	ListActive(ctx context.Context, userID int) ([]*Session, error)
+	// Revoke отзывает сессию пользователя; ErrSessionNotFound, если такой действующей сессии нет
+	Revoke(ctx context.Context, userID, id int) error
+	// RevokeAll отзывает все сессии пользователя, кроме exceptID, и возвращает их ID
+	RevokeAll(ctx context.Context, userID, exceptID int) ([]int, error)
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrInvalidToken возвращается для неизвестного, просроченного или уже использованного токена
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+const (
+	TokenEmailVerification = "email_verification"
+	TokenPasswordReset     = "password_reset"
+)
+
+// UserToken — одноразовый токен из письма. В базе хранится только SHA-256 хеш токена.
+type UserToken struct {
+	ID        int        `db:"id"`
+	UserID    int        `db:"user_id"`
+	Purpose   string     `db:"purpose"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+type TokenRepository interface {
+	Create(ctx context.Context, t *UserToken) error
+	// Consume помечает действующий токен использованным и возвращает его владельца.
+	// Если токена нет, он истек или уже использован, возвращает ErrInvalidToken.
+	Consume(ctx context.Context, purpose, tokenHash string) (int, error)
+	// DeleteByUser удаляет неиспользованные токены пользователя с данным назначением
+	DeleteByUser(ctx context.Context, userID int, purpose string) error
+	// Reissue заменяет все токены пользователя с назначением t.Purpose на t,
+	// если неиспользованный токен не выдавали ему за последние minInterval.
+	// false — такой токен есть, и новый не создан.
+	Reissue(ctx context.Context, t *UserToken, minInterval time.Duration) (bool, error)
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnrolled    = errors.New("two-factor enrolment has not been started")
+	ErrInvalidOTP         = errors.New("invalid one-time code")
+)
+
+// TokenTOTPChallenge — токен, который выдается после верного пароля и
+// обменивается на вход вместе с одноразовым кодом
+const TokenTOTPChallenge = "totp_challenge"
+
+// TOTPRequiredError возвращается, если пароль верный, но у пользователя
+// включена двухфакторная аутентификация и код не передан
+type TOTPRequiredError struct {
+	ChallengeToken string
+}
+
+func (e *TOTPRequiredError) Error() string {
+	return "one-time code required"
+}
+
+// ChallengeThrottledError возвращается вместо нового токена второго шага,
+// если предыдущий выдан совсем недавно
+type ChallengeThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ChallengeThrottledError) Error() string {
+	return "one-time code challenge was issued recently"
+}
+
+// RecoveryCodeRepository хранит SHA-256 хеши резервных кодов для входа без TOTP
+type RecoveryCodeRepository interface {
+	// Replace заменяет все резервные коды пользователя новыми
+	Replace(ctx context.Context, userID int, codeHashes []string) error
+	// Consume помечает код использованным; false — кода нет или он уже использован
+	Consume(ctx context.Context, userID int, codeHash string) (bool, error)
+}
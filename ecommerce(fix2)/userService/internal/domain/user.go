@@ -1,21 +1,122 @@
 package domain
 
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrUsernameTaken = errors.New("username is already taken")
+	ErrEmailTaken    = errors.New("email is already in use")
+	// ErrUserDisabled возвращается при входе в учетную запись, отключенную администратором
+	ErrUserDisabled = errors.New("account is disabled")
+)
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+const (
+	UserStatusActive   = "active"
+	UserStatusDisabled = "disabled"
+)
+
 type User struct {
-	ID       int    `db:"id"`
-	Username string `db:"username"`
-	Password string `db:"password"` // захешированный пароль
+	ID            int        `db:"id"`
+	Username      string     `db:"username"`
+	Password      string     `db:"password"` // захешированный пароль
+	Role          string     `db:"role"`
+	Email         string     `db:"email"`
+	EmailVerified bool       `db:"email_verified"`
+	DisplayName   string     `db:"display_name"`
+	TOTPSecret    string     `db:"totp_secret"` // base32; пустой, если 2FA не подключали
+	TOTPEnabled   bool       `db:"totp_enabled"`
+	TOTPLastStep  int64      `db:"totp_last_step"` // шаг последнего принятого TOTP-кода
+	CreatedAt     time.Time  `db:"created_at"`
+	DisabledAt    *time.Time `db:"disabled_at"` // nil — учетная запись активна
+}
+
+func (u *User) Disabled() bool {
+	return u.DisabledAt != nil
+}
+
+// UserFilter — условия выборки пользователей для администратора; пустые
+// поля выборку не ограничивают. Страницы идут по возрастанию ID: следующая
+// начинается после AfterID.
+type UserFilter struct {
+	Role          string
+	Status        string // UserStatusActive или UserStatusDisabled
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Query         string // подстрока имени пользователя или email
+	AfterID       int
+	Limit         int
+}
+
+// ProfileUpdate — частичное обновление профиля: nil-поля не меняются
+type ProfileUpdate struct {
+	Username    *string
+	Email       *string
+	DisplayName *string
+	// Пароль меняется, если NewPassword не nil; CurrentPassword обязателен
+	CurrentPassword string
+	NewPassword     *string
+	// Сессия, из которой меняют пароль: она остается, остальные завершаются
+	CurrentSessionID int
 }
 
 type UserRepository interface {
-	Create(u *User) error
-	GetByUsername(username string) (*User, error)
-	GetByID(id int) (*User, error)
-	Update(u *User) error
+	// Create и Update возвращают ErrUsernameTaken или ErrEmailTaken при нарушении уникальности
+	Create(ctx context.Context, u *User) error
+	GetByUsername(ctx context.Context, username string) (*User, error)
+	GetByID(ctx context.Context, id int) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	Update(ctx context.Context, u *User) error
+	UpdatePassword(ctx context.Context, id int, hash string) error
+	// ReplacePasswordHash меняет хеш, только если в базе все еще oldHash;
+	// false — пароль успели сменить, и новый хеш не записан
+	ReplacePasswordHash(ctx context.Context, id int, oldHash, newHash string) (bool, error)
+	MarkEmailVerified(ctx context.Context, id int) error
+	// SetTOTPSecret сохраняет новый секрет и выключает 2FA до подтверждения кодом
+	SetTOTPSecret(ctx context.Context, id int, secret string) error
+	EnableTOTP(ctx context.Context, id int) error
+	// UseTOTPStep запоминает шаг принятого TOTP-кода; false — код этого или
+	// более позднего шага уже принимали, и текущий код — повтор
+	UseTOTPStep(ctx context.Context, id int, step int64) (bool, error)
+	Delete(ctx context.Context, id int) error
+	List(ctx context.Context, f UserFilter) ([]*User, error)
+	// SetDisabled и SetRole возвращают sql.ErrNoRows, если пользователя нет
+	SetDisabled(ctx context.Context, id int, disabled bool) error
+	SetRole(ctx context.Context, id int, role string) error
 }
 
 type UserUsecase interface {
-	Register(username, password string) (*User, error)
-	Authenticate(username, password string) (*User, error)
-	GetProfile(id int) (*User, error)
-	UpdateProfile(id int, username string) (*User, error)
+	Register(ctx context.Context, username, password, email string) (*User, error)
+	// Authenticate проверяет пароль и, если включена 2FA, одноразовый код otp.
+	// Без кода возвращает TOTPRequiredError с токеном для VerifyTOTP.
+	Authenticate(ctx context.Context, username, password, otp, ip string) (*User, error)
+	GetProfile(ctx context.Context, id int) (*User, error)
+	// UpdateProfile при смене пароля завершает остальные сессии пользователя
+	// и возвращает их ID
+	UpdateProfile(ctx context.Context, id int, upd ProfileUpdate) (*User, []int, error)
+	UnlockUser(ctx context.Context, username string) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	VerifyEmail(ctx context.Context, token string) error
+	EnrollTOTP(ctx context.Context, userID int) (secret, uri string, err error)
+	ConfirmTOTP(ctx context.Context, userID int, code string) (recoveryCodes []string, err error)
+	VerifyTOTP(ctx context.Context, challengeToken, code, ip string) (*User, error)
+	CreateSession(ctx context.Context, userID int, device, ip, userAgent string) (token string, s *Session, err error)
+	ValidateSession(ctx context.Context, token string) (*Session, *User, error)
+	ListSessions(ctx context.Context, userID int) ([]*Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID int) error
+	RevokeAllSessions(ctx context.Context, userID, exceptSessionID int) ([]int, error)
+	// ListUsers возвращает страницу пользователей и ID, после которого начинается
+	// следующая страница (0 — страница последняя)
+	ListUsers(ctx context.Context, f UserFilter) (users []*User, nextAfterID int, err error)
+	DisableUser(ctx context.Context, id int) (*User, error)
+	EnableUser(ctx context.Context, id int) (*User, error)
+	SetUserRole(ctx context.Context, id int, role string) (*User, error)
 }
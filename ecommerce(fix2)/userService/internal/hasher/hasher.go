@@ -0,0 +1,61 @@
+// Package hasher реализует domain.PasswordHasher для bcrypt и argon2id.
+package hasher
+
+import (
+	"errors"
+	"userService/internal/domain"
+)
+
+// ErrUnknownAlgorithm возвращается для хеша, который не распознал ни один алгоритм
+var ErrUnknownAlgorithm = errors.New("unknown password hash algorithm")
+
+// Algorithm — хеширование одним алгоритмом с конкретными параметрами
+type Algorithm interface {
+	domain.PasswordHasher
+	// Supports сообщает, сделан ли хеш этим алгоритмом (с любыми параметрами)
+	Supports(hash string) bool
+}
+
+// Chain хеширует пароли основным алгоритмом и проверяет хеши любого из
+// известных. Хеш другого алгоритма всегда требует пересчета.
+type Chain struct {
+	primary Algorithm
+	others  []Algorithm
+}
+
+// NewChain создает хешер с основным алгоритмом primary; others нужны, чтобы
+// проверять хеши, сделанные до смены алгоритма
+func NewChain(primary Algorithm, others ...Algorithm) *Chain {
+	return &Chain{primary: primary, others: others}
+}
+
+func (c *Chain) Hash(password string) (string, error) {
+	return c.primary.Hash(password)
+}
+
+func (c *Chain) Verify(hash, password string) (bool, error) {
+	alg := c.algorithmFor(hash)
+	if alg == nil {
+		return false, ErrUnknownAlgorithm
+	}
+	return alg.Verify(hash, password)
+}
+
+func (c *Chain) NeedsRehash(hash string) bool {
+	if !c.primary.Supports(hash) {
+		return true
+	}
+	return c.primary.NeedsRehash(hash)
+}
+
+func (c *Chain) algorithmFor(hash string) Algorithm {
+	if c.primary.Supports(hash) {
+		return c.primary
+	}
+	for _, alg := range c.others {
+		if alg.Supports(hash) {
+			return alg
+		}
+	}
+	return nil
+}
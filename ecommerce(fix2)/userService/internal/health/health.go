@@ -0,0 +1,132 @@
+// Package health отдает состояние сервиса по стандартному протоколу
+// grpc.health.v1. Зависимости проверяются в фоне, и у каждой свое имя в
+// протоколе (например "postgres"); сервис в целом — "" и полное имя
+// gRPC-сервиса — SERVING, только если проходят все проверки.
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	checkInterval = 5 * time.Second
+	checkTimeout  = 2 * time.Second
+)
+
+// Check проверяет одну зависимость; nil — зависимость доступна
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+	// failing — последняя проверка не прошла; нужен, чтобы писать в лог
+	// только смену состояния, а не каждую неудачу
+	failing bool
+}
+
+// Checker периодически проверяет зависимости и публикует результат через
+// health.Server
+type Checker struct {
+	server  *grpchealth.Server
+	service string
+	checks  []*namedCheck
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewChecker создает проверку для gRPC-сервиса с полным именем service,
+// например user.UserService. Пока Start не вызван, сервис NOT_SERVING.
+func NewChecker(service string) *Checker {
+	c := &Checker{
+		server:  grpchealth.NewServer(),
+		service: service,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	c.setOverall(healthpb.HealthCheckResponse_NOT_SERVING)
+	return c
+}
+
+// Add добавляет проверку зависимости; вызывается до Start
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, &namedCheck{name: name, check: check})
+	c.server.SetServingStatus(name, healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+// Register регистрирует grpc.health.v1 на сервере
+func (c *Checker) Register(s *grpc.Server) {
+	healthpb.RegisterHealthServer(s, c.server)
+}
+
+// Start выполняет первую проверку сразу, затем повторяет ее каждые checkInterval
+func (c *Checker) Start() {
+	c.run()
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.run()
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Shutdown переводит сервис и все зависимости в NOT_SERVING до конца работы
+// процесса. Это шаг остановки: он выполняется раньше GracefulStop, чтобы
+// клиенты, которые следят за состоянием, перестали отправлять запросы.
+func (c *Checker) Shutdown(ctx context.Context) error {
+	c.stopOnce.Do(func() { close(c.stop) })
+	c.server.Shutdown()
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+func (c *Checker) run() {
+	serving := true
+	for _, nc := range c.checks {
+		ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+		err := nc.check(ctx)
+		cancel()
+
+		status := healthpb.HealthCheckResponse_SERVING
+		if err != nil {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+			serving = false
+			if !nc.failing {
+				slog.Warn("health check failed", "check", nc.name, "error", err)
+			}
+		} else if nc.failing {
+			slog.Info("health check recovered", "check", nc.name)
+		}
+		nc.failing = err != nil
+		c.server.SetServingStatus(nc.name, status)
+	}
+
+	if serving {
+		c.setOverall(healthpb.HealthCheckResponse_SERVING)
+	} else {
+		c.setOverall(healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+}
+
+func (c *Checker) setOverall(status healthpb.HealthCheckResponse_ServingStatus) {
+	c.server.SetServingStatus("", status)
+	c.server.SetServingStatus(c.service, status)
+}
@@ -0,0 +1,56 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"buf.build/go/protovalidate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// UnaryValidate проверяет запрос по правилам buf.validate из .proto до вызова
+// обработчика; правила вычисляет protovalidate, включая CEL. Нарушения
+// возвращаются как InvalidArgument с деталями BadRequest, которые gateway
+// превращает в ответ 422 с полем "fields".
+func UnaryValidate() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return handler(ctx, req)
+		}
+		err := protovalidate.Validate(msg)
+		var verr *protovalidate.ValidationError
+		if errors.As(err, &verr) {
+			return nil, violationStatus(verr.Violations)
+		}
+		if err != nil {
+			// Ошибка в самих правилах — это ошибка сервиса, а не клиента
+			slog.ErrorContext(ctx, "invalid validation rules", "method", info.FullMethod, "error", err)
+			return nil, status.Error(codes.Internal, "internal error")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// violationStatus собирает нарушения в BadRequest; field — путь к полю вида
+// items[0].quantity
+func violationStatus(violations []*protovalidate.Violation) error {
+	br := &errdetails.BadRequest{}
+	for _, v := range violations {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       protovalidate.FieldPathString(v.Proto.GetField()),
+			Description: v.Proto.GetMessage(),
+		})
+	}
+	st, err := status.New(codes.InvalidArgument, "validation failed").WithDetails(br)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "validation failed: %s: %s",
+			br.FieldViolations[0].Field, br.FieldViolations[0].Description)
+	}
+	return st.Err()
+}
@@ -0,0 +1,29 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+)
+
+// GracefulStopGRPC — шаг остановки gRPC-сервера: новые соединения и RPC не
+// принимаются, текущие дорабатывают. Если срок истек раньше, оставшиеся RPC
+// обрываются.
+func GracefulStopGRPC(server *grpc.Server) Hook {
+	return func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			server.Stop()
+			return fmt.Errorf("in-flight RPCs aborted: %w", ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Package lifecycle останавливает сервис по SIGINT/SIGTERM: сервер перестает
+// принимать новую работу и дожидается текущей, затем закрываются соединения —
+// все в пределах общего срока из shutdown.timeout.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Hook — шаг остановки. ctx истекает вместе с общим сроком остановки; шаг,
+// который не успел, должен прервать работу и вернуть ошибку.
+type Hook func(ctx context.Context) error
+
+type step struct {
+	name string
+	hook Hook
+}
+
+// Manager запускает сервер и останавливает сервис по сигналу
+type Manager struct {
+	timeout time.Duration
+	steps   []step
+}
+
+func New(timeout time.Duration) *Manager {
+	return &Manager{timeout: timeout}
+}
+
+// OnShutdown регистрирует шаг остановки. Шаги выполняются в обратном порядке,
+// как defer: ресурс, открытый последним, закрывается первым. Поэтому
+// регистрировать их удобно сразу после открытия соответствующего ресурса.
+func (m *Manager) OnShutdown(name string, hook Hook) {
+	m.steps = append(m.steps, step{name: name, hook: hook})
+}
+
+// Run запускает serve (например grpcServer.Serve) и ждет SIGINT/SIGTERM или
+// завершения serve, после чего выполняет шаги остановки. Повторный сигнал во
+// время остановки завершает процесс сразу.
+func (m *Manager) Run(serve func() error) error {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- serve()
+	}()
+
+	var serveErr error
+	select {
+	case sig := <-signals:
+		slog.Info("shutting down", "signal", sig.String(), "timeout", m.timeout)
+	case serveErr = <-served:
+		slog.Error("server stopped unexpectedly", "error", serveErr)
+	}
+
+	go func() {
+		sig := <-signals
+		slog.Warn("received second signal, exiting immediately", "signal", sig.String())
+		os.Exit(1)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+	return errors.Join(serveErr, m.shutdown(ctx))
+}
+
+// shutdown выполняет все шаги, даже если срок уже истек: закрыть пулы и
+// соединения все равно нужно, просто без ожидания текущей работы
+func (m *Manager) shutdown(ctx context.Context) error {
+	var errs []error
+	for i := len(m.steps) - 1; i >= 0; i-- {
+		s := m.steps[i]
+		start := time.Now()
+		if err := s.hook(ctx); err != nil {
+			slog.Error("shutdown step failed", "step", s.name, "error", err)
+			errs = append(errs, fmt.Errorf("stop %s: %w", s.name, err))
+			continue
+		}
+		slog.Info("shutdown step done", "step", s.name, "duration", time.Since(start).Round(time.Millisecond))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,24 @@
+package logging
+
+import (
+	"context"
+
+	"github.com/streadway/amqp"
+)
+
+// InjectHeaders кладет request id из ctx в заголовки исходящего сообщения
+func InjectHeaders(ctx context.Context, headers amqp.Table) {
+	if id := RequestID(ctx); id != "" {
+		headers[RequestIDKey] = id
+	}
+}
+
+// ContextFromHeaders возвращает ctx с request id из заголовков сообщения.
+// Сообщения без него (или с некорректным) получают новый.
+func ContextFromHeaders(ctx context.Context, headers amqp.Table) context.Context {
+	id, _ := headers[RequestIDKey].(string)
+	if !ValidRequestID(id) {
+		id = NewRequestID()
+	}
+	return WithRequestID(ctx, id)
+}
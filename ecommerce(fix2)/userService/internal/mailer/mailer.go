@@ -0,0 +1,15 @@
+package mailer
+
+import "context"
+
+// Message — письмо в виде обычного текста
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer отправляет письма пользователям
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
@@ -0,0 +1,54 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileOutbox сохраняет письма в каталог вместо отправки — для локальной разработки
+type FileOutbox struct {
+	dir string
+}
+
+func NewFileOutbox(dir string) (*FileOutbox, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create outbox dir: %w", err)
+	}
+	return &FileOutbox{dir: dir}, nil
+}
+
+func (o *FileOutbox) Send(ctx context.Context, msg Message) error {
+	name := fmt.Sprintf("%s-%s.txt", time.Now().Format("20060102-150405.000000000"), filepath.Base(msg.To))
+	content := fmt.Sprintf("To: %s\nSubject: %s\n\n%s\n", msg.To, msg.Subject, msg.Body)
+	return os.WriteFile(filepath.Join(o.dir, name), []byte(content), 0o600)
+}
+
+// MemoryOutbox хранит отправленные письма в памяти — для тестов
+type MemoryOutbox struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryOutbox() *MemoryOutbox {
+	return &MemoryOutbox{}
+}
+
+func (o *MemoryOutbox) Send(ctx context.Context, msg Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// Messages возвращает копию всех отправленных писем
+func (o *MemoryOutbox) Messages() []Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return append([]Message(nil), o.messages...)
+}
@@ -0,0 +1,37 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"userService/internal/domain"
+)
+
+// PrivacyConsumer собирает ответы сервисов на запросы выгрузки и удаления данных
+type PrivacyConsumer struct {
+	privacy      domain.PrivacyUsecase
+	rabbitClient *RabbitMQClient
+}
+
+// NewPrivacyConsumer создает новый обработчик ответов сервисов
+func NewPrivacyConsumer(uc domain.PrivacyUsecase, rabbitClient *RabbitMQClient) *PrivacyConsumer {
+	return &PrivacyConsumer{
+		privacy:      uc,
+		rabbitClient: rabbitClient,
+	}
+}
+
+// Start запускает потребителя сообщений
+func (c *PrivacyConsumer) Start() error {
+	return c.rabbitClient.ConsumePrivacyParts(c.handlePart)
+}
+
+func (c *PrivacyConsumer) handlePart(ctx context.Context, payload PrivacyPartPayload) error {
+	slog.InfoContext(ctx, "received privacy part", "service", payload.Service, "privacy_request_id", payload.RequestID)
+	err := c.privacy.CompletePart(ctx, payload.RequestID, payload.Service, payload.Data)
+	if errors.Is(err, domain.ErrPrivacyRequestNotFound) {
+		slog.WarnContext(ctx, "unknown privacy request, skipping", "privacy_request_id", payload.RequestID)
+		return nil
+	}
+	return err
+}
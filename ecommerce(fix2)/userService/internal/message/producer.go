@@ -0,0 +1,48 @@
+package message
+
+import (
+	"context"
+	"time"
+)
+
+// MessageProducer отправляет события пользователей
+type MessageProducer struct {
+	rabbitClient *RabbitMQClient
+}
+
+// NewMessageProducer создаёт нового продюсера сообщений
+func NewMessageProducer(rabbitClient *RabbitMQClient) *MessageProducer {
+	return &MessageProducer{
+		rabbitClient: rabbitClient,
+	}
+}
+
+// PublishUserLocked публикует событие о блокировке входа по имени пользователя или IP
+func (p *MessageProducer) PublishUserLocked(ctx context.Context, username, ip string, lockedUntil time.Time, lockCount int) error {
+	return p.rabbitClient.Publish(ctx, "user.locked", UserLockedPayload{
+		Username:    username,
+		IP:          ip,
+		LockedUntil: lockedUntil,
+		LockCount:   lockCount,
+		Timestamp:   time.Now(),
+	})
+}
+
+// PublishExportRequested просит сервисы прислать данные пользователя для выгрузки
+func (p *MessageProducer) PublishExportRequested(ctx context.Context, requestID, userID int) error {
+	return p.rabbitClient.Publish(ctx, "user.export_requested", PrivacyRequestPayload{
+		RequestID: requestID,
+		UserID:    userID,
+		Timestamp: time.Now(),
+	})
+}
+
+// PublishDeletionRequested просит сервисы обезличить данные удаленного пользователя
+func (p *MessageProducer) PublishDeletionRequested(ctx context.Context, requestID, userID int, sessionIDs []int) error {
+	return p.rabbitClient.Publish(ctx, "user.deletion_requested", PrivacyRequestPayload{
+		RequestID:  requestID,
+		UserID:     userID,
+		SessionIDs: sessionIDs,
+		Timestamp:  time.Now(),
+	})
+}
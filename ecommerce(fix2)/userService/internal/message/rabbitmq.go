@@ -0,0 +1,255 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+	"userService/internal/logging"
+	"userService/internal/metrics"
+	"userService/internal/tracing"
+
+	"github.com/streadway/amqp"
+)
+
+// RabbitMQClient представляет клиент для работы с RabbitMQ
+type RabbitMQClient struct {
+	conn    *amqp.Connection
+	channel *amqp.Channel
+
+	// Потребители, которых отменяет StopConsumers, и их обработчики
+	mu        sync.Mutex
+	consumers []string
+	handlers  sync.WaitGroup
+	queues    []string // очереди потребителей, для метрик глубины очередей
+}
+
+// UserLockedPayload представляет событие блокировки входа
+type UserLockedPayload struct {
+	Username    string    `json:"username"`
+	IP          string    `json:"ip,omitempty"`
+	LockedUntil time.Time `json:"locked_until"`
+	LockCount   int       `json:"lock_count"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// PrivacyRequestPayload — запрос на выгрузку или удаление данных пользователя,
+// который обрабатывает каждый сервис, хранящий его данные
+type PrivacyRequestPayload struct {
+	RequestID  int       `json:"request_id"`
+	UserID     int       `json:"user_id"`
+	SessionIDs []int     `json:"session_ids,omitempty"` // отозванные сессии удаленного пользователя
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// PrivacyPartPayload — ответ сервиса на запрос: его данные для выгрузки
+// или подтверждение, что данные обезличены
+type PrivacyPartPayload struct {
+	RequestID int             `json:"request_id"`
+	Service   string          `json:"service"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// NewRabbitMQClient создаёт новый клиент RabbitMQ
+func NewRabbitMQClient(url string) (*RabbitMQClient, error) {
+	// Подключение к RabbitMQ
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+
+	// Создание канала
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open a channel: %w", err)
+	}
+
+	// Создание exchange для событий пользователей
+	err = channel.ExchangeDeclare(
+		"user_events", // name
+		"direct",      // type
+		true,          // durable
+		false,         // auto-deleted
+		false,         // internal
+		false,         // no-wait
+		nil,           // arguments
+	)
+	if err != nil {
+		channel.Close()
+		conn.Close()
+		return nil, fmt.Errorf("failed to declare an exchange: %w", err)
+	}
+
+	return &RabbitMQClient{
+		conn:    conn,
+		channel: channel,
+	}, nil
+}
+
+// Publish публикует событие в exchange user_events. В заголовках сообщения —
+// контекст трассировки и request id из ctx, по ним потребители продолжают
+// трассу запроса, вызвавшего событие.
+func (c *RabbitMQClient) Publish(ctx context.Context, routingKey string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	span, headers := tracing.StartPublish(ctx, "user_events", routingKey)
+	logging.InjectHeaders(ctx, headers)
+	err = c.channel.Publish(
+		"user_events", // exchange
+		routingKey,    // routing key
+		false,         // mandatory
+		false,         // immediate
+		amqp.Publishing{
+			Headers:      headers,
+			ContentType:  "application/json",
+			Body:         body,
+			DeliveryMode: amqp.Persistent,
+			Timestamp:    time.Now(),
+		},
+	)
+	tracing.End(span, err)
+	metrics.Published("user_events", routingKey, err)
+	if err != nil {
+		return fmt.Errorf("failed to publish a message: %w", err)
+	}
+
+	slog.InfoContext(ctx, "published event", "routing_key", routingKey)
+	return nil
+}
+
+// ConsumePrivacyParts потребляет ответы сервисов на запросы выгрузки и удаления
+// данных. handler получает контекст со спаном обработки, продолжающим трассу
+// отправителя.
+func (c *RabbitMQClient) ConsumePrivacyParts(handler func(context.Context, PrivacyPartPayload) error) error {
+	queue, err := c.channel.QueueDeclare(
+		"user_privacy_parts", // name
+		true,                 // durable
+		false,                // delete when unused
+		false,                // exclusive
+		false,                // no-wait
+		nil,                  // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare a queue: %w", err)
+	}
+
+	err = c.channel.QueueBind(
+		queue.Name,          // queue name
+		"user.privacy_part", // routing key
+		"user_events",       // exchange
+		false,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to bind queue: %w", err)
+	}
+
+	msgs, err := c.channel.Consume(
+		queue.Name,                // queue
+		c.consumerTag(queue.Name), // consumer
+		false,                     // auto-ack
+		false,                     // exclusive
+		false,                     // no-local
+		false,                     // no-wait
+		nil,                       // args
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register a consumer: %w", err)
+	}
+
+	c.handlers.Add(1)
+	go func() {
+		defer c.handlers.Done()
+		for d := range msgs {
+			metrics.Consumed(queue.Name, d.Timestamp)
+			ctx, span := tracing.StartConsume(queue.Name, d)
+			ctx = logging.ContextFromHeaders(ctx, d.Headers)
+			var payload PrivacyPartPayload
+			if err := json.Unmarshal(d.Body, &payload); err != nil {
+				slog.ErrorContext(ctx, "failed to parse privacy part", "error", err)
+				nack(queue.Name, d, false) // Некорректное сообщение, повтор не поможет
+				tracing.End(span, err)
+				continue
+			}
+
+			if err := handler(ctx, payload); err != nil {
+				slog.ErrorContext(ctx, "failed to handle privacy part", "privacy_request_id", payload.RequestID, "service", payload.Service, "error", err)
+				nack(queue.Name, d, true)
+				tracing.End(span, err)
+				continue
+			}
+
+			ack(queue.Name, d)
+			tracing.End(span, nil)
+		}
+	}()
+
+	slog.Info("waiting for privacy request parts", "queue", queue.Name)
+	return nil
+}
+
+// Ping сообщает, живо ли соединение с брокером; сигнатура подходит для health-проверок
+func (c *RabbitMQClient) Ping(context.Context) error {
+	if c.conn.IsClosed() {
+		return errors.New("connection is closed")
+	}
+	return nil
+}
+
+// consumerTag возвращает уникальный тег потребителя и запоминает его для StopConsumers
+func (c *RabbitMQClient) consumerTag(queue string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tag := fmt.Sprintf("%s-%d-%d", queue, os.Getpid(), len(c.consumers)+1)
+	c.consumers = append(c.consumers, tag)
+	c.queues = append(c.queues, queue)
+	return tag
+}
+
+// StopConsumers отменяет потребителей: брокер перестает присылать сообщения,
+// а полученные, но еще не подтвержденные вернутся в очередь при закрытии
+// канала. Затем ждет, пока обработчики закончат текущие сообщения, но не
+// дольше ctx.
+func (c *RabbitMQClient) StopConsumers(ctx context.Context) error {
+	c.mu.Lock()
+	tags := c.consumers
+	c.consumers = nil
+	c.mu.Unlock()
+
+	for _, tag := range tags {
+		if err := c.channel.Cancel(tag, false); err != nil {
+			slog.Warn("failed to cancel consumer", "consumer", tag, "error", err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.handlers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("consumers did not finish: %w", ctx.Err())
+	}
+}
+
+// Close закрывает соединение с RabbitMQ
+func (c *RabbitMQClient) Close() {
+	if c.channel != nil {
+		c.channel.Close()
+	}
+	if c.conn != nil {
+		c.conn.Close()
+	}
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"errors"
+	"userService/internal/domain"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	logins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "user_logins_total",
+		Help: "Password logins by result: success, invalid_credentials, locked, disabled, second_factor_required or error.",
+	}, []string{"result"})
+
+	// Registrations — учетные записи, созданные через регистрацию
+	Registrations = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "user_registrations_total",
+		Help: "Accounts created through registration.",
+	})
+)
+
+// Login учитывает попытку входа по паролю с ее результатом
+func Login(err error) {
+	logins.WithLabelValues(loginResult(err)).Inc()
+}
+
+func loginResult(err error) string {
+	var locked *domain.AccountLockedError
+	var totp *domain.TOTPRequiredError
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		return "invalid_credentials"
+	case errors.As(err, &locked):
+		return "locked"
+	case errors.Is(err, domain.ErrUserDisabled):
+		return "disabled"
+	case errors.As(err, &totp):
+		return "second_factor_required"
+	default:
+		return "error"
+	}
+}
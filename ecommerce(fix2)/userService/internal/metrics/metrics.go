@@ -0,0 +1,36 @@
+// Package metrics собирает метрики Prometheus: gRPC, RabbitMQ, пул
+// соединений с БД и бизнес-счетчики сервиса. Метрики регистрируются в
+// реестре по умолчанию, вместе с метриками рантайма Go и процесса.
+package metrics
+
+import (
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Serve запускает в фоне HTTP-сервер с /metrics. Сервер отдельный от
+// основного, чтобы метрики не были доступны снаружи вместе с API.
+func Serve(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("metrics server stopped", "error", err)
+		}
+	}()
+	slog.Info("serving /metrics", "addr", addr)
+	return srv
+}
+
+// RegisterDB экспортирует статистику пула соединений (sql.DB.Stats) как go_sql_*
+func RegisterDB(db *sql.DB, name string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
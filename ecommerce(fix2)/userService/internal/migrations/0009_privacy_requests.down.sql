@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS privacy_request_parts;
+DROP TABLE IF EXISTS privacy_requests;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS privacy_requests (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    kind TEXT NOT NULL,
+    status TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS privacy_request_parts (
+    request_id INT NOT NULL REFERENCES privacy_requests(id) ON DELETE CASCADE,
+    service TEXT NOT NULL,
+    data JSONB NOT NULL DEFAULT 'null',
+    received_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (request_id, service)
+);
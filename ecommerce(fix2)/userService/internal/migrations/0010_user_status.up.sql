@@ -0,0 +1,5 @@
+-- У пользователей, созданных до этой миграции, время регистрации неизвестно,
+-- поэтому они получают время ее применения
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP;
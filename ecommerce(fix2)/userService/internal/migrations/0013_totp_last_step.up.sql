@@ -0,0 +1,3 @@
+-- Шаг последнего принятого TOTP-кода: код того же или более раннего шага
+-- повторно не принимается (RFC 6238, 5.2)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
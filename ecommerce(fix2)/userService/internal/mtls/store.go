@@ -0,0 +1,122 @@
+// Package mtls — взаимный TLS между gateway и сервисами. Сертификат, ключ и
+// CA читаются из файлов и перечитываются, когда файлы меняются, поэтому
+// выпуск новых сертификатов не требует перезапуска.
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Store хранит текущие сертификат сервиса и пул CA
+type Store struct {
+	certFile, keyFile, caFile string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	modTime time.Time // самое позднее время изменения из трех файлов
+}
+
+// Load читает сертификат, ключ и CA; ошибка здесь — ошибка настройки
+func Load(certFile, keyFile, caFile string) (*Store, error) {
+	s := &Store{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	if _, err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) current() (*tls.Certificate, *x509.CertPool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cert, s.pool
+}
+
+// reload перечитывает файлы, если они изменились. Пока новая пара
+// сертификат-ключ не сложилась (файлы заменяются по одному), остается
+// прежняя, и попытка повторяется на следующей проверке.
+func (s *Store) reload() (bool, error) {
+	modTime, err := latestModTime(s.certFile, s.keyFile, s.caFile)
+	if err != nil {
+		return false, err
+	}
+	s.mu.RLock()
+	unchanged := s.cert != nil && modTime.Equal(s.modTime)
+	s.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("load TLS certificate: %w", err)
+	}
+	caPEM, err := os.ReadFile(s.caFile)
+	if err != nil {
+		return false, fmt.Errorf("read TLS CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return false, errors.New("read TLS CA: no certificates in " + s.caFile)
+	}
+
+	s.mu.Lock()
+	s.cert, s.pool, s.modTime = &cert, pool, modTime
+	s.mu.Unlock()
+	return true, nil
+}
+
+// Watch проверяет файлы раз в interval и подхватывает новые сертификаты.
+// Возвращает функцию остановки для lifecycle.
+func (s *Store) Watch(interval time.Duration) func(context.Context) error {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			changed, err := s.reload()
+			if err != nil {
+				slog.Warn("failed to reload TLS certificates, keeping the current ones", "error", err)
+				continue
+			}
+			if changed {
+				cert, _ := s.current()
+				slog.Info("reloaded TLS certificates", "subject", cert.Leaf.Subject.CommonName, "not_after", cert.Leaf.NotAfter)
+			}
+		}
+	}()
+	return func(context.Context) error {
+		close(done)
+		<-stopped
+		return nil
+	}
+}
+
+func latestModTime(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("TLS: %w", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
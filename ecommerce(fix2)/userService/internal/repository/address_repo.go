@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"userService/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type addressRepo struct {
+	db *sqlx.DB
+}
+
+func NewAddressRepo(db *sqlx.DB) domain.AddressRepository {
+	return &addressRepo{db}
+}
+
+func (r *addressRepo) Create(ctx context.Context, a *domain.Address) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if a.IsDefault {
+		if _, err := tx.ExecContext(ctx, `UPDATE addresses SET is_default=FALSE WHERE user_id=$1 AND is_default`, a.UserID); err != nil {
+			return err
+		}
+	}
+	query := `INSERT INTO addresses (user_id, full_name, line1, line2, city, region, postal_code, country, phone, is_default)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, created_at`
+	err = tx.QueryRowContext(ctx, query, a.UserID, a.FullName, a.Line1, a.Line2, a.City, a.Region, a.PostalCode, a.Country, a.Phone, a.IsDefault).
+		Scan(&a.ID, &a.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *addressRepo) GetByID(ctx context.Context, userID, id int) (*domain.Address, error) {
+	var a domain.Address
+	err := r.db.GetContext(ctx, &a, `SELECT * FROM addresses WHERE id=$1 AND user_id=$2`, id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrAddressNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *addressRepo) List(ctx context.Context, userID int) ([]*domain.Address, error) {
+	var addresses []*domain.Address
+	err := r.db.SelectContext(ctx, &addresses, `SELECT * FROM addresses WHERE user_id=$1 ORDER BY is_default DESC, created_at DESC`, userID)
+	return addresses, err
+}
+
+func (r *addressRepo) Count(ctx context.Context, userID int) (int, error) {
+	var n int
+	err := r.db.GetContext(ctx, &n, `SELECT COUNT(*) FROM addresses WHERE user_id=$1`, userID)
+	return n, err
+}
+
+func (r *addressRepo) Update(ctx context.Context, a *domain.Address) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if a.IsDefault {
+		if _, err := tx.ExecContext(ctx, `UPDATE addresses SET is_default=FALSE WHERE user_id=$1 AND id<>$2 AND is_default`, a.UserID, a.ID); err != nil {
+			return err
+		}
+	}
+	query := `UPDATE addresses SET full_name=$1, line1=$2, line2=$3, city=$4, region=$5, postal_code=$6, country=$7, phone=$8, is_default=$9
+			  WHERE id=$10 AND user_id=$11`
+	res, err := tx.ExecContext(ctx, query, a.FullName, a.Line1, a.Line2, a.City, a.Region, a.PostalCode, a.Country, a.Phone, a.IsDefault, a.ID, a.UserID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return domain.ErrAddressNotFound
+	}
+	return tx.Commit()
+}
+
+func (r *addressRepo) Delete(ctx context.Context, userID, id int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var wasDefault bool
+	err = tx.QueryRowContext(ctx, `DELETE FROM addresses WHERE id=$1 AND user_id=$2 RETURNING is_default`, id, userID).Scan(&wasDefault)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrAddressNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if wasDefault {
+		_, err := tx.ExecContext(ctx, `UPDATE addresses SET is_default=TRUE WHERE id = (
+				  SELECT id FROM addresses WHERE user_id=$1 ORDER BY created_at DESC LIMIT 1)`, userID)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *addressRepo) SetDefault(ctx context.Context, userID, id int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE addresses SET is_default=FALSE WHERE user_id=$1 AND id<>$2 AND is_default`, userID, id); err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, `UPDATE addresses SET is_default=TRUE WHERE id=$1 AND user_id=$2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return domain.ErrAddressNotFound
+	}
+	return tx.Commit()
+}
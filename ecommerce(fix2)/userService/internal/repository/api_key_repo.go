@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"userService/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// apiKeyRow — строка api_keys; scopes хранятся массивом TEXT[]
+type apiKeyRow struct {
+	domain.APIKey
+	Scopes pq.StringArray `db:"scopes"`
+}
+
+func (row *apiKeyRow) key() *domain.APIKey {
+	k := row.APIKey
+	k.Scopes = []string(row.Scopes)
+	return &k
+}
+
+// activeAPIKey — условие для действующих ключей
+const activeAPIKey = `revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())`
+
+type apiKeyRepo struct {
+	db *sqlx.DB
+}
+
+func NewAPIKeyRepo(db *sqlx.DB) domain.APIKeyRepository {
+	return &apiKeyRepo{db}
+}
+
+func (r *apiKeyRepo) Create(ctx context.Context, k *domain.APIKey) error {
+	query := `INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+			  VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`
+	return r.db.QueryRowContext(ctx, query, k.UserID, k.Name, k.Prefix, k.KeyHash, pq.StringArray(k.Scopes), k.ExpiresAt).
+		Scan(&k.ID, &k.CreatedAt)
+}
+
+func (r *apiKeyRepo) GetActiveByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	return r.getActive(ctx, `SELECT * FROM api_keys WHERE key_hash=$1 AND `+activeAPIKey, keyHash)
+}
+
+func (r *apiKeyRepo) GetActive(ctx context.Context, userID, id int) (*domain.APIKey, error) {
+	return r.getActive(ctx, `SELECT * FROM api_keys WHERE id=$1 AND user_id=$2 AND `+activeAPIKey, id, userID)
+}
+
+func (r *apiKeyRepo) getActive(ctx context.Context, query string, args ...interface{}) (*domain.APIKey, error) {
+	var row apiKeyRow
+	err := r.db.GetContext(ctx, &row, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return row.key(), nil
+}
+
+func (r *apiKeyRepo) ListActive(ctx context.Context, userID int) ([]*domain.APIKey, error) {
+	var rows []apiKeyRow
+	err := r.db.SelectContext(ctx, &rows, `SELECT * FROM api_keys WHERE user_id=$1 AND `+activeAPIKey+` ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]*domain.APIKey, 0, len(rows))
+	for i := range rows {
+		keys = append(keys, rows[i].key())
+	}
+	return keys, nil
+}
+
+func (r *apiKeyRepo) CountActive(ctx context.Context, userID int) (int, error) {
+	var n int
+	err := r.db.GetContext(ctx, &n, `SELECT COUNT(*) FROM api_keys WHERE user_id=$1 AND `+activeAPIKey, userID)
+	return n, err
+}
+
+// Touch обновляет last_used_at не чаще раза в минуту, чтобы частые запросы
+// по ключу не превращались в такие же частые записи в базу
+func (r *apiKeyRepo) Touch(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at=NOW()
+			  WHERE id=$1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`, id)
+	return err
+}
+
+func (r *apiKeyRepo) Revoke(ctx context.Context, userID, id int) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE api_keys SET revoked_at=NOW() WHERE id=$1 AND user_id=$2 AND `+activeAPIKey, id, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *apiKeyRepo) ExpireAt(ctx context.Context, id int, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE api_keys SET expires_at=$1 WHERE id=$2 AND (expires_at IS NULL OR expires_at > $1)`, at, id)
+	return err
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"userService/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type identityRepo struct {
+	db *sqlx.DB
+}
+
+func NewIdentityRepo(db *sqlx.DB) domain.IdentityRepository {
+	return &identityRepo{db}
+}
+
+func (r *identityRepo) Get(ctx context.Context, issuer, subject string) (*domain.Identity, error) {
+	var i domain.Identity
+	err := r.db.GetContext(ctx, &i, `SELECT * FROM user_identities WHERE issuer=$1 AND subject=$2`, issuer, subject)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrIdentityNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+func (r *identityRepo) Create(ctx context.Context, i *domain.Identity) error {
+	query := `INSERT INTO user_identities (issuer, subject, user_id, email)
+			  VALUES ($1, $2, $3, $4) RETURNING created_at, last_login_at`
+	err := r.db.QueryRowContext(ctx, query, i.Issuer, i.Subject, i.UserID, i.Email).Scan(&i.CreatedAt, &i.LastLoginAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return domain.ErrIdentityExists
+	}
+	return err
+}
+
+func (r *identityRepo) Touch(ctx context.Context, issuer, subject, email string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE user_identities SET last_login_at=NOW(), email=$1 WHERE issuer=$2 AND subject=$3`,
+		email, issuer, subject)
+	return err
+}
+
+func (r *identityRepo) ListByUser(ctx context.Context, userID int) ([]*domain.Identity, error) {
+	var identities []*domain.Identity
+	err := r.db.SelectContext(ctx, &identities, `SELECT * FROM user_identities WHERE user_id=$1 ORDER BY created_at`, userID)
+	return identities, err
+}
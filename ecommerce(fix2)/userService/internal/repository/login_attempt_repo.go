@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"userService/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type loginAttemptRepo struct {
+	db *sqlx.DB
+}
+
+func NewLoginAttemptRepo(db *sqlx.DB) domain.LoginAttemptRepository {
+	return &loginAttemptRepo{db}
+}
+
+func (r *loginAttemptRepo) Get(ctx context.Context, kind, key string) (*domain.LoginAttempt, error) {
+	var a domain.LoginAttempt
+	err := r.db.GetContext(ctx, &a, `SELECT * FROM login_attempts WHERE kind=$1 AND key=$2`, kind, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// RecordFailure считает неудачу в самой базе: при чтении и записи из кода
+// параллельные попытки перезаписывали бы счетчик друг друга, и перебор
+// в несколько потоков получал бы больше попыток, чем позволяет порог
+func (r *loginAttemptRepo) RecordFailure(ctx context.Context, kind, key string, at, failuresSince, lockCountSince time.Time) (*domain.LoginAttempt, error) {
+	query := `INSERT INTO login_attempts AS a (kind, key, failures, lock_count, last_failure_at)
+			  VALUES ($1, $2, 1, 0, $3)
+			  ON CONFLICT (kind, key) DO UPDATE
+			  SET failures = CASE WHEN a.last_failure_at < $4 THEN 1 ELSE a.failures + 1 END,
+			      lock_count = CASE WHEN a.last_failure_at < $5 THEN 0 ELSE a.lock_count END,
+			      last_failure_at = $3
+			  RETURNING *`
+	var a domain.LoginAttempt
+	if err := r.db.GetContext(ctx, &a, query, kind, key, at, failuresSince, lockCountSince); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *loginAttemptRepo) Lock(ctx context.Context, kind, key string, threshold int, until time.Time) (bool, error) {
+	query := `UPDATE login_attempts
+			  SET failures = 0, lock_count = lock_count + 1, locked_until = $4
+			  WHERE kind=$1 AND key=$2 AND failures >= $3`
+	res, err := r.db.ExecContext(ctx, query, kind, key, threshold, until)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *loginAttemptRepo) Delete(ctx context.Context, kind, key string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE kind=$1 AND key=$2`, kind, key)
+	return err
+}
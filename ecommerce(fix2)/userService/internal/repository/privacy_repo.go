@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"userService/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type privacyRepo struct {
+	db *sqlx.DB
+}
+
+func NewPrivacyRepo(db *sqlx.DB) domain.PrivacyRepository {
+	return &privacyRepo{db}
+}
+
+func (r *privacyRepo) Create(ctx context.Context, p *domain.PrivacyRequest) error {
+	query := `INSERT INTO privacy_requests (user_id, kind, status) VALUES ($1, $2, $3) RETURNING id, created_at`
+	return r.db.QueryRowContext(ctx, query, p.UserID, p.Kind, p.Status).Scan(&p.ID, &p.CreatedAt)
+}
+
+func (r *privacyRepo) Get(ctx context.Context, userID, id int) (*domain.PrivacyRequest, error) {
+	var p domain.PrivacyRequest
+	err := r.db.GetContext(ctx, &p, `SELECT * FROM privacy_requests WHERE id=$1 AND user_id=$2`, id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrPrivacyRequestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *privacyRepo) SavePart(ctx context.Context, requestID int, service string, data json.RawMessage) (bool, error) {
+	if len(data) == 0 {
+		data = json.RawMessage("null")
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Блокируем запрос, чтобы две части, пришедшие одновременно, не разминулись
+	// при проверке, все ли собрано
+	var status string
+	err = tx.QueryRowContext(ctx, `SELECT status FROM privacy_requests WHERE id=$1 FOR UPDATE`, requestID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, domain.ErrPrivacyRequestNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+	if status == domain.PrivacyCompleted {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO privacy_request_parts (request_id, service, data) VALUES ($1, $2, $3)
+			  ON CONFLICT (request_id, service) DO NOTHING`, requestID, service, []byte(data))
+	if err != nil {
+		return false, err
+	}
+
+	var received int
+	err = tx.GetContext(ctx, &received, `SELECT COUNT(*) FROM privacy_request_parts WHERE request_id=$1 AND service = ANY($2)`,
+		requestID, pq.Array(domain.PrivacyServices))
+	if err != nil {
+		return false, err
+	}
+	completed := received == len(domain.PrivacyServices)
+	if completed {
+		_, err := tx.ExecContext(ctx, `UPDATE privacy_requests SET status=$1, completed_at=NOW() WHERE id=$2`, domain.PrivacyCompleted, requestID)
+		if err != nil {
+			return false, err
+		}
+	}
+	return completed, tx.Commit()
+}
+
+func (r *privacyRepo) Parts(ctx context.Context, requestID int) ([]*domain.PrivacyRequestPart, error) {
+	var parts []*domain.PrivacyRequestPart
+	err := r.db.SelectContext(ctx, &parts, `SELECT * FROM privacy_request_parts WHERE request_id=$1 ORDER BY service`, requestID)
+	return parts, err
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"userService/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type recoveryCodeRepo struct {
+	db *sqlx.DB
+}
+
+func NewRecoveryCodeRepo(db *sqlx.DB) domain.RecoveryCodeRepository {
+	return &recoveryCodeRepo{db}
+}
+
+func (r *recoveryCodeRepo) Replace(ctx context.Context, userID int, codeHashes []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *recoveryCodeRepo) Consume(ctx context.Context, userID int, codeHash string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE recovery_codes SET used_at=NOW()
+			  WHERE user_id=$1 AND code_hash=$2 AND used_at IS NULL`, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"userService/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type sessionRepo struct {
+	db *sqlx.DB
+}
+
+func NewSessionRepo(db *sqlx.DB) domain.SessionRepository {
+	return &sessionRepo{db}
+}
+
+func (r *sessionRepo) Create(ctx context.Context, s *domain.Session) error {
+	query := `INSERT INTO sessions (user_id, token_hash, device, ip, user_agent, expires_at)
+			  VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at, last_seen_at`
+	return r.db.QueryRowContext(ctx, query, s.UserID, s.TokenHash, s.Device, s.IP, s.UserAgent, s.ExpiresAt).
+		Scan(&s.ID, &s.CreatedAt, &s.LastSeenAt)
+}
+
+func (r *sessionRepo) GetActiveByTokenHash(ctx context.Context, tokenHash string) (*domain.Session, error) {
+	var s domain.Session
+	err := r.db.GetContext(ctx, &s, `SELECT * FROM sessions
+			  WHERE token_hash=$1 AND revoked_at IS NULL AND expires_at > NOW()`, tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *sessionRepo) Touch(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE sessions SET last_seen_at=NOW() WHERE id=$1`, id)
+	return err
+}
+
+func (r *sessionRepo) ListActive(ctx context.Context, userID int) ([]*domain.Session, error) {
+	var sessions []*domain.Session
+	err := r.db.SelectContext(ctx, &sessions, `SELECT * FROM sessions
+			  WHERE user_id=$1 AND revoked_at IS NULL AND expires_at > NOW()
+			  ORDER BY last_seen_at DESC`, userID)
+	return sessions, err
+}
+
+func (r *sessionRepo) Revoke(ctx context.Context, userID, id int) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE sessions SET revoked_at=NOW()
+			  WHERE id=$1 AND user_id=$2 AND revoked_at IS NULL`, id, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrSessionNotFound
+	}
+	return nil
+}
+
+func (r *sessionRepo) RevokeAll(ctx context.Context, userID, exceptID int) ([]int, error) {
+	var ids []int
+	err := r.db.SelectContext(ctx, &ids, `UPDATE sessions SET revoked_at=NOW()
+			  WHERE user_id=$1 AND id <> $2 AND revoked_at IS NULL
+			  RETURNING id`, userID, exceptID)
+	return ids, err
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"userService/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type tokenRepo struct {
+	db *sqlx.DB
+}
+
+func NewTokenRepo(db *sqlx.DB) domain.TokenRepository {
+	return &tokenRepo{db}
+}
+
+func (r *tokenRepo) Create(ctx context.Context, t *domain.UserToken) error {
+	query := `INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at)
+			  VALUES ($1, $2, $3, $4) RETURNING id, created_at`
+	return r.db.QueryRowContext(ctx, query, t.UserID, t.Purpose, t.TokenHash, t.ExpiresAt).Scan(&t.ID, &t.CreatedAt)
+}
+
+func (r *tokenRepo) Consume(ctx context.Context, purpose, tokenHash string) (int, error) {
+	// Проверка и пометка в одном запросе, чтобы токен нельзя было использовать дважды
+	query := `UPDATE user_tokens SET used_at=NOW()
+			  WHERE purpose=$1 AND token_hash=$2 AND used_at IS NULL AND expires_at > NOW()
+			  RETURNING user_id`
+	var userID int
+	err := r.db.QueryRowContext(ctx, query, purpose, tokenHash).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, domain.ErrInvalidToken
+	}
+	return userID, err
+}
+
+func (r *tokenRepo) DeleteByUser(ctx context.Context, userID int, purpose string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM user_tokens WHERE user_id=$1 AND purpose=$2 AND used_at IS NULL`, userID, purpose)
+	return err
+}
+
+// Reissue удаляет и использованные токены: у пользователя остается не больше
+// одной строки с этим назначением, сколько бы раз токен ни запрашивали
+func (r *tokenRepo) Reissue(ctx context.Context, t *domain.UserToken, minInterval time.Duration) (bool, error) {
+	query := `WITH fresh AS (
+				  SELECT 1 FROM user_tokens
+				  WHERE user_id=$1 AND purpose=$2 AND used_at IS NULL AND created_at > NOW() - make_interval(secs => $5)
+			  ), stale AS (
+				  DELETE FROM user_tokens
+				  WHERE user_id=$1 AND purpose=$2 AND NOT EXISTS (SELECT 1 FROM fresh)
+			  )
+			  INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at)
+			  SELECT $1, $2, $3, $4 WHERE NOT EXISTS (SELECT 1 FROM fresh)
+			  RETURNING id, created_at`
+	err := r.db.QueryRowContext(ctx, query, t.UserID, t.Purpose, t.TokenHash, t.ExpiresAt, minInterval.Seconds()).Scan(&t.ID, &t.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -1,11 +1,20 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 	"userService/internal/domain"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
+// uniqueViolation — код ошибки PostgreSQL для нарушения уникального индекса
+const uniqueViolation = "23505"
+
 type userRepo struct {
 	db *sqlx.DB
 }
@@ -14,25 +23,159 @@ func NewUserRepo(db *sqlx.DB) domain.UserRepository {
 	return &userRepo{db}
 }
 
-func (r *userRepo) Create(u *domain.User) error {
-	query := `INSERT INTO users (username, password) VALUES ($1, $2) RETURNING id`
-	return r.db.QueryRow(query, u.Username, u.Password).Scan(&u.ID)
+func (r *userRepo) Create(ctx context.Context, u *domain.User) error {
+	query := `INSERT INTO users (username, password, role, email, email_verified, display_name)
+			  VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`
+	err := r.db.QueryRowContext(ctx, query, u.Username, u.Password, u.Role, u.Email, u.EmailVerified, u.DisplayName).
+		Scan(&u.ID, &u.CreatedAt)
+	return mapUniqueViolation(err)
+}
+
+func (r *userRepo) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	var u domain.User
+	err := r.db.GetContext(ctx, &u, `SELECT * FROM users WHERE username=$1`, username)
+	return &u, err
 }
 
-func (r *userRepo) GetByUsername(username string) (*domain.User, error) {
+func (r *userRepo) GetByID(ctx context.Context, id int) (*domain.User, error) {
 	var u domain.User
-	err := r.db.Get(&u, `SELECT * FROM users WHERE username=$1`, username)
+	err := r.db.GetContext(ctx, &u, `SELECT * FROM users WHERE id=$1`, id)
 	return &u, err
 }
 
-func (r *userRepo) GetByID(id int) (*domain.User, error) {
+func (r *userRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var u domain.User
-	err := r.db.Get(&u, `SELECT * FROM users WHERE id=$1`, id)
+	err := r.db.GetContext(ctx, &u, `SELECT * FROM users WHERE email=$1`, email)
 	return &u, err
 }
 
-func (r *userRepo) Update(u *domain.User) error {
-	query := `UPDATE users SET username=$1 WHERE id=$2`
-	_, err := r.db.Exec(query, u.Username, u.ID)
+func (r *userRepo) Update(ctx context.Context, u *domain.User) error {
+	query := `UPDATE users SET username=$1, email=$2, email_verified=$3, display_name=$4 WHERE id=$5`
+	_, err := r.db.ExecContext(ctx, query, u.Username, u.Email, u.EmailVerified, u.DisplayName, u.ID)
+	return mapUniqueViolation(err)
+}
+
+func (r *userRepo) UpdatePassword(ctx context.Context, id int, hash string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET password=$1 WHERE id=$2`, hash, id)
+	return err
+}
+
+func (r *userRepo) ReplacePasswordHash(ctx context.Context, id int, oldHash, newHash string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET password=$1 WHERE id=$2 AND password=$3`, newHash, id, oldHash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *userRepo) MarkEmailVerified(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET email_verified=TRUE WHERE id=$1`, id)
+	return err
+}
+
+func (r *userRepo) SetTOTPSecret(ctx context.Context, id int, secret string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET totp_secret=$1, totp_enabled=FALSE WHERE id=$2`, secret, id)
+	return err
+}
+
+func (r *userRepo) EnableTOTP(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET totp_enabled=TRUE WHERE id=$1 AND totp_secret <> ''`, id)
+	return err
+}
+
+func (r *userRepo) UseTOTPStep(ctx context.Context, id int, step int64) (bool, error) {
+	// Проверка и запись в одном запросе: из параллельных входов с одним кодом пройдет один
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET totp_last_step=$1 WHERE id=$2 AND totp_last_step < $1`, step, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Delete удаляет пользователя; адреса, сессии, токены и коды восстановления
+// удаляются каскадно
+func (r *userRepo) Delete(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM users WHERE id=$1`, id)
+	return err
+}
+
+func (r *userRepo) List(ctx context.Context, f domain.UserFilter) ([]*domain.User, error) {
+	conds := []string{"id > $1"}
+	args := []interface{}{f.AfterID}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if f.Role != "" {
+		conds = append(conds, "role = "+arg(f.Role))
+	}
+	switch f.Status {
+	case domain.UserStatusActive:
+		conds = append(conds, "disabled_at IS NULL")
+	case domain.UserStatusDisabled:
+		conds = append(conds, "disabled_at IS NOT NULL")
+	}
+	if f.CreatedAfter != nil {
+		conds = append(conds, "created_at >= "+arg(*f.CreatedAfter))
+	}
+	if f.CreatedBefore != nil {
+		conds = append(conds, "created_at < "+arg(*f.CreatedBefore))
+	}
+	if f.Query != "" {
+		pattern := arg("%" + escapeLike(f.Query) + "%")
+		conds = append(conds, "(username ILIKE "+pattern+" OR email ILIKE "+pattern+")")
+	}
+
+	query := `SELECT * FROM users WHERE ` + strings.Join(conds, " AND ") + ` ORDER BY id LIMIT ` + arg(f.Limit)
+	var users []*domain.User
+	err := r.db.SelectContext(ctx, &users, query, args...)
+	return users, err
+}
+
+func (r *userRepo) SetDisabled(ctx context.Context, id int, disabled bool) error {
+	// Повторное отключение сохраняет исходное время
+	query := `UPDATE users SET disabled_at = CASE WHEN $1 THEN COALESCE(disabled_at, NOW()) END WHERE id=$2`
+	return expectRow(r.db.ExecContext(ctx, query, disabled, id))
+}
+
+func (r *userRepo) SetRole(ctx context.Context, id int, role string) error {
+	return expectRow(r.db.ExecContext(ctx, `UPDATE users SET role=$1 WHERE id=$2`, role, id))
+}
+
+// expectRow возвращает sql.ErrNoRows, если запрос не затронул ни одной строки
+func expectRow(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// mapUniqueViolation переводит нарушение уникальности имени или email в ошибки домена
+func mapUniqueViolation(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != uniqueViolation {
+		return err
+	}
+	switch pqErr.Constraint {
+	case "users_username_key":
+		return domain.ErrUsernameTaken
+	case "users_email_key":
+		return domain.ErrEmailTaken
+	}
 	return err
 }
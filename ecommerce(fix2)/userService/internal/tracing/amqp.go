@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// headerCarrier позволяет пропагатору читать и писать заголовки сообщения
+type headerCarrier amqp.Table
+
+func (c headerCarrier) Get(key string) string {
+	v, _ := c[key].(string)
+	return v
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// StartPublish открывает спан отправки сообщения и возвращает заголовки с
+// контекстом трассировки (traceparent), которые нужно положить в сообщение:
+// по ним получатель продолжит ту же трассу
+func StartPublish(ctx context.Context, exchange, routingKey string) (trace.Span, amqp.Table) {
+	ctx, span := tracer.Start(ctx, "publish "+routingKey,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(messagingAttributes("publish", exchange, routingKey)...),
+	)
+	headers := amqp.Table{}
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier(headers))
+	return span, headers
+}
+
+// StartConsume продолжает трассу отправителя по заголовкам сообщения и
+// открывает спан его обработки. Сообщения без заголовков начинают новую трассу.
+func StartConsume(queue string, d amqp.Delivery) (context.Context, trace.Span) {
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), headerCarrier(d.Headers))
+	return tracer.Start(ctx, "process "+queue,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(messagingAttributes("process", d.Exchange, d.RoutingKey)...),
+		trace.WithAttributes(attribute.String("messaging.rabbitmq.queue", queue)),
+	)
+}
+
+// End завершает спан и отмечает в нем ошибку, если она есть
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func messagingAttributes(operation, exchange, routingKey string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("messaging.system", "rabbitmq"),
+		attribute.String("messaging.operation.type", operation),
+		attribute.String("messaging.destination.name", exchange),
+		attribute.String("messaging.rabbitmq.destination.routing_key", routingKey),
+	}
+}
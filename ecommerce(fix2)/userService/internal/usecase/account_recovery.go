@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+	"userService/internal/domain"
+	"userService/internal/mailer"
+	"userService/internal/validation"
+)
+
+const (
+	emailVerificationTTL = 24 * time.Hour
+	passwordResetTTL     = time.Hour
+	// passwordResetMailTimeout ограничивает фоновую отправку письма о сбросе
+	passwordResetMailTimeout = 30 * time.Second
+)
+
+// RequestPasswordReset отвечает сразу и всегда успехом, а поиск адреса,
+// выдача токена и письмо идут в фоне: иначе по статусу или времени ответа
+// было бы видно, зарегистрирован ли адрес. Ошибки только пишутся в лог.
+func (uc *userUsecase) RequestPasswordReset(ctx context.Context, email string) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), passwordResetMailTimeout)
+	go func() {
+		defer cancel()
+		if err := uc.sendPasswordReset(ctx, email); err != nil {
+			slog.ErrorContext(ctx, "failed to send password reset email", "error", err)
+		}
+	}()
+	return nil
+}
+
+func (uc *userUsecase) sendPasswordReset(ctx context.Context, email string) error {
+	u, err := uc.repo.GetByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Действует только последняя ссылка на сброс
+	if err := uc.tokens.DeleteByUser(ctx, u.ID, domain.TokenPasswordReset); err != nil {
+		return err
+	}
+	token, err := uc.issueToken(ctx, u.ID, domain.TokenPasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return uc.mailer.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Hi %s,\n\nUse this token to reset your password: %s\n\n"+
+			"It expires in %s. If you didn't request a reset, ignore this email.",
+			u.Username, token, passwordResetTTL),
+	})
+}
+
+func (uc *userUsecase) ResetPassword(ctx context.Context, token, newPassword string) error {
+	// Проверяем до использования токена, чтобы слабый пароль не сжигал ссылку из письма
+	var v validation.Validator
+	v.Password("new_password", newPassword, "")
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	userID, err := uc.tokens.Consume(ctx, domain.TokenPasswordReset, hashToken(token))
+	if err != nil {
+		return err
+	}
+
+	hash, err := uc.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := uc.repo.UpdatePassword(ctx, userID, hash); err != nil {
+		return err
+	}
+	if err := uc.tokens.DeleteByUser(ctx, userID, domain.TokenPasswordReset); err != nil {
+		return err
+	}
+	// Старый пароль мог утечь — завершаем все сессии
+	if _, err := uc.sessions.RevokeAll(ctx, userID, 0); err != nil {
+		return err
+	}
+
+	// Владелец почты подтвердил личность — снимаем блокировку входа
+	u, err := uc.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return uc.attempts.Delete(ctx, domain.AttemptByUsername, u.Username)
+}
+
+func (uc *userUsecase) VerifyEmail(ctx context.Context, token string) error {
+	userID, err := uc.tokens.Consume(ctx, domain.TokenEmailVerification, hashToken(token))
+	if err != nil {
+		return err
+	}
+	return uc.repo.MarkEmailVerified(ctx, userID)
+}
+
+func (uc *userUsecase) sendVerificationEmail(ctx context.Context, u *domain.User) error {
+	token, err := uc.issueToken(ctx, u.ID, domain.TokenEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := uc.publicURL + "/email/verify?token=" + url.QueryEscape(token)
+	return uc.mailer.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm your email by opening this link:\n%s\n\nThe link expires in %s.",
+			u.Username, link, emailVerificationTTL),
+	})
+}
+
+// issueToken создает одноразовый токен и сохраняет его хеш
+func (uc *userUsecase) issueToken(ctx context.Context, userID int, purpose string, ttl time.Duration) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = uc.tokens.Create(ctx, &domain.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// randomToken возвращает 256 случайных бит в base64url
+func randomToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
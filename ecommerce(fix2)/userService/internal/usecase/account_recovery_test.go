@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"userService/internal/domain"
+	"userService/internal/mailer"
+)
+
+// fakeTokens — та часть TokenRepository, которая нужна для выдачи токенов
+type fakeTokens struct {
+	domain.TokenRepository
+}
+
+func (fakeTokens) Create(context.Context, *domain.UserToken) error { return nil }
+func (fakeTokens) DeleteByUser(context.Context, int, string) error { return nil }
+
+// blockingMailer не отправляет письмо, пока не закрыт release, и
+// сообщает в sent, кому писал
+type blockingMailer struct {
+	release chan struct{}
+	sent    chan string
+	err     error
+}
+
+func (m *blockingMailer) Send(_ context.Context, msg mailer.Message) error {
+	<-m.release
+	m.sent <- msg.To
+	return m.err
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		mailErr  error
+		wantSent bool
+	}{
+		{name: "registered address", email: "alice@example.com", wantSent: true},
+		{name: "mailer failure", email: "alice@example.com", mailErr: errors.New("smtp down"), wantSent: true},
+		{name: "unknown address", email: "bob@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUsers{byID: map[int]*domain.User{1: {ID: 1, Username: "alice", Email: "alice@example.com"}}}
+			m := &blockingMailer{release: make(chan struct{}), sent: make(chan string, 1), err: tt.mailErr}
+			uc := &userUsecase{repo: users, tokens: fakeTokens{}, mailer: m}
+
+			// Письмо еще не ушло, а ответ уже есть, и он одинаковый для всех адресов
+			if err := uc.RequestPasswordReset(context.Background(), tt.email); err != nil {
+				t.Fatalf("RequestPasswordReset = %v, want nil", err)
+			}
+			close(m.release)
+
+			if !tt.wantSent {
+				return
+			}
+			select {
+			case to := <-m.sent:
+				if to != tt.email {
+					t.Errorf("mail sent to %q, want %q", to, tt.email)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("reset email was not sent")
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"context"
+	"userService/internal/domain"
+	"userService/internal/validation"
+)
+
+// maxAddressesPerUser ограничивает размер адресной книги
+const maxAddressesPerUser = 20
+
+type addressUsecase struct {
+	repo domain.AddressRepository
+}
+
+func NewAddressUsecase(r domain.AddressRepository) domain.AddressUsecase {
+	return &addressUsecase{r}
+}
+
+func (uc *addressUsecase) CreateAddress(ctx context.Context, a *domain.Address) error {
+	var v validation.Validator
+	v.Address(a)
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	n, err := uc.repo.Count(ctx, a.UserID)
+	if err != nil {
+		return err
+	}
+	if n >= maxAddressesPerUser {
+		return domain.ErrAddressLimitReached
+	}
+	// Первый адрес всегда основной
+	if n == 0 {
+		a.IsDefault = true
+	}
+	return uc.repo.Create(ctx, a)
+}
+
+func (uc *addressUsecase) GetAddress(ctx context.Context, userID, id int) (*domain.Address, error) {
+	return uc.repo.GetByID(ctx, userID, id)
+}
+
+func (uc *addressUsecase) ListAddresses(ctx context.Context, userID int) ([]*domain.Address, error) {
+	return uc.repo.List(ctx, userID)
+}
+
+// UpdateAddress заменяет поля адреса. Флаг IsDefault может только назначить
+// адрес основным; снять его можно, назначив основным другой адрес.
+func (uc *addressUsecase) UpdateAddress(ctx context.Context, a *domain.Address) error {
+	current, err := uc.repo.GetByID(ctx, a.UserID, a.ID)
+	if err != nil {
+		return err
+	}
+
+	var v validation.Validator
+	v.Address(a)
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	a.IsDefault = a.IsDefault || current.IsDefault
+	a.CreatedAt = current.CreatedAt
+	return uc.repo.Update(ctx, a)
+}
+
+func (uc *addressUsecase) DeleteAddress(ctx context.Context, userID, id int) error {
+	return uc.repo.Delete(ctx, userID, id)
+}
+
+func (uc *addressUsecase) SetDefaultAddress(ctx context.Context, userID, id int) (*domain.Address, error) {
+	if err := uc.repo.SetDefault(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	return uc.repo.GetByID(ctx, userID, id)
+}
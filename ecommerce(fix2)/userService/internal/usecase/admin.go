@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+	"userService/internal/domain"
+	"userService/internal/validation"
+)
+
+func (uc *userUsecase) ListUsers(ctx context.Context, f domain.UserFilter) ([]*domain.User, int, error) {
+	var v validation.Validator
+	v.UserFilter(&f)
+	if err := v.Err(); err != nil {
+		return nil, 0, err
+	}
+	if f.Limit == 0 {
+		f.Limit = validation.DefaultPageSize
+	}
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	pageSize := f.Limit
+	f.Limit++
+	users, err := uc.repo.List(ctx, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(users) <= pageSize {
+		return users, 0, nil
+	}
+	users = users[:pageSize]
+	return users, users[pageSize-1].ID, nil
+}
+
+// DisableUser запрещает вход; действующие сессии отключенного пользователя
+// перестают проходить проверку в ValidateSession
+func (uc *userUsecase) DisableUser(ctx context.Context, id int) (*domain.User, error) {
+	if err := uc.repo.SetDisabled(ctx, id, true); err != nil {
+		return nil, err
+	}
+	return uc.repo.GetByID(ctx, id)
+}
+
+func (uc *userUsecase) EnableUser(ctx context.Context, id int) (*domain.User, error) {
+	if err := uc.repo.SetDisabled(ctx, id, false); err != nil {
+		return nil, err
+	}
+	return uc.repo.GetByID(ctx, id)
+}
+
+func (uc *userUsecase) SetUserRole(ctx context.Context, id int, role string) (*domain.User, error) {
+	var v validation.Validator
+	v.Role("role", role)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	if err := uc.repo.SetRole(ctx, id, role); err != nil {
+		return nil, err
+	}
+	return uc.repo.GetByID(ctx, id)
+}
@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"log/slog"
+	"strings"
+	"time"
+	"userService/internal/domain"
+	"userService/internal/validation"
+)
+
+const (
+	// maxAPIKeysPerUser ограничивает число действующих ключей пользователя
+	maxAPIKeysPerUser = 10
+
+	// defaultGracePeriod — сколько старый ключ действует после ротации,
+	// если клиент не указал срок
+	defaultGracePeriod = 24 * time.Hour
+
+	// apiKeyTag отличает ключи магазина от других секретов, например при
+	// поиске утекших ключей в репозиториях
+	apiKeyTag = "ek_"
+	// apiKeyPrefixLength — сколько символов ключа хранится открыто и
+	// показывается в списке, чтобы ключи можно было различить
+	apiKeyPrefixLength = len(apiKeyTag) + 8
+)
+
+type apiKeyUsecase struct {
+	repo  domain.APIKeyRepository
+	users domain.UserRepository
+}
+
+func NewAPIKeyUsecase(r domain.APIKeyRepository, users domain.UserRepository) domain.APIKeyUsecase {
+	return &apiKeyUsecase{r, users}
+}
+
+func (uc *apiKeyUsecase) CreateAPIKey(ctx context.Context, req domain.NewAPIKey) (string, *domain.APIKey, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	var v validation.Validator
+	v.NewAPIKey(&req)
+	if err := v.Err(); err != nil {
+		return "", nil, err
+	}
+
+	n, err := uc.repo.CountActive(ctx, req.UserID)
+	if err != nil {
+		return "", nil, err
+	}
+	if n >= maxAPIKeysPerUser {
+		return "", nil, domain.ErrAPIKeyLimitReached
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresIn > 0 {
+		t := time.Now().Add(req.ExpiresIn)
+		expiresAt = &t
+	}
+	return uc.issue(ctx, req.UserID, req.Name, req.Scopes, expiresAt)
+}
+
+func (uc *apiKeyUsecase) ListAPIKeys(ctx context.Context, userID int) ([]*domain.APIKey, error) {
+	return uc.repo.ListActive(ctx, userID)
+}
+
+func (uc *apiKeyUsecase) RevokeAPIKey(ctx context.Context, userID, id int) error {
+	if err := uc.repo.Revoke(ctx, userID, id); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "revoked api key", "user_id", userID, "api_key_id", id)
+	return nil
+}
+
+// RotateAPIKey не проверяет лимит ключей: на время grace у пользователя
+// может быть на один ключ больше, зато ротация никогда не упирается в лимит
+func (uc *apiKeyUsecase) RotateAPIKey(ctx context.Context, userID, id int, grace time.Duration) (string, *domain.APIKey, error) {
+	var v validation.Validator
+	v.GracePeriod("grace_period_hours", grace)
+	if err := v.Err(); err != nil {
+		return "", nil, err
+	}
+	if grace == 0 {
+		grace = defaultGracePeriod
+	}
+
+	old, err := uc.repo.GetActive(ctx, userID, id)
+	if err != nil {
+		return "", nil, err
+	}
+	key, k, err := uc.issue(ctx, userID, old.Name, old.Scopes, old.ExpiresAt)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := uc.repo.ExpireAt(ctx, old.ID, time.Now().Add(grace)); err != nil {
+		return "", nil, err
+	}
+	slog.InfoContext(ctx, "rotated api key", "user_id", userID, "old_api_key_id", old.ID, "api_key_id", k.ID)
+	return key, k, nil
+}
+
+func (uc *apiKeyUsecase) ValidateAPIKey(ctx context.Context, key string) (*domain.APIKey, *domain.User, error) {
+	if !strings.HasPrefix(key, apiKeyTag) {
+		return nil, nil, domain.ErrAPIKeyNotFound
+	}
+	k, err := uc.repo.GetActiveByHash(ctx, hashToken(key))
+	if err != nil {
+		return nil, nil, err
+	}
+	u, err := uc.users.GetByID(ctx, k.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u.Disabled() {
+		return nil, nil, domain.ErrUserDisabled
+	}
+	if err := uc.repo.Touch(ctx, k.ID); err != nil {
+		// Отметка об использовании не должна ломать запрос
+		slog.WarnContext(ctx, "failed to touch api key", "api_key_id", k.ID, "error", err)
+	}
+	return k, u, nil
+}
+
+func (uc *apiKeyUsecase) issue(ctx context.Context, userID int, name string, scopes []string, expiresAt *time.Time) (string, *domain.APIKey, error) {
+	key, err := newAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+	k := &domain.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    key[:apiKeyPrefixLength],
+		KeyHash:   hashToken(key),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err := uc.repo.Create(ctx, k); err != nil {
+		return "", nil, err
+	}
+	return key, k, nil
+}
+
+// newAPIKey возвращает ключ вида ek_<43 символа base64url>. Ключ
+// случайный и длинный, поэтому хранить достаточно SHA-256 без соли,
+// как и токены из писем.
+func newAPIKey() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return apiKeyTag + base64.RawURLEncoding.EncodeToString(raw), nil
+}
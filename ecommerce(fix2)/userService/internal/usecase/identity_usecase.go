@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"strings"
+	"userService/internal/domain"
+	"userService/internal/validation"
+)
+
+// maxUsernameAttempts — сколько вариантов имени перебрать при создании
+// пользователя из внешней учетной записи, если имя уже занято
+const maxUsernameAttempts = 5
+
+type identityUsecase struct {
+	repo   domain.IdentityRepository
+	users  domain.UserRepository
+	hasher domain.PasswordHasher
+}
+
+func NewIdentityUsecase(r domain.IdentityRepository, users domain.UserRepository, h domain.PasswordHasher) domain.IdentityUsecase {
+	return &identityUsecase{r, users, h}
+}
+
+func (uc *identityUsecase) LoginWithOIDC(ctx context.Context, ext domain.ExternalIdentity) (*domain.User, error) {
+	var v validation.Validator
+	v.ExternalIdentity(&ext)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	u, err := uc.login(ctx, ext)
+	if !errors.Is(err, domain.ErrIdentityNotFound) {
+		return u, err
+	}
+
+	// Первый вход через провайдера
+	u, created, err := uc.linkOrProvision(ctx, ext)
+	if err != nil {
+		return nil, err
+	}
+	if u.Disabled() {
+		return nil, domain.ErrUserDisabled
+	}
+	err = uc.repo.Create(ctx, &domain.Identity{Issuer: ext.Issuer, Subject: ext.Subject, UserID: u.ID, Email: ext.Email})
+	if errors.Is(err, domain.ErrIdentityExists) {
+		// Параллельный первый вход успел привязать учетную запись раньше
+		if created {
+			if err := uc.users.Delete(ctx, u.ID); err != nil {
+				slog.ErrorContext(ctx, "failed to delete duplicate user", "user_id", u.ID, "error", err)
+			}
+		}
+		return uc.login(ctx, ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		slog.InfoContext(ctx, "provisioned user for external identity", "user_id", u.ID, "subject", ext.Subject, "issuer", ext.Issuer)
+	} else {
+		slog.InfoContext(ctx, "linked user to external identity", "user_id", u.ID, "subject", ext.Subject, "issuer", ext.Issuer)
+	}
+	return u, nil
+}
+
+// login входит по уже привязанной учетной записи; ErrIdentityNotFound — привязки нет
+func (uc *identityUsecase) login(ctx context.Context, ext domain.ExternalIdentity) (*domain.User, error) {
+	identity, err := uc.repo.Get(ctx, ext.Issuer, ext.Subject)
+	if err != nil {
+		return nil, err
+	}
+	u, err := uc.users.GetByID(ctx, identity.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Disabled() {
+		return nil, domain.ErrUserDisabled
+	}
+	if err := uc.repo.Touch(ctx, ext.Issuer, ext.Subject, ext.Email); err != nil {
+		slog.WarnContext(ctx, "failed to touch identity", "user_id", u.ID, "error", err)
+	}
+	return u, nil
+}
+
+// linkOrProvision находит пользователя с тем же email или создает нового.
+// Привязка по email только для адресов, подтвержденных и провайдером, и у
+// нас. Без первого любой, кто заведет у провайдера чужой адрес, получил бы
+// чужую учетную запись. Без второго злоумышленник мог бы заранее
+// зарегистрироваться с адресом жертвы, и ее первый вход через провайдера
+// привязался бы к учетной записи, пароль которой он знает.
+func (uc *identityUsecase) linkOrProvision(ctx context.Context, ext domain.ExternalIdentity) (u *domain.User, created bool, err error) {
+	email := ""
+	if ext.EmailVerified {
+		email = ext.Email
+		u, err := uc.users.GetByEmail(ctx, email)
+		switch {
+		case err == nil && u.EmailVerified:
+			return u, false, nil
+		case err == nil:
+			// Адрес занят неподтвержденной учетной записью: создаем отдельную
+			// без email, адрес уникален и остается за той
+			slog.WarnContext(ctx, "not linking external identity to user with unverified email",
+				"user_id", u.ID, "subject", ext.Subject, "issuer", ext.Issuer)
+			email = ""
+		case !errors.Is(err, sql.ErrNoRows):
+			return nil, false, err
+		}
+	}
+
+	// Пароль случайный и никому не известен: вход только через провайдера,
+	// пока пользователь не задаст пароль через сброс
+	secret, err := randomToken()
+	if err != nil {
+		return nil, false, err
+	}
+	hash, err := uc.hasher.Hash(secret)
+	if err != nil {
+		return nil, false, err
+	}
+
+	base := usernameFor(ext)
+	for attempt := 0; attempt < maxUsernameAttempts; attempt++ {
+		username := base
+		if attempt > 0 {
+			username = fmt.Sprintf("%s-%04d", truncate(base, 27), rand.Intn(10000))
+		}
+		u = &domain.User{
+			Username:      username,
+			Password:      hash,
+			Role:          domain.RoleUser,
+			Email:         email,
+			EmailVerified: email != "",
+			DisplayName:   ext.Name,
+		}
+		err = uc.users.Create(ctx, u)
+		if !errors.Is(err, domain.ErrUsernameTaken) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return u, true, nil
+}
+
+// usernameFor подбирает имя пользователя из preferred_username или email,
+// приводя его к правилам регистрации
+func usernameFor(ext domain.ExternalIdentity) string {
+	candidate := ext.PreferredUsername
+	if candidate == "" {
+		candidate, _, _ = strings.Cut(ext.Email, "@")
+	}
+
+	var b strings.Builder
+	for _, r := range candidate {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '.', r == '-':
+			b.WriteRune(r)
+		}
+	}
+	name := b.String()
+	if name == "" || !(name[0] >= 'a' && name[0] <= 'z' || name[0] >= 'A' && name[0] <= 'Z') {
+		name = "user" + name
+	}
+	name = truncate(name, 32)
+
+	var v validation.Validator
+	v.Username("username", name)
+	if v.Err() != nil {
+		// Слишком короткое или зарезервированное имя
+		return truncate("user-"+name, 32)
+	}
+	return name
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"userService/internal/domain"
+	"userService/internal/hasher"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeIdentities — IdentityRepository в памяти
+type fakeIdentities struct {
+	byKey map[[2]string]*domain.Identity
+}
+
+func (f *fakeIdentities) Get(_ context.Context, issuer, subject string) (*domain.Identity, error) {
+	i, ok := f.byKey[[2]string{issuer, subject}]
+	if !ok {
+		return nil, domain.ErrIdentityNotFound
+	}
+	return i, nil
+}
+
+func (f *fakeIdentities) Create(_ context.Context, i *domain.Identity) error {
+	key := [2]string{i.Issuer, i.Subject}
+	if _, ok := f.byKey[key]; ok {
+		return domain.ErrIdentityExists
+	}
+	f.byKey[key] = i
+	return nil
+}
+
+func (f *fakeIdentities) Touch(context.Context, string, string, string) error {
+	return nil
+}
+
+func (f *fakeIdentities) ListByUser(context.Context, int) ([]*domain.Identity, error) {
+	return nil, nil
+}
+
+// fakeUsers — та часть UserRepository, которая нужна входу через провайдера;
+// остальные методы не реализованы и при вызове упадут
+type fakeUsers struct {
+	domain.UserRepository
+	byID map[int]*domain.User
+}
+
+func (f *fakeUsers) Create(_ context.Context, u *domain.User) error {
+	for _, existing := range f.byID {
+		if existing.Username == u.Username {
+			return domain.ErrUsernameTaken
+		}
+		if u.Email != "" && existing.Email == u.Email {
+			return domain.ErrEmailTaken
+		}
+	}
+	u.ID = len(f.byID) + 1
+	f.byID[u.ID] = u
+	return nil
+}
+
+func (f *fakeUsers) GetByID(_ context.Context, id int) (*domain.User, error) {
+	u, ok := f.byID[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return u, nil
+}
+
+func (f *fakeUsers) GetByEmail(_ context.Context, email string) (*domain.User, error) {
+	for _, u := range f.byID {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeUsers) Delete(_ context.Context, id int) error {
+	delete(f.byID, id)
+	return nil
+}
+
+const testIssuer = "https://sso.example.com"
+
+func TestLoginWithOIDCLinkOrProvision(t *testing.T) {
+	tests := []struct {
+		name string
+		// local — учетная запись, зарегистрированная до первого входа через провайдера
+		local         *domain.User
+		emailVerified bool // подтвердил ли email провайдер
+		wantLinked    bool
+		wantEmail     string
+	}{
+		{
+			name:          "new user is provisioned",
+			emailVerified: true,
+			wantEmail:     "alice@example.com",
+		},
+		{
+			name:          "verified local account is linked",
+			local:         &domain.User{Username: "alice", Email: "alice@example.com", EmailVerified: true},
+			emailVerified: true,
+			wantLinked:    true,
+			wantEmail:     "alice@example.com",
+		},
+		{
+			// Злоумышленник зарегистрировался с чужим адресом, не подтвердив его
+			name:          "unverified local account is not linked",
+			local:         &domain.User{Username: "mallory", Email: "alice@example.com"},
+			emailVerified: true,
+			wantEmail:     "",
+		},
+		{
+			name:          "email not verified by provider is not linked",
+			local:         &domain.User{Username: "alice", Email: "alice@example.com", EmailVerified: true},
+			emailVerified: false,
+			wantEmail:     "",
+		},
+		{
+			name:          "email not verified by provider is not stored",
+			emailVerified: false,
+			wantEmail:     "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUsers{byID: make(map[int]*domain.User)}
+			localID := 0
+			if tt.local != nil {
+				if err := users.Create(context.Background(), tt.local); err != nil {
+					t.Fatal(err)
+				}
+				localID = tt.local.ID
+			}
+			uc := NewIdentityUsecase(&fakeIdentities{byKey: make(map[[2]string]*domain.Identity)}, users, hasher.NewBcrypt(bcrypt.MinCost))
+
+			ext := domain.ExternalIdentity{
+				Issuer:            testIssuer,
+				Subject:           "u1",
+				Email:             "alice@example.com",
+				EmailVerified:     tt.emailVerified,
+				PreferredUsername: "alice",
+			}
+			u, err := uc.LoginWithOIDC(context.Background(), ext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if linked := localID != 0 && u.ID == localID; linked != tt.wantLinked {
+				t.Errorf("linked = %v, want %v", linked, tt.wantLinked)
+			}
+			if u.Email != tt.wantEmail {
+				t.Errorf("email = %q, want %q", u.Email, tt.wantEmail)
+			}
+			if u.Email != "" && !u.EmailVerified {
+				t.Error("email from the provider is not marked verified")
+			}
+			if tt.local != nil && !tt.wantLinked && tt.local.Email != "alice@example.com" {
+				t.Error("local account lost its email")
+			}
+
+			// Повторный вход находит ту же учетную запись по (issuer, subject)
+			again, err := uc.LoginWithOIDC(context.Background(), ext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again.ID != u.ID {
+				t.Errorf("second login: user %d, want %d", again.ID, u.ID)
+			}
+		})
+	}
+}
+
+func TestLoginWithOIDCDisabledUser(t *testing.T) {
+	users := &fakeUsers{byID: make(map[int]*domain.User)}
+	uc := NewIdentityUsecase(&fakeIdentities{byKey: make(map[[2]string]*domain.Identity)}, users, hasher.NewBcrypt(bcrypt.MinCost))
+	ext := domain.ExternalIdentity{Issuer: testIssuer, Subject: "u1", Email: "alice@example.com", EmailVerified: true}
+
+	u, err := uc.LoginWithOIDC(context.Background(), ext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := u.CreatedAt
+	u.DisabledAt = &now
+
+	if _, err := uc.LoginWithOIDC(context.Background(), ext); !errors.Is(err, domain.ErrUserDisabled) {
+		t.Fatalf("err = %v, want ErrUserDisabled", err)
+	}
+}
@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"userService/internal/domain"
+)
+
+const (
+	maxUsernameFailures = 5  // неудачных попыток подряд на одно имя пользователя
+	maxIPFailures       = 20 // неудачных попыток с одного IP (по любым именам)
+	failureWindow       = 15 * time.Minute
+	baseLockout         = time.Minute
+	maxLockout          = time.Hour
+	lockCountResetAfter = 24 * time.Hour
+)
+
+// lockPublisher сообщает о блокировке входа; в сервисе это message.MessageProducer
+type lockPublisher interface {
+	PublishUserLocked(ctx context.Context, username, ip string, lockedUntil time.Time, lockCount int) error
+}
+
+// lockoutDuration удваивает блокировку с каждой следующей: 1, 2, 4 ... минут, но не больше maxLockout
+func lockoutDuration(lockCount int) time.Duration {
+	d := baseLockout
+	for i := 1; i < lockCount && d < maxLockout; i++ {
+		d *= 2
+	}
+	if d > maxLockout {
+		d = maxLockout
+	}
+	return d
+}
+
+// checkLocked возвращает AccountLockedError, если вход заблокирован по имени или по IP
+func (uc *userUsecase) checkLocked(ctx context.Context, username, ip string) error {
+	var retryAfter time.Duration
+	for _, key := range attemptKeys(username, ip) {
+		a, err := uc.attempts.Get(ctx, key.kind, key.value)
+		if err != nil {
+			return err
+		}
+		if a == nil || a.LockedUntil == nil {
+			continue
+		}
+		if left := time.Until(*a.LockedUntil); left > retryAfter {
+			retryAfter = left
+		}
+	}
+	if retryAfter > 0 {
+		return &domain.AccountLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// recordFailure учитывает неудачную попытку входа. Счетчики ведутся и для
+// несуществующих имен, чтобы блокировка не выдавала, есть ли такой пользователь.
+// Запись не отменяется вместе с запросом: иначе клиент, обрывающий соединение
+// после неверного пароля, перебирал бы пароли без блокировки.
+func (uc *userUsecase) recordFailure(ctx context.Context, username, ip string) {
+	ctx = context.WithoutCancel(ctx)
+	for _, key := range attemptKeys(username, ip) {
+		if err := uc.recordAttemptFailure(ctx, key, username, ip); err != nil {
+			slog.ErrorContext(ctx, "failed to record login failure", "kind", key.kind, "error", err)
+		}
+	}
+}
+
+// recordAttemptFailure увеличивает счетчик атомарно и по его новому значению
+// решает, пора ли блокировать. Блокировку ставит только один запрос из тех,
+// что одновременно перешли порог, он же публикует событие.
+func (uc *userUsecase) recordAttemptFailure(ctx context.Context, key attemptKey, username, ip string) error {
+	now := time.Now()
+	a, err := uc.attempts.RecordFailure(ctx, key.kind, key.value, now, now.Add(-failureWindow), now.Add(-lockCountResetAfter))
+	if err != nil {
+		return err
+	}
+	if a.Failures < key.threshold {
+		return nil
+	}
+
+	lockCount := a.LockCount + 1
+	lockedUntil := now.Add(lockoutDuration(lockCount))
+	locked, err := uc.attempts.Lock(ctx, key.kind, key.value, key.threshold, lockedUntil)
+	if err != nil || !locked {
+		return err
+	}
+
+	slog.WarnContext(ctx, "login locked", "kind", key.kind, "until", lockedUntil)
+	if err := uc.producer.PublishUserLocked(ctx, username, ip, lockedUntil, lockCount); err != nil {
+		slog.ErrorContext(ctx, "failed to publish user locked event", "error", err)
+	}
+	return nil
+}
+
+type attemptKey struct {
+	kind      string
+	value     string
+	threshold int
+}
+
+func attemptKeys(username, ip string) []attemptKey {
+	keys := []attemptKey{{domain.AttemptByUsername, username, maxUsernameFailures}}
+	if ip != "" {
+		keys = append(keys, attemptKey{domain.AttemptByIP, ip, maxIPFailures})
+	}
+	return keys
+}
@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"userService/internal/domain"
+)
+
+// fakeAttempts — LoginAttemptRepository в памяти с той же логикой, что и SQL в репозитории
+type fakeAttempts struct {
+	byKey map[[2]string]*domain.LoginAttempt
+}
+
+func (f *fakeAttempts) Get(_ context.Context, kind, key string) (*domain.LoginAttempt, error) {
+	a, ok := f.byKey[[2]string{kind, key}]
+	if !ok {
+		return nil, nil
+	}
+	cp := *a
+	return &cp, nil
+}
+
+func (f *fakeAttempts) RecordFailure(_ context.Context, kind, key string, at, failuresSince, lockCountSince time.Time) (*domain.LoginAttempt, error) {
+	a, ok := f.byKey[[2]string{kind, key}]
+	if !ok {
+		a = &domain.LoginAttempt{Kind: kind, Key: key, LastFailureAt: at}
+		f.byKey[[2]string{kind, key}] = a
+	}
+	if a.LastFailureAt.Before(failuresSince) {
+		a.Failures = 0
+	}
+	if a.LastFailureAt.Before(lockCountSince) {
+		a.LockCount = 0
+	}
+	a.Failures++
+	a.LastFailureAt = at
+	cp := *a
+	return &cp, nil
+}
+
+func (f *fakeAttempts) Lock(_ context.Context, kind, key string, threshold int, until time.Time) (bool, error) {
+	a, ok := f.byKey[[2]string{kind, key}]
+	if !ok || a.Failures < threshold {
+		return false, nil
+	}
+	a.Failures = 0
+	a.LockCount++
+	a.LockedUntil = &until
+	return true, nil
+}
+
+func (f *fakeAttempts) Delete(_ context.Context, kind, key string) error {
+	delete(f.byKey, [2]string{kind, key})
+	return nil
+}
+
+// fakeLockPublisher запоминает номера блокировок из событий
+type fakeLockPublisher struct {
+	lockCounts []int
+}
+
+func (p *fakeLockPublisher) PublishUserLocked(_ context.Context, _, _ string, _ time.Time, lockCount int) error {
+	p.lockCounts = append(p.lockCounts, lockCount)
+	return nil
+}
+
+func TestLoginLockout(t *testing.T) {
+	const ip = "203.0.113.7"
+	tests := []struct {
+		name     string
+		failures int
+		// distinctNames — каждая попытка с новым именем, так что
+		// срабатывает только порог по IP
+		distinctNames bool
+		wantLockout   time.Duration // 0 — вход не заблокирован
+		wantEvents    []int
+	}{
+		{name: "below threshold", failures: maxUsernameFailures - 1},
+		{name: "locks after N failures", failures: maxUsernameFailures, wantLockout: baseLockout, wantEvents: []int{1}},
+		{name: "second lock doubles", failures: 2 * maxUsernameFailures, wantLockout: 2 * baseLockout, wantEvents: []int{1, 2}},
+		{name: "third lock doubles again", failures: 3 * maxUsernameFailures, wantLockout: 4 * baseLockout, wantEvents: []int{1, 2, 3}},
+		{name: "IP below threshold", failures: maxIPFailures - 1, distinctNames: true},
+		{name: "IP locks after N failures", failures: maxIPFailures, distinctNames: true, wantLockout: baseLockout, wantEvents: []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub := &fakeLockPublisher{}
+			uc := &userUsecase{attempts: &fakeAttempts{byKey: make(map[[2]string]*domain.LoginAttempt)}, producer: pub}
+			ctx := context.Background()
+
+			username := "alice"
+			for i := 0; i < tt.failures; i++ {
+				if tt.distinctNames {
+					username = fmt.Sprintf("user%d", i)
+				}
+				uc.recordFailure(ctx, username, ip)
+			}
+
+			// По IP блокируется и имя, с которым еще не ошибались
+			if tt.distinctNames {
+				username = "fresh"
+			}
+			err := uc.checkLocked(ctx, username, ip)
+			var locked *domain.AccountLockedError
+			switch {
+			case tt.wantLockout == 0 && err != nil:
+				t.Fatalf("checkLocked = %v, want not locked", err)
+			case tt.wantLockout > 0 && !errors.As(err, &locked):
+				t.Fatalf("checkLocked = %v, want AccountLockedError", err)
+			case tt.wantLockout > 0 && (locked.RetryAfter > tt.wantLockout || locked.RetryAfter < tt.wantLockout-time.Second):
+				t.Errorf("RetryAfter = %s, want about %s", locked.RetryAfter, tt.wantLockout)
+			}
+			if fmt.Sprint(pub.lockCounts) != fmt.Sprint(tt.wantEvents) {
+				t.Errorf("published lock counts %v, want %v", pub.lockCounts, tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestLockoutDuration(t *testing.T) {
+	tests := []struct {
+		lockCount int
+		want      time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{6, 32 * time.Minute},
+		{7, maxLockout},
+		{50, maxLockout},
+	}
+	for _, tt := range tests {
+		if got := lockoutDuration(tt.lockCount); got != tt.want {
+			t.Errorf("lockoutDuration(%d) = %s, want %s", tt.lockCount, got, tt.want)
+		}
+	}
+}
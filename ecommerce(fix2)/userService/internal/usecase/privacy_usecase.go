@@ -0,0 +1,251 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+	"userService/internal/domain"
+	"userService/internal/message"
+)
+
+// exportTTL — сколько готовый архив доступен для скачивания
+const exportTTL = 7 * 24 * time.Hour
+
+// privacyService — имя, под которым UserService кладет свою часть в архив
+const privacyService = "users"
+
+type privacyUsecase struct {
+	repo       domain.PrivacyRepository
+	users      domain.UserRepository
+	addresses  domain.AddressRepository
+	sessions   domain.SessionRepository
+	identities domain.IdentityRepository
+	attempts   domain.LoginAttemptRepository
+	producer   *message.MessageProducer
+	hasher     domain.PasswordHasher
+}
+
+func NewPrivacyUsecase(r domain.PrivacyRepository, users domain.UserRepository, addresses domain.AddressRepository, sessions domain.SessionRepository, identities domain.IdentityRepository, attempts domain.LoginAttemptRepository, p *message.MessageProducer, h domain.PasswordHasher) domain.PrivacyUsecase {
+	return &privacyUsecase{r, users, addresses, sessions, identities, attempts, p, h}
+}
+
+func (uc *privacyUsecase) ExportMyData(ctx context.Context, userID int) (*domain.PrivacyRequest, error) {
+	data, err := uc.exportUserData(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &domain.PrivacyRequest{UserID: userID, Kind: domain.PrivacyExport, Status: domain.PrivacyPending}
+	if err := uc.repo.Create(ctx, req); err != nil {
+		return nil, err
+	}
+	if _, err := uc.repo.SavePart(ctx, req.ID, privacyService, data); err != nil {
+		return nil, err
+	}
+	if err := uc.producer.PublishExportRequested(ctx, req.ID, userID); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (uc *privacyUsecase) GetDataExport(ctx context.Context, userID, requestID int) (*domain.DataExport, error) {
+	req, err := uc.repo.Get(ctx, userID, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Kind != domain.PrivacyExport {
+		return nil, domain.ErrPrivacyRequestNotFound
+	}
+	if req.Status != domain.PrivacyCompleted {
+		return &domain.DataExport{Request: req}, nil
+	}
+	if time.Since(*req.CompletedAt) > exportTTL {
+		return nil, domain.ErrExportExpired
+	}
+
+	parts, err := uc.repo.Parts(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	archive := map[string]interface{}{
+		"request_id":   req.ID,
+		"user_id":      req.UserID,
+		"generated_at": req.CompletedAt,
+	}
+	for _, p := range parts {
+		archive[p.Service] = p.Data
+	}
+	body, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &domain.DataExport{Request: req, Archive: body}, nil
+}
+
+// RequestAccountDeletion удаляет учетную запись сразу, а остальные сервисы
+// обезличивают данные по событию. Событие публикуется до удаления: если
+// RabbitMQ недоступен, учетная запись остается и запрос можно повторить.
+func (uc *privacyUsecase) RequestAccountDeletion(ctx context.Context, userID int, password string) (*domain.PrivacyRequest, error) {
+	u, err := uc.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := uc.hasher.Verify(u.Password, password)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	sessionIDs, err := uc.sessions.RevokeAll(ctx, userID, 0)
+	if err != nil {
+		return nil, err
+	}
+	req := &domain.PrivacyRequest{UserID: userID, Kind: domain.PrivacyDeletion, Status: domain.PrivacyPending}
+	if err := uc.repo.Create(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := uc.producer.PublishDeletionRequested(ctx, req.ID, userID, sessionIDs); err != nil {
+		return nil, err
+	}
+
+	if err := uc.users.Delete(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := uc.attempts.Delete(ctx, domain.AttemptByUsername, u.Username); err != nil {
+		slog.WarnContext(ctx, "failed to clear login attempts of deleted user", "user_id", userID, "error", err)
+	}
+	completed, err := uc.repo.SavePart(ctx, req.ID, privacyService, nil)
+	if err != nil {
+		return nil, err
+	}
+	if completed {
+		req.Status = domain.PrivacyCompleted
+	}
+	slog.InfoContext(ctx, "deleted user", "user_id", userID, "privacy_request_id", req.ID)
+	return req, nil
+}
+
+func (uc *privacyUsecase) CompletePart(ctx context.Context, requestID int, service string, data json.RawMessage) error {
+	completed, err := uc.repo.SavePart(ctx, requestID, service, data)
+	if err != nil {
+		return err
+	}
+	if completed {
+		slog.InfoContext(ctx, "privacy request completed", "privacy_request_id", requestID)
+	}
+	return nil
+}
+
+// userExport — данные UserService в архиве. Хеш пароля, секрет TOTP и
+// токены в выгрузку не попадают.
+type userExport struct {
+	Profile struct {
+		ID               int    `json:"id"`
+		Username         string `json:"username"`
+		DisplayName      string `json:"display_name"`
+		Email            string `json:"email"`
+		EmailVerified    bool   `json:"email_verified"`
+		Role             string `json:"role"`
+		TwoFactorEnabled bool   `json:"two_factor_enabled"`
+	} `json:"profile"`
+	Addresses  []addressExport  `json:"addresses"`
+	Sessions   []sessionExport  `json:"sessions"`
+	Identities []identityExport `json:"linked_accounts"`
+}
+
+type addressExport struct {
+	FullName   string    `json:"full_name"`
+	Line1      string    `json:"line1"`
+	Line2      string    `json:"line2,omitempty"`
+	City       string    `json:"city"`
+	Region     string    `json:"region,omitempty"`
+	PostalCode string    `json:"postal_code,omitempty"`
+	Country    string    `json:"country"`
+	Phone      string    `json:"phone,omitempty"`
+	IsDefault  bool      `json:"is_default"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type sessionExport struct {
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+type identityExport struct {
+	Issuer      string    `json:"issuer"`
+	Subject     string    `json:"subject"`
+	Email       string    `json:"email,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
+
+func (uc *privacyUsecase) exportUserData(ctx context.Context, userID int) (json.RawMessage, error) {
+	u, err := uc.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := uc.addresses.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := uc.sessions.ListActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := uc.identities.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	e := userExport{
+		Addresses:  make([]addressExport, 0, len(addresses)),
+		Sessions:   make([]sessionExport, 0, len(sessions)),
+		Identities: make([]identityExport, 0, len(identities)),
+	}
+	e.Profile.ID = u.ID
+	e.Profile.Username = u.Username
+	e.Profile.DisplayName = u.DisplayName
+	e.Profile.Email = u.Email
+	e.Profile.EmailVerified = u.EmailVerified
+	e.Profile.Role = u.Role
+	e.Profile.TwoFactorEnabled = u.TOTPEnabled
+	for _, a := range addresses {
+		e.Addresses = append(e.Addresses, addressExport{
+			FullName:   a.FullName,
+			Line1:      a.Line1,
+			Line2:      a.Line2,
+			City:       a.City,
+			Region:     a.Region,
+			PostalCode: a.PostalCode,
+			Country:    a.Country,
+			Phone:      a.Phone,
+			IsDefault:  a.IsDefault,
+			CreatedAt:  a.CreatedAt,
+		})
+	}
+	for _, s := range sessions {
+		e.Sessions = append(e.Sessions, sessionExport{
+			Device:     s.Device,
+			IP:         s.IP,
+			UserAgent:  s.UserAgent,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+		})
+	}
+	for _, i := range identities {
+		e.Identities = append(e.Identities, identityExport{
+			Issuer:      i.Issuer,
+			Subject:     i.Subject,
+			Email:       i.Email,
+			CreatedAt:   i.CreatedAt,
+			LastLoginAt: i.LastLoginAt,
+		})
+	}
+	return json.Marshal(e)
+}
@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"context"
+	"time"
+	"userService/internal/domain"
+)
+
+// sessionTTL — срок жизни сессии с момента входа
+const sessionTTL = 30 * 24 * time.Hour
+
+func (uc *userUsecase) CreateSession(ctx context.Context, userID int, device, ip, userAgent string) (string, *domain.Session, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	s := &domain.Session{
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		Device:    device,
+		IP:        ip,
+		UserAgent: userAgent,
+		ExpiresAt: time.Now().Add(sessionTTL),
+	}
+	if err := uc.sessions.Create(ctx, s); err != nil {
+		return "", nil, err
+	}
+	return token, s, nil
+}
+
+// ValidateSession находит действующую сессию по токену и отмечает активность
+func (uc *userUsecase) ValidateSession(ctx context.Context, token string) (*domain.Session, *domain.User, error) {
+	s, err := uc.sessions.GetActiveByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, nil, err
+	}
+	u, err := uc.repo.GetByID(ctx, s.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u.Disabled() {
+		return nil, nil, domain.ErrSessionNotFound
+	}
+	if err := uc.sessions.Touch(ctx, s.ID); err != nil {
+		return nil, nil, err
+	}
+	s.LastSeenAt = time.Now()
+	return s, u, nil
+}
+
+func (uc *userUsecase) ListSessions(ctx context.Context, userID int) ([]*domain.Session, error) {
+	return uc.sessions.ListActive(ctx, userID)
+}
+
+func (uc *userUsecase) RevokeSession(ctx context.Context, userID, sessionID int) error {
+	return uc.sessions.Revoke(ctx, userID, sessionID)
+}
+
+func (uc *userUsecase) RevokeAllSessions(ctx context.Context, userID, exceptSessionID int) ([]int, error) {
+	return uc.sessions.RevokeAll(ctx, userID, exceptSessionID)
+}
@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+	"time"
+	"userService/internal/domain"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/hotp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	totpIssuer       = "ecommerce"
+	totpChallengeTTL = 5 * time.Minute
+	// totpChallengeInterval — не чаще одного токена второго шага на пользователя
+	totpChallengeInterval = 10 * time.Second
+	totpPeriod            = 30 * time.Second
+	recoveryCodeCount     = 10
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func (uc *userUsecase) EnrollTOTP(ctx context.Context, userID int) (string, string, error) {
+	u, err := uc.repo.GetByID(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+	if u.TOTPEnabled {
+		return "", "", domain.ErrTOTPAlreadyEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: totpIssuer, AccountName: u.Username})
+	if err != nil {
+		return "", "", err
+	}
+	if err := uc.repo.SetTOTPSecret(ctx, u.ID, key.Secret()); err != nil {
+		return "", "", err
+	}
+	return key.Secret(), key.URL(), nil
+}
+
+// ConfirmTOTP включает 2FA, если код подходит к выданному секрету,
+// и возвращает резервные коды. Они показываются один раз, хранятся только хеши.
+func (uc *userUsecase) ConfirmTOTP(ctx context.Context, userID int, code string) ([]string, error) {
+	u, err := uc.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.TOTPEnabled {
+		return nil, domain.ErrTOTPAlreadyEnabled
+	}
+	if u.TOTPSecret == "" {
+		return nil, domain.ErrTOTPNotEnrolled
+	}
+	ok, err := uc.acceptTOTP(ctx, u, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, domain.ErrInvalidOTP
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		c := strings.ToLower(recoveryCodeEncoding.EncodeToString(raw))
+		codes[i] = c[:4] + "-" + c[4:]
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+	if err := uc.recovery.Replace(ctx, u.ID, hashes); err != nil {
+		return nil, err
+	}
+	if err := uc.repo.EnableTOTP(ctx, u.ID); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// VerifyTOTP — второй шаг входа: обменивает токен из TOTPRequiredError
+// и одноразовый (или резервный) код на пользователя
+func (uc *userUsecase) VerifyTOTP(ctx context.Context, challengeToken, code, ip string) (*domain.User, error) {
+	userID, err := uc.tokens.Consume(ctx, domain.TokenTOTPChallenge, hashToken(challengeToken))
+	if err != nil {
+		return nil, err
+	}
+	u, err := uc.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.checkLocked(ctx, u.Username, ip); err != nil {
+		return nil, err
+	}
+	// Учетную запись могли отключить между первым и вторым шагом входа
+	if u.Disabled() {
+		return nil, domain.ErrUserDisabled
+	}
+
+	ok, err := uc.checkSecondFactor(ctx, u, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		uc.recordFailure(ctx, u.Username, ip)
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	if err := uc.attempts.Delete(ctx, domain.AttemptByUsername, u.Username); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// issueTOTPChallenge выдает токен второго шага; у пользователя действует только
+// последний. Каждый запрос с верным паролем без кода создавал бы новую строку,
+// поэтому токены выдаются не чаще раза в totpChallengeInterval.
+func (uc *userUsecase) issueTOTPChallenge(ctx context.Context, u *domain.User) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	ok, err := uc.tokens.Reissue(ctx, &domain.UserToken{
+		UserID:    u.ID,
+		Purpose:   domain.TokenTOTPChallenge,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(totpChallengeTTL),
+	}, totpChallengeInterval)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", &domain.ChallengeThrottledError{RetryAfter: totpChallengeInterval}
+	}
+	return token, nil
+}
+
+// checkSecondFactor принимает текущий TOTP-код (с допуском в один шаг) или неиспользованный резервный код
+func (uc *userUsecase) checkSecondFactor(ctx context.Context, u *domain.User, code string) (bool, error) {
+	if code == "" {
+		return false, nil
+	}
+	ok, err := uc.acceptTOTP(ctx, u, code)
+	if err != nil || ok {
+		return ok, err
+	}
+	return uc.recovery.Consume(ctx, u.ID, hashToken(normalizeRecoveryCode(code)))
+}
+
+// acceptTOTP проверяет код на текущем шаге и соседних и принимает его, только
+// если шаг новее последнего принятого: подсмотренный или перехваченный код
+// нельзя использовать повторно, пока он еще действует (RFC 6238, 5.2)
+func (uc *userUsecase) acceptTOTP(ctx context.Context, u *domain.User, code string) (bool, error) {
+	now := time.Now().Unix() / int64(totpPeriod/time.Second)
+	for _, step := range []int64{now, now - 1, now + 1} {
+		ok, err := hotp.ValidateCustom(code, uint64(step), u.TOTPSecret, hotp.ValidateOpts{
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil || !ok {
+			continue
+		}
+		if step <= u.TOTPLastStep {
+			return false, nil
+		}
+		return uc.repo.UseTOTPStep(ctx, u.ID, step)
+	}
+	return false, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+	"userService/internal/domain"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/hotp"
+)
+
+func (f *fakeUsers) UseTOTPStep(_ context.Context, id int, step int64) (bool, error) {
+	u := f.byID[id]
+	if u.TOTPLastStep >= step {
+		return false, nil
+	}
+	u.TOTPLastStep = step
+	return true, nil
+}
+
+func TestAcceptTOTP(t *testing.T) {
+	const secret = "JBSWY3DPEHPK3PXP"
+	tests := []struct {
+		name   string
+		offset int64 // шаг кода относительно текущего
+		// lastStep — последний принятый шаг относительно текущего;
+		// stale — он принят уже после того, как пользователь был прочитан
+		lastStep int64
+		stale    bool
+		want     bool
+	}{
+		{name: "current step", offset: 0, lastStep: -10, want: true},
+		{name: "previous step", offset: -1, lastStep: -10, want: true},
+		{name: "next step", offset: 1, lastStep: -10, want: true},
+		{name: "two steps back", offset: -2, lastStep: -10, want: false},
+		{name: "two steps ahead", offset: 2, lastStep: -10, want: false},
+		{name: "reused step", offset: 0, lastStep: 0, want: false},
+		{name: "step older than the last accepted", offset: -1, lastStep: 0, want: false},
+		{name: "step used by a concurrent login", offset: 0, lastStep: 0, stale: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Не начинать у самой границы шага: код сверяется с часами еще раз
+			period := int64(totpPeriod / time.Second)
+			if left := period - time.Now().Unix()%period; left < 2 {
+				time.Sleep(time.Duration(left) * time.Second)
+			}
+			now := time.Now().Unix() / period
+
+			stored := &domain.User{ID: 1, TOTPSecret: secret, TOTPLastStep: now + tt.lastStep}
+			users := &fakeUsers{byID: map[int]*domain.User{1: stored}}
+			u := *stored
+			if tt.stale {
+				u.TOTPLastStep = now - 10
+			}
+			uc := &userUsecase{repo: users}
+
+			code, err := hotp.GenerateCodeCustom(secret, uint64(now+tt.offset), hotp.ValidateOpts{
+				Digits:    otp.DigitsSix,
+				Algorithm: otp.AlgorithmSHA1,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ok, err := uc.acceptTOTP(context.Background(), &u, code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.want {
+				t.Fatalf("acceptTOTP = %v, want %v", ok, tt.want)
+			}
+			if !ok {
+				return
+			}
+
+			// Принятый код второй раз не проходит
+			if got := stored.TOTPLastStep; got != now+tt.offset {
+				t.Errorf("last step = %d, want %d", got, now+tt.offset)
+			}
+			u = *stored
+			if ok, _ := uc.acceptTOTP(context.Background(), &u, code); ok {
+				t.Error("the same code was accepted twice")
+			}
+		})
+	}
+}
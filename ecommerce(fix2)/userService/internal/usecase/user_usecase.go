@@ -1,56 +1,246 @@
 package usecase
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strings"
 	"userService/internal/domain"
-
-	"golang.org/x/crypto/bcrypt"
+	"userService/internal/logging"
+	"userService/internal/mailer"
+	"userService/internal/message"
+	"userService/internal/metrics"
+	"userService/internal/validation"
 )
 
 type userUsecase struct {
-	repo domain.UserRepository
+	repo     domain.UserRepository
+	attempts domain.LoginAttemptRepository
+	tokens   domain.TokenRepository
+	recovery domain.RecoveryCodeRepository
+	sessions domain.SessionRepository
+	mailer   mailer.Mailer
+	producer lockPublisher
+	hasher   domain.PasswordHasher
+
+	// publicURL — адрес gateway, на который ведут ссылки из писем
+	publicURL string
+
+	// dummyHash сравнивается с паролем, если пользователя нет, чтобы время
+	// ответа не выдавало, существует ли такое имя
+	dummyHash string
 }
 
-func NewUserUsecase(r domain.UserRepository) domain.UserUsecase {
-	return &userUsecase{r}
+func NewUserUsecase(r domain.UserRepository, attempts domain.LoginAttemptRepository, tokens domain.TokenRepository, recovery domain.RecoveryCodeRepository, sessions domain.SessionRepository, m mailer.Mailer, p *message.MessageProducer, h domain.PasswordHasher, publicURL string) domain.UserUsecase {
+	dummyHash, err := h.Hash("dummy-password")
+	if err != nil {
+		logging.Fatal("failed to prepare dummy password hash", "error", err)
+	}
+	return &userUsecase{r, attempts, tokens, recovery, sessions, m, p, h, strings.TrimSuffix(publicURL, "/"), dummyHash}
 }
 
-func (uc *userUsecase) Register(username, password string) (*domain.User, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+func (uc *userUsecase) Register(ctx context.Context, username, password, email string) (*domain.User, error) {
+	var v validation.Validator
+	v.Username("username", username)
+	v.Password("password", password, username)
+	v.Email("email", email, false)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	hash, err := uc.hasher.Hash(password)
 	if err != nil {
 		return nil, err
 	}
 	u := &domain.User{
 		Username: username,
-		Password: string(hash),
+		Password: hash,
+		Role:     domain.RoleUser,
+		Email:    email,
+	}
+	if err := uc.repo.Create(ctx, u); err != nil {
+		return nil, err
 	}
-	err = uc.repo.Create(u)
+	metrics.Registrations.Inc()
+
+	// Не удалось отправить письмо — регистрация все равно состоялась
+	if u.Email != "" {
+		if err := uc.sendVerificationEmail(ctx, u); err != nil {
+			slog.ErrorContext(ctx, "failed to send verification email", "user_id", u.ID, "error", err)
+		}
+	}
+	return u, nil
+}
+
+func (uc *userUsecase) Authenticate(ctx context.Context, username, password, otp, ip string) (*domain.User, error) {
+	u, err := uc.authenticate(ctx, username, password, otp, ip)
+	metrics.Login(err)
 	return u, err
 }
 
-func (uc *userUsecase) Authenticate(username, password string) (*domain.User, error) {
-	u, err := uc.repo.GetByUsername(username)
+func (uc *userUsecase) authenticate(ctx context.Context, username, password, otp, ip string) (*domain.User, error) {
+	if err := uc.checkLocked(ctx, username, ip); err != nil {
+		return nil, err
+	}
+
+	u, err := uc.repo.GetByUsername(ctx, username)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		uc.hasher.Verify(uc.dummyHash, password)
+		uc.recordFailure(ctx, username, ip)
+		return nil, domain.ErrInvalidCredentials
+	}
+	ok, err := uc.hasher.Verify(u.Password, password)
 	if err != nil {
 		return nil, err
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)); err != nil {
+	if !ok {
+		uc.recordFailure(ctx, username, ip)
+		return nil, domain.ErrInvalidCredentials
+	}
+	uc.rehashIfNeeded(ctx, u, password)
+	// Проверяем после пароля, чтобы по ответу нельзя было узнать, что учетная запись отключена
+	if u.Disabled() {
+		return nil, domain.ErrUserDisabled
+	}
+
+	if u.TOTPEnabled {
+		if otp == "" {
+			token, err := uc.issueTOTPChallenge(ctx, u)
+			if err != nil {
+				return nil, err
+			}
+			return nil, &domain.TOTPRequiredError{ChallengeToken: token}
+		}
+		ok, err := uc.checkSecondFactor(ctx, u, otp)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			uc.recordFailure(ctx, username, ip)
+			return nil, domain.ErrInvalidCredentials
+		}
+	}
+
+	// Успешный вход сбрасывает счетчик по имени; счетчик по IP истекает сам,
+	// иначе одна рабочая учетка позволяла бы перебирать чужие пароли с того же IP
+	if err := uc.attempts.Delete(ctx, domain.AttemptByUsername, username); err != nil {
 		return nil, err
 	}
 	return u, nil
 }
 
-func (uc *userUsecase) GetProfile(id int) (*domain.User, error) {
-	return uc.repo.GetByID(id)
+func (uc *userUsecase) GetProfile(ctx context.Context, id int) (*domain.User, error) {
+	return uc.repo.GetByID(ctx, id)
 }
 
-func (uc *userUsecase) UpdateProfile(id int, username string) (*domain.User, error) {
-	user, err := uc.repo.GetByID(id)
+func (uc *userUsecase) UpdateProfile(ctx context.Context, id int, upd domain.ProfileUpdate) (*domain.User, []int, error) {
+	user, err := uc.repo.GetByID(ctx, id)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	user.Username = username
-	err = uc.repo.Update(user)
+
+	var v validation.Validator
+	if upd.Username != nil && *upd.Username != user.Username {
+		v.Username("username", *upd.Username)
+	}
+	if upd.Email != nil {
+		v.Email("email", *upd.Email, false)
+	}
+	if upd.DisplayName != nil {
+		v.DisplayName("display_name", *upd.DisplayName)
+	}
+	if upd.NewPassword != nil {
+		username := user.Username
+		if upd.Username != nil {
+			username = *upd.Username
+		}
+		v.Password("new_password", *upd.NewPassword, username)
+	}
+	if err := v.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// Пароль проверяем до любых изменений, чтобы неверный текущий пароль
+	// не оставлял профиль обновленным наполовину
+	if upd.NewPassword != nil {
+		ok, err := uc.hasher.Verify(user.Password, upd.CurrentPassword)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			return nil, nil, domain.ErrInvalidCredentials
+		}
+	}
+
+	emailChanged := false
+	if upd.Username != nil {
+		user.Username = *upd.Username
+	}
+	if upd.DisplayName != nil {
+		user.DisplayName = *upd.DisplayName
+	}
+	if upd.Email != nil && *upd.Email != user.Email {
+		user.Email = *upd.Email
+		user.EmailVerified = false
+		emailChanged = true
+	}
+	if err := uc.repo.Update(ctx, user); err != nil {
+		return nil, nil, err
+	}
+
+	var revoked []int
+	if upd.NewPassword != nil {
+		hash, err := uc.hasher.Hash(*upd.NewPassword)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := uc.repo.UpdatePassword(ctx, user.ID, hash); err != nil {
+			return nil, nil, err
+		}
+		user.Password = hash
+		// Как и при сбросе: старый пароль мог утечь, и его сессии не должны
+		// пережить смену. Остается только сессия, из которой пароль сменили.
+		if revoked, err = uc.sessions.RevokeAll(ctx, user.ID, upd.CurrentSessionID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if emailChanged && user.Email != "" {
+		if err := uc.sendVerificationEmail(ctx, user); err != nil {
+			slog.ErrorContext(ctx, "failed to send verification email", "user_id", user.ID, "error", err)
+		}
+	}
+	return user, revoked, nil
+}
+
+func (uc *userUsecase) UnlockUser(ctx context.Context, username string) error {
+	return uc.attempts.Delete(ctx, domain.AttemptByUsername, username)
+}
+
+// rehashIfNeeded пересчитывает хеш по текущим настройкам после успешной
+// проверки пароля: только в этот момент известен сам пароль. Ошибка не
+// мешает входу — хеш обновится при следующем. Хеш заменяется, только если
+// в базе все еще проверенный: иначе вход со старым паролем, начатый до его
+// смены или сброса, вернул бы старый пароль.
+func (uc *userUsecase) rehashIfNeeded(ctx context.Context, u *domain.User, password string) {
+	if !uc.hasher.NeedsRehash(u.Password) {
+		return
+	}
+	hash, err := uc.hasher.Hash(password)
 	if err != nil {
-		return nil, err
+		slog.ErrorContext(ctx, "failed to rehash password", "user_id", u.ID, "error", err)
+		return
+	}
+	replaced, err := uc.repo.ReplacePasswordHash(ctx, u.ID, u.Password, hash)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to rehash password", "user_id", u.ID, "error", err)
+		return
+	}
+	if replaced {
+		u.Password = hash
 	}
-	return user, nil
 }
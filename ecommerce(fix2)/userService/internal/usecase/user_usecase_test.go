@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"userService/internal/domain"
+	"userService/internal/hasher"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (f *fakeUsers) ReplacePasswordHash(_ context.Context, id int, oldHash, newHash string) (bool, error) {
+	u := f.byID[id]
+	if u.Password != oldHash {
+		return false, nil
+	}
+	u.Password = newHash
+	return true, nil
+}
+
+func TestRehashIfNeeded(t *testing.T) {
+	weak := hasher.NewBcrypt(bcrypt.MinCost)
+	strong := hasher.NewBcrypt(bcrypt.MinCost + 1)
+	oldHash, err := weak.Hash("old password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changedHash, err := strong.Hash("new password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		// stored — хеш в базе к моменту пересчета; у входящего пользователя oldHash
+		stored       string
+		wantRehash   bool
+		wantPassword string // пароль, который должен подходить к хешу в базе
+	}{
+		{name: "weak hash is replaced", stored: oldHash, wantRehash: true, wantPassword: "old password"},
+		{name: "password changed meanwhile", stored: changedHash, wantPassword: "new password"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUsers{byID: map[int]*domain.User{1: {ID: 1, Password: tt.stored}}}
+			uc := &userUsecase{repo: users, hasher: strong}
+
+			u := &domain.User{ID: 1, Password: oldHash}
+			uc.rehashIfNeeded(context.Background(), u, "old password")
+
+			stored := users.byID[1].Password
+			if ok, _ := strong.Verify(stored, tt.wantPassword); !ok {
+				t.Errorf("stored hash does not match %q", tt.wantPassword)
+			}
+			if rehashed := stored != tt.stored; rehashed != tt.wantRehash {
+				t.Errorf("rehashed = %v, want %v", rehashed, tt.wantRehash)
+			}
+			if strong.NeedsRehash(stored) {
+				t.Error("stored hash still uses the old cost")
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package validation
+
+import (
+	"regexp"
+	"strings"
+	"userService/internal/domain"
+)
+
+// countryRule — требования к адресу в конкретной стране
+type countryRule struct {
+	postalCode     *regexp.Regexp // nil — в стране нет почтовых индексов
+	regionRequired bool           // штат, провинция или префектура
+}
+
+var (
+	fiveDigits = regexp.MustCompile(`^\d{5}$`)
+	sixDigits  = regexp.MustCompile(`^\d{6}$`)
+	fourDigits = regexp.MustCompile(`^\d{4}$`)
+)
+
+var countryRules = map[string]countryRule{
+	"US": {postalCode: regexp.MustCompile(`^\d{5}(-\d{4})?$`), regionRequired: true},
+	"CA": {postalCode: regexp.MustCompile(`^[A-Z]\d[A-Z] ?\d[A-Z]\d$`), regionRequired: true},
+	"AU": {postalCode: fourDigits, regionRequired: true},
+	"JP": {postalCode: regexp.MustCompile(`^\d{3}-?\d{4}$`), regionRequired: true},
+	"GB": {postalCode: regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`)},
+	"DE": {postalCode: fiveDigits},
+	"FR": {postalCode: fiveDigits},
+	"IT": {postalCode: fiveDigits},
+	"ES": {postalCode: fiveDigits},
+	"NL": {postalCode: regexp.MustCompile(`^\d{4} ?[A-Z]{2}$`)},
+	"PL": {postalCode: regexp.MustCompile(`^\d{2}-\d{3}$`)},
+	"RU": {postalCode: sixDigits},
+	"BY": {postalCode: sixDigits},
+	"UZ": {postalCode: sixDigits},
+	"CN": {postalCode: sixDigits, regionRequired: true},
+	"IN": {postalCode: sixDigits, regionRequired: true},
+	// С 2023 года в Казахстане действуют индексы вида A10A0A0, старые шестизначные еще в ходу
+	"KZ": {postalCode: regexp.MustCompile(`^(\d{6}|[A-Z]\d{2}[A-Z]\d[A-Z]\d)$`)},
+	"KG": {postalCode: sixDigits},
+	"HK": {},
+	"AE": {},
+}
+
+var countryCodePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+const maxAddressField = 200
+
+// Address проверяет обязательные поля адреса с учетом правил страны. Страна
+// и индекс приводятся к верхнему регистру.
+func (v *Validator) Address(a *domain.Address) {
+	a.Country = strings.ToUpper(strings.TrimSpace(a.Country))
+	a.PostalCode = strings.ToUpper(strings.TrimSpace(a.PostalCode))
+
+	for field, value := range map[string]string{
+		"full_name": a.FullName, "line1": a.Line1, "line2": a.Line2,
+		"city": a.City, "region": a.Region, "phone": a.Phone,
+	} {
+		if len([]rune(value)) > maxAddressField {
+			v.add(field, "is too long")
+		}
+	}
+	if strings.TrimSpace(a.FullName) == "" {
+		v.add("full_name", "is required")
+	}
+	if strings.TrimSpace(a.Line1) == "" {
+		v.add("line1", "is required")
+	}
+	if strings.TrimSpace(a.City) == "" {
+		v.add("city", "is required")
+	}
+
+	if !countryCodePattern.MatchString(a.Country) {
+		v.add("country", "must be an ISO 3166-1 alpha-2 code")
+		return
+	}
+
+	rule, known := countryRules[a.Country]
+	if !known {
+		// Для остальных стран индекс необязателен и проверяется только на длину
+		if len(a.PostalCode) > 12 {
+			v.add("postal_code", "is too long")
+		}
+		return
+	}
+	if rule.regionRequired && strings.TrimSpace(a.Region) == "" {
+		v.add("region", "is required for "+a.Country)
+	}
+	switch {
+	case rule.postalCode == nil:
+		a.PostalCode = ""
+	case a.PostalCode == "":
+		v.add("postal_code", "is required for "+a.Country)
+	case !rule.postalCode.MatchString(a.PostalCode):
+		v.add("postal_code", "has invalid format for "+a.Country)
+	}
+}
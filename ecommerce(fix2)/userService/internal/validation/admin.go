@@ -0,0 +1,38 @@
+package validation
+
+import (
+	"userService/internal/domain"
+)
+
+const (
+	// DefaultPageSize — размер страницы ListUsers, если клиент его не указал
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+	maxQueryLength  = 64
+)
+
+// Role проверяет, что роль существует
+func (v *Validator) Role(field, role string) {
+	if role != domain.RoleUser && role != domain.RoleAdmin {
+		v.add(field, "must be one of: user, admin")
+	}
+}
+
+// UserFilter проверяет фильтры ListUsers
+func (v *Validator) UserFilter(f *domain.UserFilter) {
+	if f.Role != "" {
+		v.Role("role", f.Role)
+	}
+	if f.Status != "" && f.Status != domain.UserStatusActive && f.Status != domain.UserStatusDisabled {
+		v.add("status", "must be one of: active, disabled")
+	}
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && !f.CreatedBefore.After(*f.CreatedAfter) {
+		v.add("created_before", "must be later than created_after")
+	}
+	if len(f.Query) > maxQueryLength {
+		v.add("query", "is too long")
+	}
+	if f.Limit < 0 || f.Limit > MaxPageSize {
+		v.add("page_size", "must be between 1 and 200")
+	}
+}
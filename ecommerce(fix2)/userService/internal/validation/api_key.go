@@ -0,0 +1,67 @@
+package validation
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+	"userService/internal/domain"
+)
+
+const (
+	maxAPIKeyNameLength = 64
+	// MaxAPIKeyLifetime — самый долгий срок, на который можно выпустить ключ
+	MaxAPIKeyLifetime = 365 * 24 * time.Hour
+	// MaxAPIKeyGracePeriod — сколько старый ключ может действовать после ротации
+	MaxAPIKeyGracePeriod = 7 * 24 * time.Hour
+)
+
+// NewAPIKey проверяет имя, scopes и срок действия нового ключа
+func (v *Validator) NewAPIKey(k *domain.NewAPIKey) {
+	name := strings.TrimSpace(k.Name)
+	switch {
+	case name == "":
+		v.add("name", "is required")
+	case utf8.RuneCountInString(name) > maxAPIKeyNameLength:
+		v.add("name", "is too long")
+	}
+	v.APIKeyScopes("scopes", k.Scopes)
+	if k.ExpiresIn < 0 || k.ExpiresIn > MaxAPIKeyLifetime {
+		v.add("expires_in_days", "must be between 0 and 365")
+	}
+}
+
+// APIKeyScopes проверяет, что список не пуст, не содержит повторов и неизвестных scopes
+func (v *Validator) APIKeyScopes(field string, scopes []string) {
+	if len(scopes) == 0 {
+		v.add(field, "at least one scope is required")
+		return
+	}
+	seen := make(map[string]bool, len(scopes))
+	for _, s := range scopes {
+		if !knownScope(s) {
+			v.add(field, "unknown scope "+s+"; must be one of: "+strings.Join(domain.APIKeyScopes, ", "))
+			return
+		}
+		if seen[s] {
+			v.add(field, "duplicate scope "+s)
+			return
+		}
+		seen[s] = true
+	}
+}
+
+// GracePeriod проверяет, сколько старый ключ продолжит действовать после ротации
+func (v *Validator) GracePeriod(field string, d time.Duration) {
+	if d < 0 || d > MaxAPIKeyGracePeriod {
+		v.add(field, "must be between 0 and 168 hours")
+	}
+}
+
+func knownScope(scope string) bool {
+	for _, s := range domain.APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,18 @@
+package validation
+
+import (
+	"userService/internal/domain"
+)
+
+// ExternalIdentity проверяет учетную запись внешнего провайдера
+func (v *Validator) ExternalIdentity(ext *domain.ExternalIdentity) {
+	if ext.Issuer == "" {
+		v.add("issuer", "is required")
+	}
+	if ext.Subject == "" {
+		v.add("subject", "is required")
+	}
+	if ext.EmailVerified && ext.Email != "" {
+		v.Email("email", ext.Email, false)
+	}
+}
@@ -0,0 +1,142 @@
+// Package validation проверяет данные пользователя до записи в базу:
+// формат имени, зарезервированные имена, требования к паролю и email.
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 32
+	minPasswordLength = 8
+	// bcrypt учитывает только первые 72 байта пароля
+	maxPasswordBytes = 72
+	maxEmailLength   = 254
+	maxDisplayName   = 64
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_.-]*$`)
+
+// reservedUsernames нельзя занять при регистрации: они похожи на служебные
+// учетные записи или совпадают с маршрутами gateway
+var reservedUsernames = map[string]bool{
+	"admin": true, "administrator": true, "root": true, "system": true,
+	"support": true, "help": true, "security": true, "staff": true,
+	"moderator": true, "api": true, "me": true, "null": true,
+	"undefined": true, "anonymous": true, "noreply": true, "no-reply": true,
+}
+
+// commonPasswords — самые частые пароли из публичных утечек
+var commonPasswords = map[string]bool{
+	"password": true, "password1": true, "password123": true, "12345678": true,
+	"123456789": true, "1234567890": true, "qwerty123": true, "qwertyuiop": true,
+	"11111111": true, "iloveyou": true, "sunshine": true, "letmein1": true,
+	"football": true, "baseball": true, "welcome1": true, "abc12345": true,
+	"passw0rd": true, "trustno1": true, "superman": true, "1q2w3e4r": true,
+}
+
+// FieldError — нарушение правила для одного поля
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Errors — все нарушения, найденные в запросе
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Validator накапливает ошибки по полям
+type Validator struct {
+	errs Errors
+}
+
+func (v *Validator) add(field, msg string) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: msg})
+}
+
+// Err возвращает Errors или nil, если нарушений нет
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// Username проверяет длину, допустимые символы и зарезервированные имена
+func (v *Validator) Username(field, username string) {
+	switch {
+	case len(username) < minUsernameLength || len(username) > maxUsernameLength:
+		v.add(field, fmt.Sprintf("must be %d to %d characters long", minUsernameLength, maxUsernameLength))
+	case !usernamePattern.MatchString(username):
+		v.add(field, "must start with a letter and contain only letters, digits, '_', '.' or '-'")
+	case reservedUsernames[strings.ToLower(username)]:
+		v.add(field, "is reserved")
+	}
+}
+
+// Password проверяет требования к паролю: длину, хотя бы одну букву и одну
+// цифру или символ, отсутствие в списке частых паролей и имени пользователя внутри
+func (v *Validator) Password(field, password, username string) {
+	var hasLetter, hasOther bool
+	for _, r := range password {
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		} else {
+			hasOther = true
+		}
+	}
+
+	lower := strings.ToLower(password)
+	switch {
+	case len([]rune(password)) < minPasswordLength:
+		v.add(field, fmt.Sprintf("must be at least %d characters long", minPasswordLength))
+	case len(password) > maxPasswordBytes:
+		v.add(field, fmt.Sprintf("must be at most %d bytes long", maxPasswordBytes))
+	case !hasLetter || !hasOther:
+		v.add(field, "must contain a letter and a digit or symbol")
+	case commonPasswords[lower]:
+		v.add(field, "is too common")
+	case username != "" && strings.Contains(lower, strings.ToLower(username)):
+		v.add(field, "must not contain the username")
+	}
+}
+
+// Email проверяет адрес; пустой адрес допустим, если required == false
+func (v *Validator) Email(field, email string, required bool) {
+	if email == "" {
+		if required {
+			v.add(field, "is required")
+		}
+		return
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || len(email) > maxEmailLength {
+		v.add(field, "must be a valid email address")
+	}
+}
+
+// DisplayName ограничивает длину и запрещает управляющие символы
+func (v *Validator) DisplayName(field, name string) {
+	if len([]rune(name)) > maxDisplayName {
+		v.add(field, fmt.Sprintf("must be at most %d characters long", maxDisplayName))
+		return
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			v.add(field, "must not contain control characters")
+			return
+		}
+	}
+}